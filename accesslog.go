@@ -0,0 +1,143 @@
+package fastapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Chendemo12/fastapi/openapi"
+)
+
+// HeaderRequestID 请求ID请求头和响应头
+const HeaderRequestID = "X-Request-ID"
+
+// 请求头中的请求ID最大长度, 超出时重新生成
+const maxRequestIDLength = 128
+
+// 请求头中的请求ID需为可见的ASCII字符, 以免写入响应头和日志时被篡改格式
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// AccessLogFormat 访问日志格式
+type AccessLogFormat string
+
+const (
+	AccessLogText AccessLogFormat = "text" // key=value 格式
+	AccessLogJSON AccessLogFormat = "json" // 每条日志为一个JSON对象
+)
+
+// AccessLogConfig 访问日志配置
+type AccessLogConfig struct {
+	Format AccessLogFormat       `json:"format" description:"日志格式, 默认为 text"`
+	Logger LoggerIface           `json:"-" description:"日志输出, 以 Info 级别写入, 默认为 ReplaceLogger 设置的全局日志"`
+	Skip   func(c *Context) bool `json:"-" description:"返回 true 时不记录此请求"`
+}
+
+// AccessLog 一条访问日志
+type AccessLog struct {
+	RequestID        string  `json:"request_id" description:"请求ID"`
+	Route            string  `json:"route" description:"路由标识 RouteIface.Id, 由请求方法和路由模式组成, 而非请求Url, 与 metrics 的 route 标签一致, 未匹配到路由时为空"`
+	Status           int     `json:"status" description:"响应状态码"`
+	Latency          float64 `json:"latency_ms" description:"处理耗时, 单位毫秒"`
+	ClientIP         string  `json:"client_ip" description:"客户端IP"`
+	RequestSize      int64   `json:"request_size" description:"请求体字节数, 取自 Content-Length"`
+	ResponseSize     int64   `json:"response_size" description:"响应体字节数, 路由器未实现 ResponseSizer 时为-1"`
+	ValidationErrors int     `json:"validation_errors" description:"请求或响应校验失败的字段数"`
+}
+
+func (l *AccessLog) String() string {
+	route := l.Route
+	if route == "" {
+		route = "-"
+	}
+	return fmt.Sprintf("request_id=%s route=%s status=%d latency=%.3fms client_ip=%s request_size=%d response_size=%d validation_errors=%d",
+		l.RequestID, route, l.Status, l.Latency, l.ClientIP, l.RequestSize, l.ResponseSize, l.ValidationErrors)
+}
+
+// 访问日志记录器
+type accessLogger struct {
+	conf AccessLogConfig
+}
+
+// 在写入响应之前执行, 添加请求ID响应头
+func (a *accessLogger) beforeWrite(c *Context) {
+	c.muxCtx.Header(HeaderRequestID, c.RequestID())
+}
+
+// 在写入响应之后执行, 输出一条访问日志
+func (a *accessLogger) log(c *Context) {
+	if entry := a.entry(c); entry != nil {
+		a.output(entry)
+	}
+}
+
+// 由 Context 生成访问日志, 被 AccessLogConfig.Skip 跳过时返回nil;
+// 对于异步写入的响应流, 需在 MuxContext 被回收之前生成, 写入结束后再更新耗时和响应体字节数
+func (a *accessLogger) entry(c *Context) *AccessLog {
+	if a.conf.Skip != nil && a.conf.Skip(c) {
+		return nil
+	}
+
+	entry := &AccessLog{
+		RequestID:    c.RequestID(),
+		Status:       c.response.StatusCode,
+		Latency:      float64(time.Since(c.startedAt).Microseconds()) / 1000,
+		ClientIP:     c.muxCtx.ClientIP(),
+		ResponseSize: -1,
+		Route:        c.RouteId(),
+	}
+	if size, err := strconv.ParseInt(c.muxCtx.GetHeader("Content-Length"), 10, 64); err == nil {
+		entry.RequestSize = size
+	}
+	if sizer, ok := c.muxCtx.(ResponseSizer); ok {
+		entry.ResponseSize = sizer.ResponseSize()
+	}
+	if ve, ok := c.response.Content.(*openapi.HTTPValidationError); ok {
+		entry.ValidationErrors = len(ve.Detail)
+	}
+
+	return entry
+}
+
+func (a *accessLogger) output(entry *AccessLog) {
+	logger := a.conf.Logger
+	if logger == nil {
+		logger = console
+	}
+	if a.conf.Format == AccessLogJSON {
+		bs, _ := json.Marshal(entry)
+		logger.Info(string(bs))
+	} else {
+		logger.Info(entry.String())
+	}
+}
+
+// RequestID 此次请求的唯一ID, 优先使用请求头 X-Request-ID, 不存在时随机生成;
+// 启用访问日志后, 请求ID会通过 X-Request-ID 响应头返回
+func (c *Context) RequestID() string {
+	if c.requestId != "" {
+		return c.requestId
+	}
+
+	id := c.muxCtx.GetHeader(HeaderRequestID)
+	if !validRequestID(id) {
+		bs := make([]byte, 16)
+		_, _ = rand.Read(bs)
+		id = hex.EncodeToString(bs)
+	}
+	c.requestId = id
+
+	return id
+}
@@ -6,6 +6,7 @@ package fastapi
 import (
 	"github.com/Chendemo12/fastapi/openapi"
 	"github.com/Chendemo12/fastapi/utils"
+	"github.com/Chendemo12/fastapi/websocket"
 )
 
 //goland:noinspection GoUnusedGlobalVariable
@@ -16,6 +17,12 @@ type Ctx = Context
 
 type BaseRouter = BaseGroupRouter
 
+// HTTPError 通用的HTTP错误, 路由函数返回此错误时, 默认的 RouteErrorFormatter 会以其状态码响应
+type HTTPError = openapi.HTTPError
+
+// WebSocketConn websocket 路由的连接, 消息类型和关闭码等定义于 websocket 包
+type WebSocketConn = websocket.Conn
+
 // None 可用于POST/PATH/PUT方法的占位
 type None struct{}
 
@@ -25,6 +32,7 @@ func (*None) SchemaDesc() string { return "Empty Request Content" }
 var (
 	ReflectObjectType = utils.ReflectObjectType
 	SetJsonEngine     = utils.SetJsonEngine
+	NewHTTPError      = openapi.NewHTTPError
 )
 
 //goland:noinspection GoUnusedGlobalVariable
@@ -3,6 +3,7 @@ package fastapi
 import (
 	"context"
 	"fmt"
+	"math/rand/v2"
 	"net"
 	"os"
 	"os/signal"
@@ -15,6 +16,7 @@ import (
 
 var one = &sync.Once{}
 var wrapper *Wrapper = nil // 默认实例
+var lazyInitOnce = &sync.Once{}
 
 // EventKind 事件类型
 type EventKind string
@@ -48,7 +50,15 @@ type Wrapper struct {
 	previousDeps        []DependenceHandle  `description:"在接口参数校验前执行的依赖函数"`
 	afterDeps           []DependenceHandle  `description:"在接口参数校验成功后执行的依赖函数(相当于路由函数前钩子)"`
 	beforeWrite         func(c *Context)    `description:"在数据写入响应流之前执行的钩子方法"`
+	onPanic             PanicHandle         `description:"路由发生panic时的钩子方法"`
 	routeErrorFormatter RouteErrorFormatter `description:"handle返回错误时的格式化方法"`
+	allowMethods        map[string]string   `description:"路径 -> Allow 响应头"`
+	security            []SecurityScheme    `description:"全部路由的默认认证方案"`
+	securitySchemes     []SecurityScheme    `description:"已声明的认证方案, 初始化后包含路由使用的全部认证方案"`
+	cors                *cors               `description:"跨域资源共享配置"`
+	accessLog           *accessLogger       `description:"访问日志"`
+	observers           []RequestObserver   `description:"请求观察者"`
+	initOnce            sync.Once           `description:"确保仅初始化一次"`
 }
 
 type FastApi = Wrapper
@@ -73,7 +83,10 @@ type Config struct {
 	// 当此设置被开启后，在遇到一个错误的参数时，会立刻停止终止流程，直接返回错误消息
 	StopImmediatelyWhenErrorOccurs     bool `json:"stopImmediatelyWhenErrorOccurs" description:"是否在遇到错误字段时立刻停止校验"`
 	ContextAutomaticDerivationDisabled bool `json:"contextAutomaticDerivationDisabled,omitempty" description:"禁止为每一个请求创建单独的Context"`
-	DisableResponseValidate            bool `json:"disableResponseValidate" description:"是否禁用响应参数校验，仅JSON类型有效"`
+	DisableResponseValidate            bool `json:"disableResponseValidate" description:"是否禁用响应参数校验，仅JSON类型有效, 等同于 ResponseValidateOff"`
+	// 响应参数校验模式, 默认为 ResponseValidateAlways; 对于 ResponseValidateSampled 需同时设置采样率
+	ResponseValidateMode       ResponseValidateMode `json:"responseValidateMode,omitempty" description:"响应参数校验模式"`
+	ResponseValidateSampleRate int                  `json:"responseValidateSampleRate,omitempty" description:"响应参数校验的采样率(百分比), 取值1-100, 仅 sampled 模式有效"`
 
 	host string
 	port string
@@ -89,6 +102,8 @@ func (c *Config) Copy() *Config {
 		ContextAutomaticDerivationDisabled: c.ContextAutomaticDerivationDisabled,
 		StopImmediatelyWhenErrorOccurs:     c.StopImmediatelyWhenErrorOccurs,
 		DisableResponseValidate:            c.DisableResponseValidate,
+		ResponseValidateMode:               c.ResponseValidateMode,
+		ResponseValidateSampleRate:         c.ResponseValidateSampleRate,
 		host:                               c.host,
 		port:                               c.port,
 	}
@@ -96,6 +111,50 @@ func (c *Config) Copy() *Config {
 
 func (c *Config) ListenAddr() string { return net.JoinHostPort(c.host, c.port) }
 
+// ResponseValidateMode 响应参数校验模式
+type ResponseValidateMode string
+
+const (
+	ResponseValidateAlways  ResponseValidateMode = "always"   // 校验每一个响应, 校验失败时返回422
+	ResponseValidateSampled ResponseValidateMode = "sampled"  // 按照采样率校验部分响应, 校验失败时返回422
+	ResponseValidateLogOnly ResponseValidateMode = "log-only" // 校验每一个响应, 校验失败时仅记录日志, 仍然返回原始响应
+	ResponseValidateOff     ResponseValidateMode = "off"      // 不校验响应
+)
+
+// 检查响应参数校验模式的配置
+func (c *Config) checkResponseValidate() error {
+	switch c.ResponseValidateMode {
+	case "", ResponseValidateAlways, ResponseValidateLogOnly, ResponseValidateOff:
+		return nil
+	case ResponseValidateSampled:
+		if c.ResponseValidateSampleRate < 1 || c.ResponseValidateSampleRate > 100 {
+			return fmt.Errorf("response validate sample rate must be in [1, 100], got %d", c.ResponseValidateSampleRate)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported response validate mode: '%s'", c.ResponseValidateMode)
+	}
+}
+
+// 本次请求实际的响应参数校验模式, 对于 sampled 模式, 未被采样时为 off, 反之为 always
+func (c *Config) responseValidateMode() ResponseValidateMode {
+	if c.DisableResponseValidate {
+		return ResponseValidateOff
+	}
+
+	switch c.ResponseValidateMode {
+	case "":
+		return ResponseValidateAlways
+	case ResponseValidateSampled:
+		if rand.IntN(100) < c.ResponseValidateSampleRate {
+			return ResponseValidateAlways
+		}
+		return ResponseValidateOff
+	default:
+		return c.ResponseValidateMode
+	}
+}
+
 // 初始化路由, 必须在路由添加完成，swagger注册之前调用
 func (f *Wrapper) initRoutes() *Wrapper {
 	var err error
@@ -103,11 +162,19 @@ func (f *Wrapper) initRoutes() *Wrapper {
 	for _, group := range f.groupRouters {
 		// 必须先设置参数，再 Init 初始化
 		group.errorFormatter = f.routeErrorFormatter
+		group.security = f.security
 
 		err = group.Init()
 		if err != nil {
 			panic(fmt.Errorf("group-router: '%s' created failld, %v", group.String(), err))
 		}
+		f.securitySchemes = append(f.securitySchemes, group.schemes...)
+	}
+
+	// 文档中的认证方案以名称引用, 因此名称不允许重复
+	f.securitySchemes, err = uniqueSecuritySchemes(append(f.securitySchemes, f.security...))
+	if err != nil {
+		panic(err)
 	}
 
 	return f
@@ -120,6 +187,9 @@ func (f *Wrapper) initFinder() *Wrapper {
 		for _, r := range group.Routes() {
 			routes = append(routes, r)
 		}
+		for _, r := range group.WebSocketRoutes() {
+			routes = append(routes, r)
+		}
 	}
 
 	// 初始化finder
@@ -149,8 +219,16 @@ func (f *Wrapper) initMux() *Wrapper {
 			err = f.mux.BindRoute(route.Swagger().Method, route.Swagger().Url, f.Handler)
 			if err != nil {
 				// 此时日志已初始化完毕
-				Errorf(
-					"route: '%s:%s' bind failed, %v", route.Swagger().Method, route.Swagger().Url, err,
+				structured.Error("route bind failed",
+					"method", route.Swagger().Method, "path", route.Swagger().Url, "error", err,
+				)
+			}
+		}
+		for _, route := range group.WebSocketRoutes() {
+			err = f.bindWebSocketRoute(route)
+			if err != nil {
+				structured.Error("route bind failed",
+					"method", route.Swagger().Method, "path", route.Swagger().Url, "error", err,
 				)
 			}
 		}
@@ -161,6 +239,11 @@ func (f *Wrapper) initMux() *Wrapper {
 
 // 初始化Wrapper,并完成服务依赖的建立，启动前，必须显式的初始化Wrapper的基本配置，若初始化中发生异常则panic
 func (f *Wrapper) initialize() *Wrapper {
+	f.initOnce.Do(f.doInitialize)
+	return f
+}
+
+func (f *Wrapper) doInitialize() {
 	if f.mux == nil {
 		panic("mux is not initialized")
 	}
@@ -174,15 +257,21 @@ func (f *Wrapper) initialize() *Wrapper {
 		return c
 	}}
 
-	SetJsonEngine(jsoniter.ConfigCompatibleWithStandardLibrary)
-	LazyInit()
+	if err := f.conf.checkResponseValidate(); err != nil {
+		panic(err)
+	}
+
+	// 全局的校验器等仅需初始化一次, 以允许多个 Wrapper 实例并发初始化
+	lazyInitOnce.Do(func() {
+		SetJsonEngine(jsoniter.ConfigCompatibleWithStandardLibrary)
+		LazyInit()
+	})
 
 	f.initRoutes()
 	f.initFinder()
 	f.initMux()
+	f.initMethods()
 	f.initSwagger() // === 必须最后调用
-
-	return f
 }
 
 // ================================ Api ================================
@@ -198,6 +287,11 @@ func (f *Wrapper) Context() context.Context { return f.ctx }
 // Mux 获取路由器
 func (f *Wrapper) Mux() MuxWrapper { return f.mux }
 
+// Init 完成路由解析、路由绑定和文档创建，但不启动服务, 多次调用仅首次有效
+// 通常无需显式调用, Run 会自动完成初始化; 适用于在没有网络监听的情况下(例如测试)驱动 Wrapper.Handler
+// 必须在 SetMux 和 IncludeRouter 之后调用
+func (f *Wrapper) Init() *Wrapper { return f.initialize() }
+
 // SetMux 设置路由器，必须在启动之前设置
 func (f *Wrapper) SetMux(mux MuxWrapper) *Wrapper {
 	f.mux = mux
@@ -246,12 +340,64 @@ func (f *Wrapper) UseAfter(hooks ...DependenceHandle) *Wrapper {
 	return f
 }
 
-// UseBeforeWrite 在数据写入响应流之前执行的钩子方法; 可用于日志记录, 所有请求无论何时终止都会执行此方法
+// AddSecurityScheme 声明认证方案, 仅显示在文档的 securitySchemes 中, 不作用于任何路由;
+// 可在依赖函数中通过 Security 使用
+func (f *Wrapper) AddSecurityScheme(schemes ...SecurityScheme) *Wrapper {
+	f.securitySchemes = append(f.securitySchemes, schemes...)
+	return f
+}
+
+// UseSecurity 设置全部路由的默认认证方案, 满足其一即可, 凭证可通过 Context.Credentials 获取;
+// 认证依赖函数在 UseAfter 之后, 路由组依赖函数之前执行, 可被路由组的 GroupSecurity 和 RouteSecurity 覆盖
+func (f *Wrapper) UseSecurity(schemes ...SecurityScheme) *Wrapper {
+	f.security = append(f.security, schemes...)
+	return f
+}
+
+// UseCORS 启用跨域资源共享, 适用于全部 MuxWrapper;
+// 预检请求由自动注册的 OPTIONS 路由响应, Access-Control-Allow-Methods 为此路径已注册的请求方法, 不会进入依赖函数和路由函数;
+// 其他请求(包括404和405)在来源被允许时添加跨域响应头; AllowCredentials 不可与 * 来源同时使用, 否则 panic
+func (f *Wrapper) UseCORS(conf CORSConfig) *Wrapper {
+	f.cors = newCORS(conf)
+	return f
+}
+
+// UseAccessLog 启用访问日志, 适用于全部 MuxWrapper;
+// 在 UseBeforeWrite 钩子之前添加 X-Request-ID 响应头, 并在响应写入之后通过 LoggerIface 输出一条访问日志,
+// 包含请求ID、路由ID、状态码、耗时、请求和响应的字节数以及校验失败的字段数
+func (f *Wrapper) UseAccessLog(conf ...AccessLogConfig) *Wrapper {
+	f.accessLog = &accessLogger{}
+	if len(conf) > 0 {
+		f.accessLog.conf = conf[0]
+	}
+	return f
+}
+
+// UseObserver 添加请求观察者, 可用于指标统计等场景, 适用于全部 MuxWrapper;
+// 仅观察已注册的路由, 不包括 websocket 路由、预检请求以及404和405响应
+func (f *Wrapper) UseObserver(observers ...RequestObserver) *Wrapper {
+	f.observers = append(f.observers, observers...)
+	return f
+}
+
+// UseBeforeWrite 在数据写入响应流之前执行的钩子方法; 可用于日志记录, 所有请求无论何时终止都会执行此方法;
+// 对于404和405响应 Context 不包含路由信息, Context.RouteId 为空字符串
 func (f *Wrapper) UseBeforeWrite(fc func(c *Context)) *Wrapper {
 	f.beforeWrite = fc
 	return f
 }
 
+// OnPanic 设置路由发生panic时的钩子方法, 可用于日志记录或告警, 默认通过结构化日志输出错误和调用栈;
+// 此方法执行之后会通过 RouteErrorFormatter 返回500响应, 对于已升级协议的 websocket 路由则以 CloseInternalServerErr 关闭连接
+func (f *Wrapper) OnPanic(fc PanicHandle) *Wrapper {
+	if fc == nil {
+		Warn("panic handle is nil, ignore")
+		return f
+	}
+	f.onPanic = fc
+	return f
+}
+
 // Use 添加一个依赖函数(锚点), 数据校验后依赖函数
 //
 // 由于 Wrapper 的核心实现类似于装饰器, 而非常规的中间件,因此无法通过 MuxWrapper 的中间件来影响到 Wrapper 的执行过程;
@@ -262,9 +408,10 @@ func (f *Wrapper) UseBeforeWrite(fc func(c *Context)) *Wrapper {
 //		而此处的hook不返回值，而是通过 Context.Set 和 Context.Get 来进行上下文数据的传递，并通过返回 error 来终止后续的流程;
 //		同时，由于 Context.Set 和 Context.Get 是线程安全的，因此可以放心的在依赖函数中操作 Context;
 //	   	依赖函数的执行始终是顺序进行的，其执行顺序是固定的：
-//	   	始终由 UsePrevious -> (请求参数)Validate -> UseAfter -> (路由函数)RouteHandler -> (响应参数)Validate -> UseBeforeWrite -> exit;
+//	   	始终由 UsePrevious -> (请求参数)Validate -> UseAfter -> (限流)RateLimit -> (认证)Security -> (鉴权)Scopes -> (按凭证限流)PrincipalRateLimit -> (路由组依赖函数)Dependencies -> (依赖注入)Provide -> (路由函数)RouteHandler -> (响应参数)Validate -> UseBeforeWrite -> exit;
 //
 // 此处的依赖函数有校验前依赖函数和校验后依赖函数,分别通过 Wrapper.UsePrevious 和 Wrapper.UseAfter 注册;
+// 仅作用于部分路由的依赖函数, 可通过路由组实现 GroupDependencies 和 RouteDependencies 接口来注册;
 // 当请求参数校验失败时不会执行 Wrapper.UseAfter 依赖函数, 请求参数会在 Wrapper.UsePrevious 执行完成之后被触发;
 // 如果依赖函数要终止后续的流程,应返回 error, 错误消息会作为消息体返回给客户端, 响应数据格式默认为500+string,可通过 Wrapper.SetRouteErrorFormatter 进行修改;
 func (f *Wrapper) Use(hooks ...DependenceHandle) *Wrapper {
@@ -313,9 +460,20 @@ func (f *Wrapper) DisableResponseValidate() *Wrapper {
 	return f
 }
 
+// SetResponseValidateMode 设置响应参数校验模式, sampleRate 为 ResponseValidateSampled 模式下的采样率(百分比)
+//
+//	app.SetResponseValidateMode(fastapi.ResponseValidateSampled, 10) // 仅校验10%的响应
+func (f *Wrapper) SetResponseValidateMode(mode ResponseValidateMode, sampleRate ...int) *Wrapper {
+	f.conf.ResponseValidateMode = mode
+	if len(sampleRate) > 0 {
+		f.conf.ResponseValidateSampleRate = sampleRate[0]
+	}
+	return f
+}
+
 // Shutdown 平滑关闭
 func (f *Wrapper) Shutdown() {
-	Debug("ready to shutdown...")
+	structured.Debug("ready to shutdown", "title", f.conf.Title)
 
 	// 执行关机前事件
 	for _, event := range f.events {
@@ -326,7 +484,9 @@ func (f *Wrapper) Shutdown() {
 
 	err := f.mux.ShutdownWithTimeout(time.Duration(f.conf.ShutdownTimeout) * time.Second)
 	if err != nil {
-		Warnf("graceful shutdown failed, err: %s", err)
+		structured.Warn("graceful shutdown failed",
+			"timeout", time.Duration(f.conf.ShutdownTimeout)*time.Second, "error", err,
+		)
 	}
 
 	f.cancel() // 停止所有请求，需最后关闭
@@ -339,7 +499,7 @@ func (f *Wrapper) Shutdown() {
 func (f *Wrapper) Run(host, port string) {
 	f.conf.host = host
 	f.conf.port = port
-	Debugf("%s starting...", f.Config().Title)
+	structured.Debug("server starting", "title", f.Config().Title)
 
 	f.initialize()
 
@@ -352,7 +512,7 @@ func (f *Wrapper) Run(host, port string) {
 
 	f.isStarted <- struct{}{} // 解除阻塞上层的任务
 	addr := net.JoinHostPort(f.conf.host, f.conf.port)
-	Debug("http server listening on: " + addr)
+	structured.Debug("http server listening", "addr", addr)
 
 	close(f.isStarted)
 
@@ -395,6 +555,9 @@ func cleanConfig(cs ...Config) Config {
 		conf.DisableSwagAutoCreate = cs[0].DisableSwagAutoCreate
 		conf.StopImmediatelyWhenErrorOccurs = cs[0].StopImmediatelyWhenErrorOccurs
 		conf.ContextAutomaticDerivationDisabled = cs[0].ContextAutomaticDerivationDisabled
+		conf.DisableResponseValidate = cs[0].DisableResponseValidate
+		conf.ResponseValidateMode = cs[0].ResponseValidateMode
+		conf.ResponseValidateSampleRate = cs[0].ResponseValidateSampleRate
 	}
 
 	return conf
@@ -416,6 +579,7 @@ func New(c Config) *Wrapper {
 	}
 	app.ctx, app.cancel = context.WithCancel(context.Background())
 	app.beforeWrite = func(c *Context) {}
+	app.onPanic = defaultPanicHandle
 
 	if conf.Description != "" {
 		app.SetDescription(conf.Description)
@@ -0,0 +1,159 @@
+package fastapi
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// 跨域资源共享响应头
+const (
+	HeaderOrigin                        = "Origin"
+	HeaderVary                          = "Vary"
+	HeaderAccessControlRequestMethod    = "Access-Control-Request-Method"
+	HeaderAccessControlRequestHeaders   = "Access-Control-Request-Headers"
+	HeaderAccessControlAllowOrigin      = "Access-Control-Allow-Origin"
+	HeaderAccessControlAllowMethods     = "Access-Control-Allow-Methods"
+	HeaderAccessControlAllowHeaders     = "Access-Control-Allow-Headers"
+	HeaderAccessControlAllowCredentials = "Access-Control-Allow-Credentials"
+	HeaderAccessControlExposeHeaders    = "Access-Control-Expose-Headers"
+	HeaderAccessControlMaxAge           = "Access-Control-Max-Age"
+)
+
+// CORSConfig 跨域资源共享配置
+type CORSConfig struct {
+	// 允许的来源, "*" 表示任意来源; 支持一个通配符, 例如 "https://*.example.com"
+	AllowOrigins     []string                 `json:"allow_origins" description:"允许的来源"`
+	AllowOriginFunc  func(origin string) bool `json:"-" description:"自定义的来源校验函数, 与 AllowOrigins 满足其一即可"`
+	AllowHeaders     []string                 `json:"allow_headers" description:"预检请求允许的请求头, 为空则允许预检请求声明的全部请求头"`
+	ExposeHeaders    []string                 `json:"expose_headers" description:"允许浏览器读取的响应头"`
+	AllowCredentials bool                     `json:"allow_credentials" description:"是否允许携带 cookie 等凭证, 不可与 * 来源同时使用"`
+	MaxAge           int                      `json:"max_age" description:"预检请求的缓存时间, 单位秒, 0 则不设置"`
+}
+
+// 解析后的跨域配置
+type cors struct {
+	conf     CORSConfig
+	any      bool       // 允许任意来源
+	exact    []string   // 精确匹配的来源
+	patterns [][]string // 含通配符的来源, [前缀, 后缀]
+}
+
+// 解析跨域配置, 浏览器不接受携带凭证的 * 来源, 因此 AllowCredentials 与 * 来源同时设置时 panic
+func newCORS(conf CORSConfig) *cors {
+	c := &cors{conf: conf}
+	for _, origin := range conf.AllowOrigins {
+		switch {
+		case origin == "*":
+			c.any = true
+		case strings.Contains(origin, "*"):
+			prefix, suffix, _ := strings.Cut(strings.ToLower(origin), "*")
+			c.patterns = append(c.patterns, []string{prefix, suffix})
+		default:
+			c.exact = append(c.exact, strings.ToLower(origin))
+		}
+	}
+	if c.any && conf.AllowCredentials {
+		panic("cors: AllowCredentials is not allowed with wildcard origin '*', specify the allowed origins instead")
+	}
+	return c
+}
+
+// 来源是否被允许
+func (c *cors) allowed(origin string) bool {
+	if c.any {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	if slices.Contains(c.exact, lower) {
+		return true
+	}
+	for _, p := range c.patterns {
+		if len(lower) > len(p[0])+len(p[1]) && strings.HasPrefix(lower, p[0]) && strings.HasSuffix(lower, p[1]) {
+			return true
+		}
+	}
+	return c.conf.AllowOriginFunc != nil && c.conf.AllowOriginFunc(origin)
+}
+
+// 为跨域请求添加响应头, 来源不被允许时不添加任何跨域响应头, 由浏览器拦截;
+// 除 * 来源外, 响应内容均取决于请求来源, 因此无论来源是否被允许均添加 Vary: Origin, 以免被缓存复用
+func (c *cors) writeHeaders(ctx MuxContext) bool {
+	if !c.any {
+		addVary(ctx, HeaderOrigin)
+	}
+	origin := ctx.GetHeader(HeaderOrigin)
+	if origin == "" || !c.allowed(origin) {
+		return false
+	}
+
+	if c.any {
+		ctx.Header(HeaderAccessControlAllowOrigin, "*")
+	} else {
+		ctx.Header(HeaderAccessControlAllowOrigin, origin)
+	}
+	if c.conf.AllowCredentials {
+		ctx.Header(HeaderAccessControlAllowCredentials, "true")
+	}
+	if len(c.conf.ExposeHeaders) > 0 {
+		ctx.Header(HeaderAccessControlExposeHeaders, strings.Join(c.conf.ExposeHeaders, ", "))
+	}
+	return true
+}
+
+// 响应预检请求, 允许的请求方法为此路径已注册的请求方法
+func (c *cors) writePreflight(ctx MuxContext, allowMethods string) {
+	allowed := c.writeHeaders(ctx)
+	addVary(ctx, HeaderOrigin, HeaderAccessControlRequestMethod, HeaderAccessControlRequestHeaders)
+	if !allowed {
+		return
+	}
+
+	ctx.Header(HeaderAccessControlAllowMethods, allowMethods)
+	if len(c.conf.AllowHeaders) > 0 {
+		ctx.Header(HeaderAccessControlAllowHeaders, strings.Join(c.conf.AllowHeaders, ", "))
+	} else if headers := ctx.GetHeader(HeaderAccessControlRequestHeaders); headers != "" {
+		ctx.Header(HeaderAccessControlAllowHeaders, headers)
+	}
+	if c.conf.MaxAge > 0 {
+		ctx.Header(HeaderAccessControlMaxAge, strconv.Itoa(c.conf.MaxAge))
+	}
+}
+
+// 是否为预检请求
+func isPreflight(ctx MuxContext) bool {
+	return ctx.Method() == http.MethodOptions && ctx.GetHeader(HeaderOrigin) != "" &&
+		ctx.GetHeader(HeaderAccessControlRequestMethod) != ""
+}
+
+// 将 tokens 合并到响应头 Vary 中, 仅添加缺少的值; 路由器未实现 ResponseHeaderReader 时直接覆盖
+func addVary(ctx MuxContext, tokens ...string) {
+	var values []string
+	if reader, ok := ctx.(ResponseHeaderReader); ok {
+		for _, v := range strings.Split(reader.ResponseHeader(HeaderVary), ",") {
+			if v = strings.TrimSpace(v); v == "*" { // 已经随任意请求头变化
+				return
+			} else if v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+
+	n := len(values)
+	for _, token := range tokens {
+		exist := false
+		for _, v := range values {
+			if strings.EqualFold(v, token) {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			values = append(values, token)
+		}
+	}
+	if n == 0 || len(values) > n {
+		ctx.Header(HeaderVary, strings.Join(values, ", "))
+	}
+}
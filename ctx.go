@@ -2,6 +2,8 @@ package fastapi
 
 import (
 	"context"
+	"log/slog"
+	"reflect"
 	"sync"
 	"time"
 
@@ -17,22 +19,32 @@ import (
 //	Context 的任何引用都是不对的, 若需在return之后监听 Context.Context() 则应该显式的复制或派生
 type Context struct {
 	muxCtx      MuxContext         `description:"路由器Context"`
+	route       RouteIface         `description:"匹配到的路由, 请求路径不存在或请求方法不被允许时为nil"`
 	appCtx      context.Context    `description:"根context"`
 	routeCtx    context.Context    `description:"获取针对此次请求的唯一context"`
 	routeCancel context.CancelFunc `description:"获取针对此次请求的唯一取消函数"`
 	// 存储路径参数, 路径参数类型全部为字符串类型, 路径参数都是肯定存在的
 	pathFields map[string]string `description:"路径参数"`
+	// 声明了类型的路径参数会按照与查询参数相同的规则进行转换
+	pathValues map[string]any `description:"类型转换后的路径参数"`
 	// 对于查询参数，参数类型会按照以下规则进行转换：
 	// 	int 	=> int64
 	// 	uint 	=> uint64
 	// 	float 	=> float64
 	//	string 	=> string
 	// 	bool 	=> bool
-	queryFields  map[string]any `description:"查询参数, 仅记录存在值的查询参数"`
-	queryStruct  any            `description:"结构体查询参数"`
-	requestModel any            `description:"请求体"`
+	//	[]T 	=> []any, 元素按照上述规则转换
+	queryFields  map[string]any                 `description:"查询参数, 仅记录存在值的查询参数"`
+	queryStructs []any                          `description:"结构体查询参数, 按照函数入参的顺序排列"`
+	requestModel any                            `description:"请求体"`
+	provided     map[reflect.Type]reflect.Value `description:"依赖注入的提供者返回值, 提供的类型:返回值"`
 	file         *File
-	response     *Response `description:"返回值,以减少函数间复制的开销"`
+	response     *Response     `description:"返回值,以减少函数间复制的开销"`
+	streamDone   chan struct{} `description:"响应流异步写入的结束信号, 不为nil时需待其结束后再释放 Context"`
+	written      bool          `description:"响应是否已经开始写入"`
+	startedAt    time.Time     `description:"请求开始处理的时间"`
+	requestId    string        `description:"请求ID"`
+	logger       *slog.Logger  `description:"携带请求信息的结构化日志"`
 	// This mutex protects Keys map.
 	locker sync.RWMutex
 	// 每个请求专有的K/V
@@ -40,10 +52,11 @@ type Context struct {
 }
 
 // 申请一个 Context 并初始化
-func (f *Wrapper) acquireCtx(ctx MuxContext) *Context {
+func (f *Wrapper) acquireCtx(ctx MuxContext, route RouteIface) *Context {
 	c := f.pool.Get().(*Context)
 	// 初始化各种参数
 	c.muxCtx = ctx
+	c.route = route
 	c.response = AcquireResponse()
 	// 为每一个路由创建一个独立的ctx, 允许不启用此功能
 	if !f.conf.ContextAutomaticDerivationDisabled {
@@ -51,8 +64,14 @@ func (f *Wrapper) acquireCtx(ctx MuxContext) *Context {
 	}
 	c.appCtx = f.ctx
 	c.pathFields = map[string]string{}
+	c.pathValues = map[string]any{}
 	c.queryFields = map[string]any{}
 	c.file = nil
+	c.streamDone = nil
+	c.written = false
+	c.startedAt = time.Now()
+	c.requestId = ""
+	c.logger = nil
 	c.locker = sync.RWMutex{}
 
 	return c
@@ -63,14 +82,22 @@ func (f *Wrapper) releaseCtx(ctx *Context) {
 	ReleaseResponse(ctx.response)
 
 	ctx.muxCtx = nil
+	ctx.route = nil
 	ctx.appCtx = nil
 	ctx.routeCtx = nil
 	ctx.routeCancel = nil
 	ctx.requestModel = nil
+	ctx.queryStructs = nil
+	ctx.provided = nil
 	ctx.file = nil
+	ctx.streamDone = nil
+	ctx.written = false
+	ctx.requestId = ""
+	ctx.logger = nil
 	ctx.response = nil // 释放内存
 
 	ctx.pathFields = nil
+	ctx.pathValues = nil
 	ctx.queryFields = nil
 	ctx.Keys = nil
 
@@ -82,6 +109,15 @@ func (f *Wrapper) releaseCtx(ctx *Context) {
 // MuxContext 获取web引擎的上下文
 func (c *Context) MuxContext() MuxContext { return c.muxCtx }
 
+// RouteId 匹配到的路由标识, 与访问日志和 Context.Logger 中的 route 一致;
+// 请求路径不存在(404)或请求方法不被允许(405)时没有匹配的路由, 返回空字符串
+func (c *Context) RouteId() string {
+	if c.route == nil {
+		return ""
+	}
+	return c.route.Id()
+}
+
 // MX shortcut web引擎的上下文
 func (c *Context) MX() any { return c.muxCtx.Ctx() }
 
@@ -109,6 +145,9 @@ func (c *Context) Done() <-chan struct{} {
 	}
 }
 
+// LastEventID 读取 Last-Event-ID 请求头, 即 SSE 客户端重连前收到的最后一个事件ID, 不存在则为空字符串
+func (c *Context) LastEventID() string { return c.muxCtx.GetHeader(HeaderLastEventID) }
+
 // Query 获取查询参数
 // 对于已经在路由处定义的查询参数，首先从 Context.queryFields 内部读取
 // 对于没有定义的其他查询参数则调用低层 MuxContext 进行解析
@@ -120,6 +159,7 @@ func (c *Context) Done() <-chan struct{} {
 //	float 	=> float64
 //	string 	=> string
 //	bool 	=> bool
+//	[]T 	=> []any, 元素按照上述规则转换
 func (c *Context) Query(name string, undefined ...string) any {
 	v, ok := c.queryFields[name]
 	if ok {
@@ -141,6 +181,36 @@ func (c *Context) PathField(name string, undefined ...string) string {
 	return c.muxCtx.Params(name, undefined...)
 }
 
+// PathValue 获取类型转换后的路径参数, 对于声明了类型的路径参数(例如: /api/user/:id<int>)，参数类型会按照以下规则进行转换：
+//
+//	int 	=> int64
+//	uint 	=> uint64
+//	float 	=> float64
+//	string 	=> string
+//	bool 	=> bool
+//
+// 未声明类型的路径参数则与 PathField 一致
+func (c *Context) PathValue(name string) any {
+	v, ok := c.pathValues[name]
+	if ok {
+		return v
+	}
+
+	return c.PathField(name)
+}
+
+// PathInt 获取声明为有符号整数类型的路径参数, 类型不匹配时返回0
+func (c *Context) PathInt(name string) int64 {
+	v, _ := c.pathValues[name].(int64)
+	return v
+}
+
+// PathUint 获取声明为无符号整数类型的路径参数, 类型不匹配时返回0
+func (c *Context) PathUint(name string) uint64 {
+	v, _ := c.pathValues[name].(uint64)
+	return v
+}
+
 // Set 存储一个键值对，延迟初始化 ！仅当 MuxContext 未实现此类方法时采用！
 // Set is used to store a new key/value pair exclusively for this context.
 // It also lazy initializes  c.Keys if it was not used previously.
@@ -219,9 +289,21 @@ func (c *Context) GetTime(key string) (t time.Time) {
 	return
 }
 
+// Credentials 认证通过后的凭证, 未经认证则返回nil
+func (c *Context) Credentials() *Credentials {
+	if v, ok := c.Get(CredentialsKey); ok {
+		credentials, _ := v.(*Credentials)
+		return credentials
+	}
+	return nil
+}
+
 // Response 响应体，配合 Wrapper.UseBeforeWrite 实现在依赖函数中读取响应体内容，以进行日志记录等 ！慎重对 Response 进行修改！
 func (c *Context) Response() *Response { return c.response }
 
+// StartedAt 请求开始处理的时间
+func (c *Context) StartedAt() time.Time { return c.startedAt }
+
 // ================================ 路由组路由方法 ================================
 
 // Status 修改成功响应的状态码
@@ -0,0 +1,115 @@
+package fastapi
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+
+	"github.com/Chendemo12/fastapi/openapi"
+)
+
+// 脱离路由器的 MuxContext, 用于路由器回收其 Context 之后仍需访问请求信息的场景:
+// websocket 路由在协议升级之后, 以及响应流在 MuxHandler 返回之后异步写入时; 响应相关的方法均不可用
+type detachedMuxContext struct {
+	method   string
+	path     string
+	clientIP string
+	params   map[string]string
+	req      *http.Request
+	query    url.Values
+}
+
+var errMuxContextDetached = errors.New("mux context detached, response is not available")
+
+// 复制 ctx 中的请求信息, req 为请求的副本, 为nil时仅保留请求方法、路径、客户端IP和路径参数
+func newDetachedMuxContext(ctx MuxContext, req *http.Request, params map[string]string) *detachedMuxContext {
+	if req == nil {
+		req = &http.Request{Method: ctx.Method(), Header: http.Header{}}
+	}
+	c := &detachedMuxContext{
+		method:   ctx.Method(),
+		path:     ctx.Path(),
+		clientIP: ctx.ClientIP(),
+		params:   make(map[string]string, len(params)),
+		req:      req,
+		query:    url.Values{},
+	}
+	for k, v := range params {
+		c.params[k] = v
+	}
+	if req.URL != nil {
+		c.query = req.URL.Query()
+	}
+	return c
+}
+
+func (c *detachedMuxContext) Method() string { return c.method }
+func (c *detachedMuxContext) Path() string   { return c.path }
+
+// Ctx 请求的副本
+func (c *detachedMuxContext) Ctx() any { return c.req }
+
+func (c *detachedMuxContext) Set(key string, value any) {}
+
+func (c *detachedMuxContext) Get(key string) (value any, exists bool) { return nil, false }
+
+func (c *detachedMuxContext) ClientIP() string { return c.clientIP }
+
+func (c *detachedMuxContext) ContentType() string {
+	return c.req.Header.Get(openapi.HeaderContentType)
+}
+
+func (c *detachedMuxContext) GetHeader(key string) string { return c.req.Header.Get(key) }
+
+func (c *detachedMuxContext) Cookie(name string) (string, error) {
+	cookie, err := c.req.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return cookie.Value, nil
+}
+
+func (c *detachedMuxContext) Params(key string, undefined ...string) string {
+	if v := c.params[key]; v != "" || len(undefined) == 0 {
+		return v
+	}
+	return undefined[0]
+}
+
+func (c *detachedMuxContext) Query(key string, undefined ...string) string {
+	if v := c.query.Get(key); v != "" || len(undefined) == 0 {
+		return v
+	}
+	return undefined[0]
+}
+
+// QueryArray 实现 QueryArrayReader
+func (c *detachedMuxContext) QueryArray(key string) []string { return c.query[key] }
+
+func (c *detachedMuxContext) MultipartForm() (*multipart.Form, error) {
+	return nil, errMuxContextDetached
+}
+
+func (c *detachedMuxContext) ShouldBind(obj any) (validated bool, err error) {
+	return false, errMuxContextDetached
+}
+
+func (c *detachedMuxContext) Header(key, value string)      {}
+func (c *detachedMuxContext) SetCookie(cookie *http.Cookie) {}
+func (c *detachedMuxContext) Status(code int)               {}
+
+func (c *detachedMuxContext) Redirect(code int, location string) error { return errMuxContextDetached }
+func (c *detachedMuxContext) SendString(s string) error                { return errMuxContextDetached }
+func (c *detachedMuxContext) JSON(code int, data any) error            { return errMuxContextDetached }
+func (c *detachedMuxContext) File(filepath string) error               { return errMuxContextDetached }
+func (c *detachedMuxContext) Write(p []byte) (int, error)              { return 0, errMuxContextDetached }
+
+func (c *detachedMuxContext) SendStream(stream io.Reader, size ...int) error {
+	return errMuxContextDetached
+}
+
+func (c *detachedMuxContext) FileAttachment(filepath, filename string) error {
+	return errMuxContextDetached
+}
@@ -0,0 +1,127 @@
+package fastapitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Chendemo12/fastapi"
+	"github.com/Chendemo12/fastapi/openapi"
+)
+
+// 记录 Info 日志的 logger
+type recordLogger struct {
+	fastapi.LoggerIface
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *recordLogger) Info(args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprint(args...))
+}
+
+func (l *recordLogger) last() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.lines) == 0 {
+		return ""
+	}
+	return l.lines[len(l.lines)-1]
+}
+
+func TestAccessLog(t *testing.T) {
+	logger := &recordLogger{LoggerIface: fastapi.NewDefaultLogger()}
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&BookRouter{}).IncludeRouter(&TenantRouter{}).IncludeRouter(&TaskRouter{})
+	app.UseAccessLog(fastapi.AccessLogConfig{Format: fastapi.AccessLogJSON, Logger: logger})
+	var hookID string
+	app.UseBeforeWrite(func(c *fastapi.Context) {
+		hookID = c.RequestID()
+	})
+	client := NewClient(app)
+
+	t.Run("propagate", func(t *testing.T) {
+		resp := client.Post("/api/book/info", &Book{Id: 2, Title: "go"}, WithHeader(fastapi.HeaderRequestID, "req-1"))
+		if resp.StatusCode != http.StatusOK || resp.Header.Get(fastapi.HeaderRequestID) != "req-1" || hookID != "req-1" {
+			t.Fatalf("status = %d, X-Request-ID = %q, hook = %q", resp.StatusCode, resp.Header.Get(fastapi.HeaderRequestID), hookID)
+		}
+
+		entry := &fastapi.AccessLog{}
+		if err := json.Unmarshal([]byte(logger.last()), entry); err != nil {
+			t.Fatalf("log = %s, error = %v", logger.last(), err)
+		}
+		if entry.RequestID != "req-1" || entry.Route != openapi.CreateRouteIdentify(http.MethodPost, "/api/book/info") || entry.Status != http.StatusOK ||
+			entry.RequestSize == 0 || entry.ResponseSize != int64(len(resp.Body)) || entry.ValidationErrors != 0 || entry.Latency < 0 {
+			t.Errorf("log = %s", logger.last())
+		}
+	})
+
+	t.Run("generate", func(t *testing.T) {
+		for _, id := range []string{"", "bad id", strings.Repeat("a", 129)} {
+			resp := client.Get("/api/book/info", WithHeader(fastapi.HeaderRequestID, id))
+			got := resp.Header.Get(fastapi.HeaderRequestID)
+			if len(got) != 32 || !strings.Contains(logger.last(), got) {
+				t.Errorf("X-Request-ID = %q, log = %s", got, logger.last())
+			}
+		}
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		resp := client.Get("/api/tenant/info", WithHeader("X-Api-Version", "1"))
+		if resp.StatusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("status = %d", resp.StatusCode)
+		}
+		entry := &fastapi.AccessLog{}
+		_ = json.Unmarshal([]byte(logger.last()), entry)
+		if entry.Route != openapi.CreateRouteIdentify(http.MethodGet, "/api/tenant/info") || entry.Status != http.StatusUnprocessableEntity || entry.ValidationErrors != 1 {
+			t.Errorf("log = %s", logger.last())
+		}
+	})
+
+	t.Run("event-stream", func(t *testing.T) {
+		// 访问日志在响应流写入结束后输出, 包含整个响应流的字节数
+		resp := client.Get("/api/task/progress")
+		entry := &fastapi.AccessLog{}
+		_ = json.Unmarshal([]byte(logger.last()), entry)
+		if entry.Route != openapi.CreateRouteIdentify(http.MethodGet, "/api/task/progress") ||
+			entry.Status != http.StatusOK || entry.ResponseSize != int64(len(resp.Body)) || !strings.Contains(resp.String(), "id: 3") {
+			t.Errorf("log = %s, body = %s", logger.last(), resp.String())
+		}
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		client.Get("/api/book/unknown")
+		entry := &fastapi.AccessLog{}
+		_ = json.Unmarshal([]byte(logger.last()), entry)
+		if entry.Route != "" || entry.Status != http.StatusNotFound {
+			t.Errorf("log = %s", logger.last())
+		}
+	})
+}
+
+func TestAccessLog_Text(t *testing.T) {
+	logger := &recordLogger{LoggerIface: fastapi.NewDefaultLogger()}
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&BookRouter{})
+	app.UseAccessLog(fastapi.AccessLogConfig{
+		Logger: logger,
+		Skip:   func(c *fastapi.Context) bool { return c.MuxContext().Method() == http.MethodDelete },
+	})
+	client := NewClient(app)
+
+	client.Get("/api/book/info", WithHeader(fastapi.HeaderRequestID, "req-2"))
+	want := "request_id=req-2 route=" + openapi.CreateRouteIdentify(http.MethodGet, "/api/book/info") + " status=200 latency="
+	if !strings.HasPrefix(logger.last(), want) || !strings.Contains(logger.last(), "validation_errors=0") {
+		t.Errorf("log = %s, want prefix %s", logger.last(), want)
+	}
+
+	client.Delete("/api/book/info")
+	if len(logger.lines) != 1 {
+		t.Errorf("skipped request should not be logged: %v", logger.lines)
+	}
+}
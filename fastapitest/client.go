@@ -0,0 +1,183 @@
+// Package fastapitest 提供了无需网络监听即可驱动 fastapi.Wrapper 的测试客户端
+//
+//	# Usage
+//
+//	app := fastapi.New(fastapi.Config{})
+//	app.IncludeRouter(&ExampleRouter{})
+//
+//	client := fastapitest.NewClient(app)
+//	resp := client.Get("/api/example/app-title?name=lee")
+//	resp.StatusCode // 200
+//	resp.JSON(&v)
+package fastapitest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	"github.com/Chendemo12/fastapi"
+	"github.com/Chendemo12/fastapi/middleware/stdWrapper"
+	"github.com/Chendemo12/fastapi/openapi"
+	"github.com/Chendemo12/fastapi/utils"
+)
+
+// Client 进程内测试客户端
+// 请求不经过网络, 而是直接构造 http.Request 并交由内存中的 stdWrapper.StdMux 分发, 响应写入 httptest.ResponseRecorder
+//
+// 每一个 Client 拥有独立的 Wrapper 和路由器, 因此不同的 Client 可以在并行测试中使用
+type Client struct {
+	app *fastapi.Wrapper
+	mux *stdWrapper.StdMux
+}
+
+// NewClient 创建测试客户端, 并完成 Wrapper 的初始化(路由、查找器和文档)
+// 此方法会将 Wrapper 的路由器替换为 stdWrapper.StdMux，因此必须在注册完全部路由之后调用
+func NewClient(app *fastapi.Wrapper) *Client {
+	mux := stdWrapper.Default()
+	app.SetMux(mux).Init()
+
+	return &Client{app: app, mux: mux}
+}
+
+// App 被测试的 Wrapper
+func (c *Client) App() *fastapi.Wrapper { return c.app }
+
+// Handler 内部的路由器, 可用于 httptest.NewServer 等需要 http.Handler 的场景
+func (c *Client) Handler() http.Handler { return c.mux }
+
+// RequestOption 修改请求的选项
+type RequestOption func(req *http.Request)
+
+// WithHeader 设置请求头
+func WithHeader(key, value string) RequestOption {
+	return func(req *http.Request) {
+		req.Header.Set(key, value)
+	}
+}
+
+// WithCookie 添加cookie
+func WithCookie(cookie *http.Cookie) RequestOption {
+	return func(req *http.Request) {
+		req.AddCookie(cookie)
+	}
+}
+
+// WithContentType 设置请求体类型, 当请求体不是json时需设置
+func WithContentType(contentType string) RequestOption {
+	return WithHeader(openapi.HeaderContentType, contentType)
+}
+
+func (c *Client) Get(url string, opts ...RequestOption) *Response {
+	return c.Request(http.MethodGet, url, nil, opts...)
+}
+
+func (c *Client) Delete(url string, opts ...RequestOption) *Response {
+	return c.Request(http.MethodDelete, url, nil, opts...)
+}
+
+func (c *Client) Post(url string, body any, opts ...RequestOption) *Response {
+	return c.Request(http.MethodPost, url, body, opts...)
+}
+
+func (c *Client) Put(url string, body any, opts ...RequestOption) *Response {
+	return c.Request(http.MethodPut, url, body, opts...)
+}
+
+func (c *Client) Patch(url string, body any, opts ...RequestOption) *Response {
+	return c.Request(http.MethodPatch, url, body, opts...)
+}
+
+func (c *Client) Head(url string, opts ...RequestOption) *Response {
+	return c.Request(http.MethodHead, url, nil, opts...)
+}
+
+func (c *Client) Options(url string, opts ...RequestOption) *Response {
+	return c.Request(http.MethodOptions, url, nil, opts...)
+}
+
+// Request 发起一个请求
+//
+//	body 可以是 nil, []byte, string, io.Reader 或任意可json序列化的对象;
+//	对于后者会自动设置 Content-Type 为 application/json
+func (c *Client) Request(method, url string, body any, opts ...RequestOption) *Response {
+	var reader io.Reader
+	isJson := false
+
+	switch v := body.(type) {
+	case nil:
+	case []byte:
+		reader = bytes.NewReader(v)
+	case string:
+		reader = strings.NewReader(v)
+	case io.Reader:
+		reader = v
+	default:
+		bs, err := utils.JsonMarshal(v)
+		if err != nil {
+			panic("fastapitest: marshal request body failed, " + err.Error())
+		}
+		reader = bytes.NewReader(bs)
+		isJson = true
+	}
+
+	req := httptest.NewRequest(method, url, reader)
+	if req.ContentLength > 0 { // 与 net/http 服务端一致, 请求头中保留 Content-Length
+		req.Header.Set("Content-Length", strconv.FormatInt(req.ContentLength, 10))
+	}
+	if isJson {
+		req.Header.Set(openapi.HeaderContentType, string(openapi.MIMEApplicationJSON))
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	return c.Do(req)
+}
+
+// Do 发送一个自定义的请求
+func (c *Client) Do(req *http.Request) *Response {
+	recorder := httptest.NewRecorder()
+	c.mux.ServeHTTP(recorder, req)
+
+	result := recorder.Result()
+	resp := &Response{
+		StatusCode: result.StatusCode,
+		Header:     result.Header,
+		Body:       recorder.Body.Bytes(),
+	}
+	if req.Method == http.MethodHead { // 与 http.Server 一致, 丢弃 HEAD 请求的响应体
+		resp.Body = nil
+	}
+	return resp
+}
+
+// Response 测试请求的响应
+type Response struct {
+	Header     http.Header
+	Body       []byte
+	StatusCode int
+}
+
+// JSON 将响应体反序列化到 v
+func (r *Response) JSON(v any) error {
+	return utils.JsonUnmarshal(r.Body, v)
+}
+
+// String 以字符串形式返回响应体
+func (r *Response) String() string { return string(r.Body) }
+
+// ValidationError 将响应体解析为422校验错误, 如果响应状态码不是422则返回nil
+func (r *Response) ValidationError() *openapi.HTTPValidationError {
+	if r.StatusCode != http.StatusUnprocessableEntity {
+		return nil
+	}
+	ve := &openapi.HTTPValidationError{}
+	if err := r.JSON(ve); err != nil {
+		return nil
+	}
+	return ve
+}
@@ -0,0 +1,133 @@
+package fastapitest
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Chendemo12/fastapi"
+)
+
+type PageReq struct {
+	PageNum  int    `json:"pageNum" query:"pageNum" validate:"required,gte=1"`
+	Keyword  string `json:"keyword" query:"keyword"`
+	PageSize int    `json:"pageSize" query:"pageSize"`
+}
+
+type UserForm struct {
+	Name string `json:"name" validate:"required"`
+	Age  int    `json:"age"`
+}
+
+type UserRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *UserRouter) Prefix() string { return "/api/user" }
+
+func (r *UserRouter) Path() map[string]string {
+	return map[string]string{"GetDetail": "detail/:id"}
+}
+
+func (r *UserRouter) GetList(c *fastapi.Context, page *PageReq) (*PageReq, error) {
+	return page, nil
+}
+
+func (r *UserRouter) GetDetail(c *fastapi.Context) (string, error) {
+	return c.PathField("id"), nil
+}
+
+func (r *UserRouter) PostCreate(c *fastapi.Context, form *UserForm) (*UserForm, error) {
+	return form, nil
+}
+
+func (r *UserRouter) GetError(c *fastapi.Context) (string, error) {
+	c.Status(http.StatusBadRequest)
+	return "", errors.New("bad request")
+}
+
+func newTestClient() *Client {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&UserRouter{})
+	return NewClient(app)
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+	client := newTestClient()
+
+	t.Run("struct-query", func(t *testing.T) {
+		resp := client.Get("/api/user/list?pageNum=2&keyword=go")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+		page := &PageReq{}
+		if err := resp.JSON(page); err != nil {
+			t.Fatal(err)
+		}
+		if page.PageNum != 2 || page.Keyword != "go" {
+			t.Errorf("page = %+v", page)
+		}
+	})
+
+	t.Run("query-validate-failed", func(t *testing.T) {
+		resp := client.Get("/api/user/list?pageNum=0")
+		ve := resp.ValidationError()
+		if ve == nil || len(ve.Detail) == 0 {
+			t.Fatalf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+	})
+
+	t.Run("path-param", func(t *testing.T) {
+		resp := client.Get("/api/user/detail/12")
+		if resp.StatusCode != http.StatusOK || resp.String() != "12" {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+	})
+
+	t.Run("request-body", func(t *testing.T) {
+		resp := client.Post("/api/user/create", &UserForm{Name: "lee", Age: 18})
+		form := &UserForm{}
+		if err := resp.JSON(form); err != nil || form.Name != "lee" {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+
+		resp = client.Post("/api/user/create", `{"age": 18}`)
+		if resp.ValidationError() == nil {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+	})
+
+	t.Run("route-error", func(t *testing.T) {
+		resp := client.Get("/api/user/error")
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+	})
+
+	t.Run("openapi", func(t *testing.T) {
+		resp := client.Get("/openapi.json")
+		doc := map[string]any{}
+		if err := resp.JSON(&doc); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := doc["paths"].(map[string]any)["/api/user/list"]; !ok {
+			t.Errorf("openapi.json missing route: %s", resp.String())
+		}
+	})
+}
+
+func TestClient_Parallel(t *testing.T) {
+	t.Parallel()
+	client := newTestClient()
+
+	for i := 0; i < 4; i++ {
+		t.Run("parallel", func(t *testing.T) {
+			t.Parallel()
+			resp := client.Get("/api/user/detail/1")
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+			}
+		})
+	}
+}
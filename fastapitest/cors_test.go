@@ -0,0 +1,206 @@
+package fastapitest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Chendemo12/fastapi"
+)
+
+func preflight(origin, method string) []RequestOption {
+	return []RequestOption{
+		WithHeader(fastapi.HeaderOrigin, origin),
+		WithHeader(fastapi.HeaderAccessControlRequestMethod, method),
+		WithHeader(fastapi.HeaderAccessControlRequestHeaders, "Content-Type, X-Token"),
+	}
+}
+
+func TestCORS(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&BookRouter{}).IncludeRouter(&ShelfRouter{})
+	app.UseCORS(fastapi.CORSConfig{
+		AllowOrigins:     []string{"https://app.example.com", "https://*.example.org"},
+		AllowOriginFunc:  func(origin string) bool { return origin == "http://localhost:3000" },
+		ExposeHeaders:    []string{"X-Total"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	})
+	client := NewClient(app)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		opts        []RequestOption
+		wantStatus  int
+		wantHeaders map[string]string
+	}{
+		{
+			name: "preflight", method: http.MethodOptions, path: "/api/book/info",
+			opts:       preflight("https://app.example.com", http.MethodPost),
+			wantStatus: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Methods":     "GET, HEAD, POST, DELETE, OPTIONS",
+				"Access-Control-Allow-Headers":     "Content-Type, X-Token",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Max-Age":           "600",
+				"Vary":                             "Origin, Access-Control-Request-Method, Access-Control-Request-Headers",
+			},
+		},
+		{
+			name: "preflight-pattern", method: http.MethodOptions, path: "/api/book/info",
+			opts:        preflight("https://a.b.example.org", http.MethodGet),
+			wantStatus:  http.StatusNoContent,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "https://a.b.example.org"},
+		},
+		{
+			name: "preflight-func", method: http.MethodOptions, path: "/api/book/info",
+			opts:        preflight("http://localhost:3000", http.MethodGet),
+			wantStatus:  http.StatusNoContent,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "http://localhost:3000"},
+		},
+		{
+			name: "preflight-denied", method: http.MethodOptions, path: "/api/book/info",
+			opts:        preflight("https://example.org", http.MethodGet),
+			wantStatus:  http.StatusNoContent,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "", "Access-Control-Allow-Methods": "", "Allow": "GET, HEAD, POST, DELETE, OPTIONS"},
+		},
+		{
+			name: "preflight-custom-options", method: http.MethodOptions, path: "/api/shelf/item",
+			opts:        preflight("https://app.example.com", http.MethodPost),
+			wantStatus:  http.StatusNoContent,
+			wantHeaders: map[string]string{"Access-Control-Allow-Methods": "POST, OPTIONS"},
+		},
+		{
+			name: "custom-options", method: http.MethodOptions, path: "/api/shelf/item",
+			opts:        []RequestOption{WithHeader(fastapi.HeaderOrigin, "https://app.example.com")},
+			wantStatus:  http.StatusOK,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "https://app.example.com", "Access-Control-Allow-Methods": ""},
+		},
+		{
+			name: "simple", method: http.MethodGet, path: "/api/book/info",
+			opts:       []RequestOption{WithHeader(fastapi.HeaderOrigin, "https://app.example.com")},
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "X-Total",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			name: "simple-denied", method: http.MethodGet, path: "/api/book/info",
+			opts:        []RequestOption{WithHeader(fastapi.HeaderOrigin, "https://evil.com")},
+			wantStatus:  http.StatusOK,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "", "Vary": "Origin"},
+		},
+		{
+			name: "same-origin", method: http.MethodGet, path: "/api/book/info",
+			wantStatus:  http.StatusOK,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "", "Vary": "Origin"},
+		},
+		{
+			name: "not-found", method: http.MethodGet, path: "/api/book/unknown",
+			opts:        []RequestOption{WithHeader(fastapi.HeaderOrigin, "https://app.example.com")},
+			wantStatus:  http.StatusNotFound,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "https://app.example.com"},
+		},
+		{
+			name: "method-not-allowed", method: http.MethodPut, path: "/api/book/info",
+			opts:        []RequestOption{WithHeader(fastapi.HeaderOrigin, "https://app.example.com")},
+			wantStatus:  http.StatusMethodNotAllowed,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "https://app.example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body any
+			if tt.method == http.MethodPut {
+				body = &Book{}
+			}
+			resp := client.Request(tt.method, tt.path, body, tt.opts...)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", resp.StatusCode, tt.wantStatus, resp.String())
+			}
+			for key, want := range tt.wantHeaders {
+				if got := resp.Header.Get(key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCORS_AnyOrigin(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&BookRouter{})
+	app.UseCORS(fastapi.CORSConfig{AllowOrigins: []string{"*"}, AllowHeaders: []string{"Content-Type"}})
+	client := NewClient(app)
+
+	resp := client.Options("/api/book/info", preflight("https://any.com", http.MethodDelete)...)
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Headers"); got != "Content-Type" {
+		t.Errorf("Access-Control-Allow-Headers = %q", got)
+	}
+	if resp.Header.Get("Access-Control-Allow-Credentials") != "" || resp.Header.Get("Access-Control-Max-Age") != "" {
+		t.Errorf("unexpected headers: %v", resp.Header)
+	}
+
+	resp = client.Get("/api/book/info")
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" || strings.Contains(resp.Header.Get("Vary"), "Origin") {
+		t.Errorf("same-origin request should not have CORS headers: %v", resp.Header)
+	}
+}
+
+func TestCORS_WildcardCredentials(t *testing.T) {
+	defer func() {
+		if v := recover(); v == nil {
+			t.Error("AllowCredentials with wildcard origin should panic")
+		}
+	}()
+	fastapi.New(fastapi.Config{Title: "fastapitest"}).
+		UseCORS(fastapi.CORSConfig{AllowOrigins: []string{"https://app.example.com", "*"}, AllowCredentials: true})
+}
+
+func TestCORS_MergeVary(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&BookRouter{})
+	app.UseCORS(fastapi.CORSConfig{AllowOrigins: []string{"https://app.example.com"}})
+	client := NewClient(app)
+	// 模拟路由器之前的中间件(例如压缩)已设置 Vary
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		w.Header().Add("Vary", "origin")
+		client.Handler().ServeHTTP(w, r)
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		opts   []RequestOption
+		want   string
+	}{
+		{"simple", http.MethodGet, []RequestOption{WithHeader(fastapi.HeaderOrigin, "https://app.example.com")},
+			"Accept-Encoding, origin"},
+		{"preflight", http.MethodOptions, preflight("https://app.example.com", http.MethodGet),
+			"Accept-Encoding, origin, Access-Control-Request-Method, Access-Control-Request-Headers"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/book/info", nil)
+			for _, opt := range tt.opts {
+				opt(req)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if got := strings.Join(rec.Header().Values("Vary"), ", "); got != tt.want {
+				t.Errorf("Vary = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
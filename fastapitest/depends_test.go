@@ -0,0 +1,131 @@
+package fastapitest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Chendemo12/fastapi"
+)
+
+// 记录依赖函数的执行顺序
+func trace(name string) fastapi.DependenceHandle {
+	return func(c *fastapi.Context) error {
+		c.Set("trace", c.GetString("trace")+name+",")
+		return nil
+	}
+}
+
+func requireToken(c *fastapi.Context) error {
+	if c.MuxContext().GetHeader("X-Token") == "" {
+		return fastapi.NewHTTPError(http.StatusUnauthorized)
+	}
+	return nil
+}
+
+func requireAdmin(c *fastapi.Context) error {
+	if c.MuxContext().GetHeader("X-Token") != "admin" {
+		return fastapi.NewHTTPError(http.StatusForbidden)
+	}
+	return nil
+}
+
+type AccountRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *AccountRouter) Prefix() string { return "/api/account" }
+
+func (r *AccountRouter) Dependencies() []fastapi.DependenceHandle {
+	return []fastapi.DependenceHandle{trace("group"), requireToken}
+}
+
+func (r *AccountRouter) RouteDependencies() map[string][]fastapi.DependenceHandle {
+	return map[string][]fastapi.DependenceHandle{
+		"DeleteUser": {trace("route"), requireAdmin},
+	}
+}
+
+func (r *AccountRouter) GetUser(c *fastapi.Context) (string, error) {
+	return c.GetString("trace"), nil
+}
+
+func (r *AccountRouter) DeleteUser(c *fastapi.Context) (string, error) {
+	return c.GetString("trace"), nil
+}
+
+type TypoRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *TypoRouter) RouteDependencies() map[string][]fastapi.DependenceHandle {
+	return map[string][]fastapi.DependenceHandle{"GetUsr": {requireToken}}
+}
+
+func (r *TypoRouter) GetUser(c *fastapi.Context) (string, error) { return "", nil }
+
+func TestGroupDependencies(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&AccountRouter{}).IncludeRouter(&BookRouter{})
+	app.UsePrevious(trace("previous"))
+	app.UseAfter(trace("after"))
+	client := NewClient(app)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "group", method: http.MethodGet, path: "/api/account/user", token: "user", wantStatus: http.StatusOK, wantBody: "previous,after,group,"},
+		{name: "group-reject", method: http.MethodGet, path: "/api/account/user", wantStatus: http.StatusUnauthorized},
+		{name: "route", method: http.MethodDelete, path: "/api/account/user", token: "admin", wantStatus: http.StatusOK, wantBody: "previous,after,group,route,"},
+		{name: "route-reject", method: http.MethodDelete, path: "/api/account/user", token: "user", wantStatus: http.StatusForbidden},
+		{name: "other-group", method: http.MethodGet, path: "/api/book/info", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []RequestOption
+			if tt.token != "" {
+				opts = append(opts, WithHeader("X-Token", tt.token))
+			}
+			resp := client.Request(tt.method, tt.path, nil, opts...)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", resp.StatusCode, tt.wantStatus, resp.String())
+			}
+			if tt.wantBody != "" && resp.String() != tt.wantBody {
+				t.Errorf("body = %s, want %s", resp.String(), tt.wantBody)
+			}
+		})
+	}
+
+	t.Run("openapi", func(t *testing.T) {
+		doc := map[string]any{}
+		if err := client.Get("/openapi.json").JSON(&doc); err != nil {
+			t.Fatal(err)
+		}
+		item := doc["paths"].(map[string]any)["/api/account/user"].(map[string]any)
+		desc := item["delete"].(map[string]any)["description"].(string)
+		if !strings.Contains(desc, "fastapitest.requireToken") || !strings.Contains(desc, "fastapitest.requireAdmin") {
+			t.Errorf("delete description = %q", desc)
+		}
+		desc = item["get"].(map[string]any)["description"].(string)
+		if strings.Contains(desc, "requireAdmin") {
+			t.Errorf("get description = %q", desc)
+		}
+	})
+}
+
+func TestRouteDependencies_UnknownMethod(t *testing.T) {
+	defer func() {
+		if v := recover(); v == nil || !strings.Contains(fmt.Sprint(v), "GetUsr") {
+			t.Errorf("recover() = %v, want unknown route method error", v)
+		}
+	}()
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&TypoRouter{})
+	NewClient(app)
+}
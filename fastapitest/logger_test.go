@@ -0,0 +1,95 @@
+package fastapitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/Chendemo12/fastapi"
+	"github.com/Chendemo12/fastapi/openapi"
+)
+
+type LogRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *LogRouter) Prefix() string { return "/api/log" }
+
+func (r *LogRouter) GetHello(c *fastapi.Context) (string, error) {
+	c.Logger().Info("hello", "name", c.Query("name", ""))
+	return "hello", nil
+}
+
+func (r *LogRouter) GetPanic(c *fastapi.Context) (string, error) {
+	panic("log panic")
+}
+
+func TestContextLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fastapi.ReplaceLogger(fastapi.NewSlogLogger(slog.NewJSONHandler(buf, nil)))
+	defer fastapi.ReplaceLogger(fastapi.NewDefaultLogger())
+
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&LogRouter{})
+	client := NewClient(app)
+
+	resp := client.Get("/api/log/hello?name=lee", WithHeader(fastapi.HeaderRequestID, "req-1"))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.StatusCode, resp.String())
+	}
+
+	entry := map[string]any{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log = %s, error = %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"level":      "INFO",
+		"msg":        "hello",
+		"request_id": "req-1",
+		"route":      openapi.CreateRouteIdentify(http.MethodGet, "/api/log/hello"),
+		"client_ip":  "192.0.2.1",
+		"name":       "lee",
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("%s = %v, want %v; log = %s", k, entry[k], v, buf.String())
+		}
+	}
+}
+
+func TestPanicLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fastapi.ReplaceLogger(fastapi.NewSlogLogger(slog.NewJSONHandler(buf, nil)))
+	defer fastapi.ReplaceLogger(fastapi.NewDefaultLogger())
+
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&LogRouter{})
+	client := NewClient(app)
+
+	if resp := client.Get("/api/log/panic"); resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("status = %d, body = %s", resp.StatusCode, resp.String())
+	}
+
+	entry := map[string]any{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log = %s, error = %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"level":  "ERROR",
+		"msg":    "panic recovered",
+		"method": http.MethodGet,
+		"path":   "/api/log/panic",
+		"route":  openapi.CreateRouteIdentify(http.MethodGet, "/api/log/panic"),
+		"error":  "log panic",
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("%s = %v, want %v; log = %s", k, entry[k], v, buf.String())
+		}
+	}
+	if stack, _ := entry["stack"].(string); stack == "" {
+		t.Errorf("stack is empty; log = %s", buf.String())
+	}
+}
@@ -0,0 +1,181 @@
+package fastapitest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Chendemo12/fastapi"
+	"github.com/Chendemo12/fastapi/openapi"
+)
+
+type Book struct {
+	Id    int    `json:"id" description:"编号"`
+	Title string `json:"title" description:"书名"`
+}
+
+type BookRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *BookRouter) Prefix() string { return "/api/book" }
+
+func (r *BookRouter) GetInfo(c *fastapi.Context) (*Book, error) {
+	c.MuxContext().Header("X-Total", "1")
+	return &Book{Id: 1, Title: "fastapi"}, nil
+}
+
+func (r *BookRouter) PostInfo(c *fastapi.Context, book *Book) (*Book, error) {
+	return book, nil
+}
+
+func (r *BookRouter) DeleteInfo(c *fastapi.Context) (int, error) {
+	return 1, nil
+}
+
+type ShelfRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *ShelfRouter) Prefix() string { return "/api/shelf" }
+
+func (r *ShelfRouter) PostItem(c *fastapi.Context, book *Book) (int, error) { return 1, nil }
+
+// OptionsItem 自定义的 OPTIONS 路由不会被覆盖
+func (r *ShelfRouter) OptionsItem(c *fastapi.Context) (string, error) { return "custom", nil }
+
+func TestHttpMethods(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&BookRouter{}).IncludeRouter(&ShelfRouter{})
+	client := NewClient(app)
+
+	t.Run("head", func(t *testing.T) {
+		resp := client.Head("/api/book/info")
+		if resp.StatusCode != http.StatusOK || len(resp.Body) != 0 {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+		if resp.Header.Get("X-Total") != "1" {
+			t.Errorf("header = %v", resp.Header)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		resp := client.Options("/api/book/info")
+		if resp.StatusCode != http.StatusNoContent {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+		if allow := resp.Header.Get(fastapi.HeaderAllow); allow != "GET, HEAD, POST, DELETE, OPTIONS" {
+			t.Errorf("Allow = %s", allow)
+		}
+	})
+
+	t.Run("custom-options", func(t *testing.T) {
+		resp := client.Options("/api/shelf/item")
+		if resp.StatusCode != http.StatusOK || resp.String() != "custom" {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+	})
+
+	t.Run("method-not-allowed", func(t *testing.T) {
+		resp := client.Put("/api/book/info", &Book{})
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Fatalf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+		if allow := resp.Header.Get(fastapi.HeaderAllow); allow == "" {
+			t.Error("Allow header missing")
+		}
+		e := &fastapi.HTTPError{}
+		if err := resp.JSON(e); err != nil || e.Detail != http.StatusText(http.StatusMethodNotAllowed) {
+			t.Errorf("body = %s, err = %v", resp.String(), err)
+		}
+
+		resp = client.Get("/api/shelf/item")
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		resp := client.Get("/api/unknown")
+		e := &fastapi.HTTPError{}
+		if resp.StatusCode != http.StatusNotFound || resp.JSON(e) != nil || e.Detail != http.StatusText(http.StatusNotFound) {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+	})
+}
+
+type ApiError struct {
+	Code    int    `json:"code" description:"错误码"`
+	Message string `json:"message" description:"错误信息"`
+}
+
+func TestFallbackErrorFormatter(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&BookRouter{})
+	app.SetRouteErrorFormatter(func(c *fastapi.Context, err error) (int, any) {
+		statusCode := c.Response().StatusCode
+		return statusCode, &ApiError{Code: statusCode, Message: err.Error()}
+	})
+	client := NewClient(app)
+
+	for path, want := range map[string]int{"/api/unknown": http.StatusNotFound, "/api/book/info": http.StatusMethodNotAllowed} {
+		resp := client.Patch(path, &Book{})
+		e := &ApiError{}
+		if resp.StatusCode != want || resp.JSON(e) != nil || e.Code != want || e.Message != http.StatusText(want) {
+			t.Errorf("%s: status = %d, body = %s", path, resp.StatusCode, resp.String())
+		}
+	}
+}
+
+func TestFallbackHooks(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&BookRouter{})
+	var formatted, written []string
+	app.SetRouteErrorFormatter(func(c *fastapi.Context, err error) (int, any) {
+		formatted = append(formatted, c.RouteId())
+		return c.Response().StatusCode, err.Error()
+	})
+	app.UseBeforeWrite(func(c *fastapi.Context) {
+		c.Logger().Info("before write")
+		written = append(written, c.RouteId())
+	})
+	client := NewClient(app)
+
+	for path, want := range map[string]int{"/api/unknown": http.StatusNotFound, "/api/book/info": http.StatusMethodNotAllowed} {
+		formatted, written = nil, nil
+		resp := client.Patch(path, &Book{})
+		if resp.StatusCode != want || len(formatted) != 1 || formatted[0] != "" || len(written) != 1 || written[0] != "" {
+			t.Errorf("%s: status = %d, formatter = %q, hook = %q", path, resp.StatusCode, formatted, written)
+		}
+	}
+
+	written = nil
+	client.Get("/api/book/info")
+	if len(written) != 1 || written[0] != openapi.CreateRouteIdentify(http.MethodGet, "/api/book/info") {
+		t.Errorf("hook = %q", written)
+	}
+}
+
+func TestFallbackOpenApi(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&BookRouter{})
+	resp := NewClient(app).Get("/openapi.json")
+
+	doc := map[string]any{}
+	if err := resp.JSON(&doc); err != nil {
+		t.Fatal(err)
+	}
+	components := doc["components"].(map[string]any)
+	responses, ok := components["responses"].(map[string]any)
+	if !ok {
+		t.Fatalf("components = %v", components)
+	}
+	for name, desc := range map[string]string{"NotFound": "Not Found", "MethodNotAllowed": "Method Not Allowed"} {
+		r, _ := responses[name].(map[string]any)
+		if r["description"] != desc {
+			t.Errorf("%s = %v", name, r)
+		}
+	}
+	if _, ok := components["schemas"].(map[string]any)["fastapi.HTTPError"]; !ok {
+		t.Error("HTTPError schema missing")
+	}
+}
@@ -0,0 +1,143 @@
+package fastapitest
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Chendemo12/fastapi"
+)
+
+type CrashRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *CrashRouter) Prefix() string { return "/api/crash" }
+
+func (r *CrashRouter) GetNow(c *fastapi.Context) (string, error) {
+	var m map[string]int
+	m["boom"] = 1 // assignment to entry in nil map
+	return "unreachable", nil
+}
+
+func (r *CrashRouter) GetAbort(c *fastapi.Context) (string, error) {
+	panic(http.ErrAbortHandler)
+}
+
+func (r *CrashRouter) GetOk(c *fastapi.Context) (string, error) {
+	if c.GetBool("crash") {
+		panic("dependency asked to crash")
+	}
+	return "ok", nil
+}
+
+func TestHandlerPanicRecovery(t *testing.T) {
+	var (
+		recovered  any
+		stack      []byte
+		beforeCode int
+	)
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&CrashRouter{})
+	app.OnPanic(func(c *fastapi.Context, v any, s []byte) {
+		recovered, stack = v, s
+	})
+	app.UseBeforeWrite(func(c *fastapi.Context) {
+		beforeCode = c.Response().StatusCode
+	})
+	app.UseAfter(func(c *fastapi.Context) error {
+		if c.MuxContext().GetHeader("X-Crash") != "" {
+			c.Set("crash", true)
+		}
+		return nil
+	})
+	client := NewClient(app)
+
+	t.Run("route", func(t *testing.T) {
+		resp := client.Get("/api/crash/now")
+		e := &fastapi.HTTPError{}
+		if resp.StatusCode != http.StatusInternalServerError || resp.JSON(e) != nil || e.Detail != http.StatusText(http.StatusInternalServerError) {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+		err, ok := recovered.(error)
+		if !ok || !strings.Contains(err.Error(), "nil map") || !strings.Contains(string(stack), "GetNow") {
+			t.Errorf("recovered = %v, stack = %s", recovered, stack)
+		}
+		if beforeCode != http.StatusInternalServerError {
+			t.Errorf("beforeWrite saw status %d", beforeCode)
+		}
+	})
+
+	t.Run("context-reusable", func(t *testing.T) {
+		// panic 之后 Context 被正确归还, 后续请求不受影响
+		for i := 0; i < 10; i++ {
+			if resp := client.Get("/api/crash/ok"); resp.StatusCode != http.StatusOK || resp.String() != "ok" {
+				t.Fatalf("status = %d, body = %s", resp.StatusCode, resp.String())
+			}
+		}
+		if resp := client.Get("/api/crash/ok", WithHeader("X-Crash", "1")); resp.StatusCode != http.StatusInternalServerError {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+		if recovered != "dependency asked to crash" {
+			t.Errorf("recovered = %v", recovered)
+		}
+	})
+
+	t.Run("abort-handler", func(t *testing.T) {
+		defer func() {
+			if v := recover(); !errors.Is(v.(error), http.ErrAbortHandler) {
+				t.Errorf("recover() = %v, want http.ErrAbortHandler", v)
+			}
+		}()
+		client.Get("/api/crash/abort")
+		t.Error("http.ErrAbortHandler should be re-panicked")
+	})
+}
+
+func TestHandlerPanicDuringWrite(t *testing.T) {
+	var writes, panics int
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&CrashRouter{})
+	app.OnPanic(func(c *fastapi.Context, v any, s []byte) {
+		panics++
+	})
+	app.UseBeforeWrite(func(c *fastapi.Context) {
+		writes++
+		panic("before write")
+	})
+
+	// 写入过程中发生 panic 时不会再次执行写入
+	NewClient(app).Get("/api/crash/ok")
+	if writes != 1 || panics != 1 {
+		t.Errorf("writes = %d, panics = %d", writes, panics)
+	}
+}
+
+// 请求时发生 panic 的观察者
+type panicObserver struct{ responses int }
+
+func (o *panicObserver) OnRequest(c *fastapi.Context, route fastapi.RouteIface) {
+	if c.MuxContext().GetHeader("X-Crash") != "" {
+		panic("observer crashed")
+	}
+}
+
+func (o *panicObserver) OnResponse(c *fastapi.Context, route fastapi.RouteIface) { o.responses++ }
+
+func TestHandlerPanicInObserver(t *testing.T) {
+	observer := &panicObserver{}
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&CrashRouter{}).UseObserver(observer)
+	client := NewClient(app)
+
+	if resp := client.Get("/api/crash/ok", WithHeader("X-Crash", "1")); resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+	}
+	if resp := client.Get("/api/crash/ok"); resp.StatusCode != http.StatusOK || resp.String() != "ok" {
+		t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+	}
+	if observer.responses != 2 {
+		t.Errorf("OnResponse called %d times, want 2", observer.responses)
+	}
+}
@@ -0,0 +1,565 @@
+package fastapitest
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Chendemo12/fastapi"
+	"github.com/Chendemo12/fastapi/openapi"
+)
+
+type TenantReq struct {
+	TenantId string `header:"X-Tenant-Id" validate:"required" description:"租户ID"`
+	Version  int    `header:"X-Api-Version" validate:"omitempty,gte=2"`
+	Session  string `cookie:"session"`
+	Keyword  string `json:"keyword" query:"keyword"`
+}
+
+type TenantRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *TenantRouter) Prefix() string { return "/api/tenant" }
+
+func (r *TenantRouter) GetInfo(c *fastapi.Context, req *TenantReq) (*TenantReq, error) {
+	return req, nil
+}
+
+func TestHeaderCookieParams(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&TenantRouter{})
+	client := NewClient(app)
+
+	t.Run("bind", func(t *testing.T) {
+		resp := client.Get("/api/tenant/info?keyword=go",
+			WithHeader("X-Tenant-Id", "t1"),
+			WithHeader("X-Api-Version", "3"),
+			WithCookie(&http.Cookie{Name: "session", Value: "abc"}),
+		)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+		req := &TenantReq{}
+		if err := resp.JSON(req); err != nil {
+			t.Fatal(err)
+		}
+		want := &TenantReq{TenantId: "t1", Version: 3, Session: "abc", Keyword: "go"}
+		if !reflect.DeepEqual(req, want) {
+			t.Errorf("req = %+v, want %+v", req, want)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		opts    []RequestOption
+		wantLoc []string
+	}{
+		{name: "missing-header", wantLoc: []string{"header", "X-Tenant-Id"}},
+		{
+			name:    "header-type",
+			opts:    []RequestOption{WithHeader("X-Tenant-Id", "t1"), WithHeader("X-Api-Version", "v1")},
+			wantLoc: []string{"header", "X-Api-Version"},
+		},
+		{
+			name:    "header-validate",
+			opts:    []RequestOption{WithHeader("X-Tenant-Id", "t1"), WithHeader("X-Api-Version", "1")},
+			wantLoc: []string{"header", "X-Api-Version"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := client.Get("/api/tenant/info", tt.opts...)
+			ve := resp.ValidationError()
+			if ve == nil || len(ve.Detail) == 0 {
+				t.Fatalf("status = %d, body = %s", resp.StatusCode, resp.String())
+			}
+			if !reflect.DeepEqual(ve.Detail[0].Loc, tt.wantLoc) {
+				t.Errorf("loc = %v, want %v", ve.Detail[0].Loc, tt.wantLoc)
+			}
+		})
+	}
+}
+
+func TestHeaderCookieParams_OpenApi(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&TenantRouter{})
+
+	doc := map[string]any{}
+	if err := NewClient(app).Get("/openapi.json").JSON(&doc); err != nil {
+		t.Fatal(err)
+	}
+	params := doc["paths"].(map[string]any)["/api/tenant/info"].(map[string]any)["get"].(map[string]any)["parameters"].([]any)
+
+	got := map[string]string{}
+	for _, p := range params {
+		m := p.(map[string]any)
+		got[m["name"].(string)] = m["in"].(string)
+		if m["name"] == "X-Tenant-Id" && m["required"] != true {
+			t.Errorf("X-Tenant-Id should be required")
+		}
+	}
+	want := map[string]string{"X-Tenant-Id": "header", "X-Api-Version": "header", "session": "cookie", "keyword": "query"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parameters = %v, want %v", got, want)
+	}
+}
+
+type ItemRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *ItemRouter) Prefix() string { return "/api/item" }
+
+func (r *ItemRouter) Path() map[string]string {
+	return map[string]string{"GetLevel": "level/:level<int8>", "GetOrder": "order/:no<uint>/:name"}
+}
+
+func (r *ItemRouter) GetLevel(c *fastapi.Context) (int64, error) {
+	return c.PathInt("level"), nil
+}
+
+type Order struct {
+	No   uint64 `json:"no"`
+	Name string `json:"name"`
+}
+
+func (r *ItemRouter) GetOrder(c *fastapi.Context) (*Order, error) {
+	return &Order{No: c.PathUint("no"), Name: c.PathValue("name").(string)}, nil
+}
+
+func TestTypedPathParams(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&ItemRouter{})
+	client := NewClient(app)
+
+	t.Run("convert", func(t *testing.T) {
+		if resp := client.Get("/api/item/level/-12"); resp.StatusCode != http.StatusOK || resp.String() != "-12" {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+		resp := client.Get("/api/item/order/42/book")
+		if resp.StatusCode != http.StatusOK || resp.String() != `{"no":42,"name":"book"}` {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+	})
+
+	tests := []struct {
+		name    string
+		url     string
+		wantLoc []string
+	}{
+		{name: "not-integer", url: "/api/item/level/abc", wantLoc: []string{"path", "level"}},
+		{name: "out-of-range", url: "/api/item/level/200", wantLoc: []string{"path", "level"}},
+		{name: "negative-uint", url: "/api/item/order/-1/book", wantLoc: []string{"path", "no"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := client.Get(tt.url)
+			ve := resp.ValidationError()
+			if ve == nil || len(ve.Detail) == 0 {
+				t.Fatalf("status = %d, body = %s", resp.StatusCode, resp.String())
+			}
+			if !reflect.DeepEqual(ve.Detail[0].Loc, tt.wantLoc) {
+				t.Errorf("loc = %v, want %v", ve.Detail[0].Loc, tt.wantLoc)
+			}
+		})
+	}
+
+	t.Run("openapi", func(t *testing.T) {
+		doc := map[string]any{}
+		if err := client.Get("/openapi.json").JSON(&doc); err != nil {
+			t.Fatal(err)
+		}
+		item, ok := doc["paths"].(map[string]any)["/api/item/order/{no}/{name}"].(map[string]any)
+		if !ok {
+			t.Fatalf("paths = %v", doc["paths"])
+		}
+		types := map[string]any{}
+		for _, p := range item["get"].(map[string]any)["parameters"].([]any) {
+			m := p.(map[string]any)
+			types[m["name"].(string)] = m["schema"].(map[string]any)["type"]
+		}
+		if types["no"] != "integer" || types["name"] != "string" {
+			t.Errorf("parameter types = %v", types)
+		}
+	})
+}
+
+func TestTypedPathParams_UnsupportedType(t *testing.T) {
+	swagger := &openapi.RouteSwagger{Method: http.MethodGet, Url: "/api/item/:id<uuid>", ResponseModel: &openapi.BaseModelMeta{}}
+	if err := swagger.Init(); err == nil {
+		t.Error("Init() should return error for unsupported path parameter type")
+	}
+}
+
+type SearchReq struct {
+	Ids    []int     `query:"id" validate:"required,dive,gte=1"`
+	Tags   []string  `query:"tags" explode:"false"`
+	Scores []float64 `query:"scores" style:"pipeDelimited"`
+	Flags  []bool    `query:"flag"`
+}
+
+type SearchRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *SearchRouter) Prefix() string { return "/api/search" }
+
+func (r *SearchRouter) GetList(c *fastapi.Context, req *SearchReq) (*SearchReq, error) {
+	return req, nil
+}
+
+func TestArrayQueryParams(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&SearchRouter{})
+	client := NewClient(app)
+
+	t.Run("bind", func(t *testing.T) {
+		resp := client.Get("/api/search/list?id=1&id=2&tags=a,b&tags=c&scores=1.5|2&flag=true")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+		req := &SearchReq{}
+		if err := resp.JSON(req); err != nil {
+			t.Fatal(err)
+		}
+		want := &SearchReq{Ids: []int{1, 2}, Tags: []string{"a", "b", "c"}, Scores: []float64{1.5, 2}, Flags: []bool{true}}
+		if !reflect.DeepEqual(req, want) {
+			t.Errorf("req = %+v, want %+v", req, want)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		url     string
+		wantLoc []string
+	}{
+		{name: "missing", url: "/api/search/list?tags=a", wantLoc: []string{"query", "id"}},
+		{name: "elem-type", url: "/api/search/list?id=1&id=x", wantLoc: []string{"query", "Ids", "1"}},
+		{name: "dive", url: "/api/search/list?id=1&id=0", wantLoc: []string{"query", "Ids[1]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := client.Get(tt.url)
+			ve := resp.ValidationError()
+			if ve == nil || len(ve.Detail) == 0 {
+				t.Fatalf("status = %d, body = %s", resp.StatusCode, resp.String())
+			}
+			if !reflect.DeepEqual(ve.Detail[0].Loc, tt.wantLoc) {
+				t.Errorf("loc = %v, want %v", ve.Detail[0].Loc, tt.wantLoc)
+			}
+		})
+	}
+
+	t.Run("openapi", func(t *testing.T) {
+		doc := map[string]any{}
+		if err := client.Get("/openapi.json").JSON(&doc); err != nil {
+			t.Fatal(err)
+		}
+		params := doc["paths"].(map[string]any)["/api/search/list"].(map[string]any)["get"].(map[string]any)["parameters"].([]any)
+		got := map[string]string{}
+		for _, p := range params {
+			m := p.(map[string]any)
+			schema := m["schema"].(map[string]any)
+			items := schema["items"].(map[string]any)
+			got[m["name"].(string)] = fmt.Sprintf("%v %v %v %v", schema["type"], items["type"], m["style"], m["explode"])
+		}
+		want := map[string]string{
+			"id":     "array integer form true",
+			"tags":   "array string form false",
+			"scores": "array number pipeDelimited false",
+			"flag":   "array boolean form true",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("parameters = %v, want %v", got, want)
+		}
+	})
+}
+
+type ListReq struct {
+	Page  int      `query:"page" default:"1" validate:"gte=1"`
+	Size  uint8    `query:"size" default:"20"`
+	Sort  []string `query:"sort" explode:"false" default:"id,name"`
+	Lang  string   `header:"Accept-Language" default:"en"`
+	Valid bool     `query:"valid" default:"true"`
+}
+
+type ListRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *ListRouter) Prefix() string { return "/api/page" }
+
+func (r *ListRouter) GetList(c *fastapi.Context, req *ListReq) (*ListReq, error) {
+	return req, nil
+}
+
+func TestDefaultParams(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&ListRouter{})
+	client := NewClient(app)
+
+	tests := []struct {
+		name string
+		url  string
+		opts []RequestOption
+		want *ListReq
+	}{
+		{
+			name: "defaults",
+			url:  "/api/page/list",
+			want: &ListReq{Page: 1, Size: 20, Sort: []string{"id", "name"}, Lang: "en", Valid: true},
+		},
+		{
+			name: "override",
+			url:  "/api/page/list?page=3&sort=age&valid=false",
+			opts: []RequestOption{WithHeader("Accept-Language", "zh")},
+			want: &ListReq{Page: 3, Size: 20, Sort: []string{"age"}, Lang: "zh", Valid: false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := client.Get(tt.url, tt.opts...)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", resp.StatusCode, resp.String())
+			}
+			req := &ListReq{}
+			if err := resp.JSON(req); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(req, tt.want) {
+				t.Errorf("req = %+v, want %+v", req, tt.want)
+			}
+		})
+	}
+
+	t.Run("openapi", func(t *testing.T) {
+		doc := map[string]any{}
+		if err := client.Get("/openapi.json").JSON(&doc); err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]any{}
+		for _, p := range doc["paths"].(map[string]any)["/api/page/list"].(map[string]any)["get"].(map[string]any)["parameters"].([]any) {
+			m := p.(map[string]any)
+			got[m["name"].(string)] = m["default"]
+		}
+		want := map[string]any{"page": 1.0, "size": 20.0, "sort": []any{"id", "name"}, "Accept-Language": "en", "valid": true}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("defaults = %v, want %v", got, want)
+		}
+	})
+}
+
+type BadDefaultReq struct {
+	Page int `query:"page" default:"one"`
+}
+
+type BadDefaultRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *BadDefaultRouter) GetList(c *fastapi.Context, req *BadDefaultReq) (int, error) {
+	return req.Page, nil
+}
+
+type UploadForm struct {
+	Name  string `json:"name"`
+	Level int8   `json:"level" default:"3"`
+}
+
+type UploadRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *UploadRouter) PostUpload(c *fastapi.Context, file *fastapi.File, form *UploadForm) (*UploadForm, error) {
+	return form, nil
+}
+
+type BadFormDefaultRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *BadFormDefaultRouter) PostUpload(c *fastapi.Context, file *fastapi.File, form *struct {
+	Level int8 `json:"level" default:"300"`
+}) (int, error) {
+	return 0, nil
+}
+
+func TestDefaultParams_Invalid(t *testing.T) {
+	for _, router := range []fastapi.GroupRouter{&BadDefaultRouter{}, &BadFormDefaultRouter{}} {
+		if err := fastapi.NewGroupRouteMeta(router, nil).Init(); err == nil {
+			t.Errorf("%T: Init() should return error for invalid default value", router)
+		}
+	}
+}
+
+// 非基本类型字段的默认值被忽略
+type NestedDefaultRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *NestedDefaultRouter) PostUpload(c *fastapi.Context, file *fastapi.File, form *struct {
+	Level int8         `json:"level" default:"3"`
+	Owner UploadForm   `json:"owner" default:"lee"`
+	Tags  []UploadForm `json:"tags" default:"a"`
+}) (int8, error) {
+	return form.Level, nil
+}
+
+func TestDefaultParams_Unsupported(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&NestedDefaultRouter{})
+	client := NewClient(app)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile(openapi.MultipartFormFileName, "a.txt")
+	_, _ = part.Write([]byte("hello"))
+	_ = writer.WriteField(openapi.MultipartFormParamName, `{}`)
+	_ = writer.Close()
+
+	resp := client.Post("/upload", body, WithContentType(writer.FormDataContentType()))
+	if resp.StatusCode != http.StatusOK || resp.String() != "3" {
+		t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+	}
+}
+
+func TestDefaultParams_Form(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&UploadRouter{})
+	client := NewClient(app)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile(openapi.MultipartFormFileName, "a.txt")
+	_, _ = part.Write([]byte("hello"))
+	_ = writer.WriteField(openapi.MultipartFormParamName, `{"name":"a"}`)
+	_ = writer.Close()
+
+	resp := client.Post("/upload", body, WithContentType(writer.FormDataContentType()))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.StatusCode, resp.String())
+	}
+	form := &UploadForm{}
+	if err := resp.JSON(form); err != nil {
+		t.Fatal(err)
+	}
+	if want := (&UploadForm{Name: "a", Level: 3}); !reflect.DeepEqual(form, want) {
+		t.Errorf("form = %+v, want %+v", form, want)
+	}
+}
+
+type Pagination struct {
+	Page int `query:"page" default:"1" validate:"gte=1"`
+	Size int `query:"size" default:"10" validate:"lte=100"`
+}
+
+type Filter struct {
+	Keyword string `query:"keyword" validate:"required,min=2"`
+	Tenant  string `header:"X-Tenant-Id"`
+}
+
+type Article struct {
+	Page    int    `json:"page"`
+	Size    int    `json:"size"`
+	Keyword string `json:"keyword"`
+	Tenant  string `json:"tenant"`
+}
+
+type ArticleRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *ArticleRouter) Prefix() string { return "/api/article" }
+
+func (r *ArticleRouter) GetList(c *fastapi.Context, page *Pagination, filter Filter) (*Article, error) {
+	return &Article{Page: page.Page, Size: page.Size, Keyword: filter.Keyword, Tenant: filter.Tenant}, nil
+}
+
+func (r *ArticleRouter) DeleteList(c *fastapi.Context, filter *Filter, page *Pagination) (*Article, error) {
+	return &Article{Page: page.Page, Size: page.Size, Keyword: filter.Keyword, Tenant: filter.Tenant}, nil
+}
+
+func TestMultipleStructQuery(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&ArticleRouter{})
+	client := NewClient(app)
+
+	t.Run("bind", func(t *testing.T) {
+		for _, method := range []string{http.MethodGet, http.MethodDelete} {
+			resp := client.Request(method, "/api/article/list?size=20&keyword=go", nil, WithHeader("X-Tenant-Id", "t1"))
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("%s status = %d, body = %s", method, resp.StatusCode, resp.String())
+			}
+			got := &Article{}
+			if err := resp.JSON(got); err != nil {
+				t.Fatal(err)
+			}
+			if want := (&Article{Page: 1, Size: 20, Keyword: "go", Tenant: "t1"}); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s article = %+v, want %+v", method, got, want)
+			}
+		}
+	})
+
+	tests := []struct {
+		name    string
+		url     string
+		wantLoc [][]string
+	}{
+		{name: "first-struct", url: "/api/article/list?size=200&keyword=go", wantLoc: [][]string{{"query", "Size"}}},
+		{name: "second-struct", url: "/api/article/list?keyword=g", wantLoc: [][]string{{"query", "Keyword"}}},
+		{name: "both", url: "/api/article/list?size=200&keyword=g", wantLoc: [][]string{{"query", "Size"}, {"query", "Keyword"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ve := client.Get(tt.url).ValidationError()
+			if ve == nil {
+				t.Fatal("expected validation error")
+			}
+			var locs [][]string
+			for _, d := range ve.Detail {
+				locs = append(locs, d.Loc)
+			}
+			if !reflect.DeepEqual(locs, tt.wantLoc) {
+				t.Errorf("loc = %v, want %v", locs, tt.wantLoc)
+			}
+		})
+	}
+
+	t.Run("openapi", func(t *testing.T) {
+		doc := map[string]any{}
+		if err := client.Get("/openapi.json").JSON(&doc); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, p := range doc["paths"].(map[string]any)["/api/article/list"].(map[string]any)["get"].(map[string]any)["parameters"].([]any) {
+			names = append(names, p.(map[string]any)["name"].(string))
+		}
+		if want := []string{"page", "size", "keyword", "X-Tenant-Id"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("parameters = %v, want %v", names, want)
+		}
+	})
+}
+
+type SortFilter struct {
+	Size string `query:"SIZE"`
+}
+
+type ConflictRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *ConflictRouter) GetList(c *fastapi.Context, page *Pagination, filter *SortFilter) (int, error) {
+	return 0, nil
+}
+
+func TestMultipleStructQuery_Conflict(t *testing.T) {
+	err := fastapi.NewGroupRouteMeta(&ConflictRouter{}, nil).Init()
+	if err == nil || !strings.Contains(err.Error(), "SortFilter") || !strings.Contains(err.Error(), "Pagination") {
+		t.Errorf("Init() error = %v, want name conflict", err)
+	}
+}
@@ -0,0 +1,162 @@
+package fastapitest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Chendemo12/fastapi"
+)
+
+type Session struct {
+	Token string
+	Calls int // 提供者被调用的次数
+}
+
+type Principal struct {
+	Name  string `json:"name" description:"名称"`
+	Admin bool   `json:"admin" description:"是否为管理员"`
+}
+
+type TokenHeader struct {
+	Token string `header:"X-Token" validate:"required" description:"令牌"`
+}
+
+type Note struct {
+	Text string `json:"text" validate:"required" description:"内容"`
+}
+
+type cycleA struct{}
+type cycleB struct{}
+
+func init() {
+	fastapi.Provide(func(c *fastapi.Context, h *TokenHeader) (*Session, error) {
+		calls := int(c.GetInt64("session-calls")) + 1
+		c.Set("session-calls", int64(calls))
+		if h.Token == "bad" {
+			return nil, fastapi.NewHTTPError(http.StatusUnauthorized)
+		}
+		return &Session{Token: h.Token, Calls: calls}, nil
+	})
+	fastapi.Provide(func(c *fastapi.Context, s *Session) (*Principal, error) {
+		return &Principal{Name: s.Token, Admin: s.Token == "admin"}, nil
+	})
+	fastapi.Provide(func(c *fastapi.Context, b *cycleB) (*cycleA, error) { return &cycleA{}, nil })
+	fastapi.Provide(func(c *fastapi.Context, a *cycleA) (*cycleB, error) { return &cycleB{}, nil })
+}
+
+type ProfileRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *ProfileRouter) Prefix() string { return "/api/profile" }
+
+func (r *ProfileRouter) GetMe(c *fastapi.Context, p *Principal) (*Principal, error) {
+	return p, nil
+}
+
+// GetSession 同时依赖 Session 和 Principal, Session 的提供者只会被调用一次
+func (r *ProfileRouter) GetSession(c *fastapi.Context, s *Session, p *Principal) (int, error) {
+	return s.Calls, nil
+}
+
+func (r *ProfileRouter) PostNote(c *fastapi.Context, p *Principal, note *Note) (string, error) {
+	return p.Name + ":" + note.Text, nil
+}
+
+type CycleRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *CycleRouter) GetCycle(c *fastapi.Context, a *cycleA) (string, error) { return "", nil }
+
+func TestProvide(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&ProfileRouter{})
+	client := NewClient(app)
+
+	t.Run("inject", func(t *testing.T) {
+		p := &Principal{}
+		resp := client.Get("/api/profile/me", WithHeader("X-Token", "admin"))
+		if resp.StatusCode != http.StatusOK || resp.JSON(p) != nil || p.Name != "admin" || !p.Admin {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+	})
+
+	t.Run("cached", func(t *testing.T) {
+		resp := client.Get("/api/profile/session", WithHeader("X-Token", "user"))
+		if resp.StatusCode != http.StatusOK || resp.String() != "1" {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+	})
+
+	t.Run("request-body", func(t *testing.T) {
+		resp := client.Post("/api/profile/note", &Note{Text: "hi"}, WithHeader("X-Token", "user"))
+		if resp.StatusCode != http.StatusOK || resp.String() != "user:hi" {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+	})
+
+	t.Run("param-validate", func(t *testing.T) {
+		resp := client.Get("/api/profile/me")
+		if resp.StatusCode != http.StatusUnprocessableEntity || !strings.Contains(resp.String(), "X-Token") {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+	})
+
+	t.Run("provider-error", func(t *testing.T) {
+		resp := client.Get("/api/profile/me", WithHeader("X-Token", "bad"))
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+	})
+
+	t.Run("openapi", func(t *testing.T) {
+		doc := map[string]any{}
+		if err := client.Get("/openapi.json").JSON(&doc); err != nil {
+			t.Fatal(err)
+		}
+		op := doc["paths"].(map[string]any)["/api/profile/me"].(map[string]any)["get"].(map[string]any)
+		params, _ := op["parameters"].([]any)
+		if len(params) != 1 {
+			t.Fatalf("parameters = %v", params)
+		}
+		if p := params[0].(map[string]any); p["name"] != "X-Token" || p["in"] != "header" || p["required"] != true {
+			t.Errorf("parameter = %v", p)
+		}
+	})
+}
+
+func TestProvide_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fc   any
+	}{
+		{name: "not-func", fc: &Session{}},
+		{name: "no-context", fc: func() (*Session, error) { return nil, nil }},
+		{name: "no-error", fc: func(c *fastapi.Context) *Session { return nil }},
+		{name: "duplicate", fc: func(c *fastapi.Context) (*Session, error) { return nil, nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Provide() should panic")
+				}
+			}()
+			fastapi.Provide(tt.fc)
+		})
+	}
+
+	t.Run("circular", func(t *testing.T) {
+		defer func() {
+			if v := recover(); v == nil || !strings.Contains(fmt.Sprint(v), "circular") {
+				t.Errorf("recover() = %v, want circular dependency error", v)
+			}
+		}()
+		app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+		app.IncludeRouter(&CycleRouter{})
+		NewClient(app)
+	})
+}
@@ -0,0 +1,160 @@
+package fastapitest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Chendemo12/fastapi"
+)
+
+type QuotaRouter struct {
+	fastapi.BaseGroupRouter
+	group  *fastapi.RateLimiter
+	export *fastapi.RateLimiter
+}
+
+func (r *QuotaRouter) Prefix() string { return "/api/quota" }
+
+func (r *QuotaRouter) RateLimit() *fastapi.RateLimiter { return r.group }
+
+func (r *QuotaRouter) RouteRateLimit() map[string]*fastapi.RateLimiter {
+	return map[string]*fastapi.RateLimiter{
+		"GetExport": r.export,
+		"GetHealth": nil,
+	}
+}
+
+func (r *QuotaRouter) GetItems(c *fastapi.Context) (string, error) { return "items", nil }
+
+func (r *QuotaRouter) GetUsers(c *fastapi.Context) (string, error) { return "users", nil }
+
+func (r *QuotaRouter) GetExport(c *fastapi.Context) (string, error) { return "export", nil }
+
+func (r *QuotaRouter) GetHealth(c *fastapi.Context) (string, error) { return "ok", nil }
+
+func TestRateLimit(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&QuotaRouter{
+		group:  fastapi.NewRateLimiter(2, time.Minute),
+		export: fastapi.NewRateLimiter(1, time.Hour, fastapi.RateLimitByHeader("X-Tenant")),
+	})
+	client := NewClient(app)
+
+	tests := []struct {
+		name          string
+		path          string
+		opts          []RequestOption
+		wantStatus    int
+		wantRemaining string
+	}{
+		{name: "first", path: "/api/quota/items", wantStatus: http.StatusOK, wantRemaining: "1"},
+		{name: "shared", path: "/api/quota/users", wantStatus: http.StatusOK, wantRemaining: "0"},
+		{name: "exceeded", path: "/api/quota/items", wantStatus: http.StatusTooManyRequests, wantRemaining: "0"},
+		{name: "unlimited", path: "/api/quota/health", wantStatus: http.StatusOK},
+		{name: "tenant-a", path: "/api/quota/export", opts: []RequestOption{WithHeader("X-Tenant", "a")}, wantStatus: http.StatusOK, wantRemaining: "0"},
+		{name: "tenant-a-exceeded", path: "/api/quota/export", opts: []RequestOption{WithHeader("X-Tenant", "a")}, wantStatus: http.StatusTooManyRequests, wantRemaining: "0"},
+		{name: "tenant-b", path: "/api/quota/export", opts: []RequestOption{WithHeader("X-Tenant", "b")}, wantStatus: http.StatusOK, wantRemaining: "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := client.Get(tt.path, tt.opts...)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", resp.StatusCode, tt.wantStatus, resp.String())
+			}
+			if got := resp.Header.Get(fastapi.HeaderRateLimitRemaining); got != tt.wantRemaining {
+				t.Errorf("RateLimit-Remaining = %q, want %q", got, tt.wantRemaining)
+			}
+			if tt.wantStatus == http.StatusTooManyRequests {
+				if resp.Header.Get(fastapi.HeaderRetryAfter) == "" || resp.String() != `{"detail":"Too Many Requests"}` {
+					t.Errorf("Retry-After = %q, body = %s", resp.Header.Get(fastapi.HeaderRetryAfter), resp.String())
+				}
+			} else if resp.Header.Get(fastapi.HeaderRetryAfter) != "" {
+				t.Errorf("unexpected Retry-After on status %d", resp.StatusCode)
+			}
+		})
+	}
+
+	t.Run("openapi", func(t *testing.T) {
+		doc := map[string]any{}
+		if err := client.Get("/openapi.json").JSON(&doc); err != nil {
+			t.Fatal(err)
+		}
+		paths := doc["paths"].(map[string]any)
+		responses := func(path string) map[string]any {
+			return paths[path].(map[string]any)["get"].(map[string]any)["responses"].(map[string]any)
+		}
+
+		m429, ok := responses("/api/quota/items")["429"].(map[string]any)
+		if !ok {
+			t.Fatalf("429 response is missing: %v", responses("/api/quota/items"))
+		}
+		want := `{"description":"需要等待的秒数","schema":{"type":"integer"}}`
+		if got := marshal(t, m429["headers"].(map[string]any)["Retry-After"]); got != want {
+			t.Errorf("Retry-After header = %s, want %s", got, want)
+		}
+		if _, ok = responses("/api/quota/health")["429"]; ok {
+			t.Error("unlimited route should not document 429")
+		}
+	})
+}
+
+type GuardedRouter struct {
+	fastapi.BaseGroupRouter
+	group   *fastapi.RateLimiter
+	profile *fastapi.RateLimiter
+}
+
+func (r *GuardedRouter) Prefix() string { return "/api/guarded" }
+
+func (r *GuardedRouter) Security() []fastapi.SecurityScheme {
+	return []fastapi.SecurityScheme{bearerScheme}
+}
+
+func (r *GuardedRouter) RateLimit() *fastapi.RateLimiter { return r.group }
+
+func (r *GuardedRouter) RouteRateLimit() map[string]*fastapi.RateLimiter {
+	return map[string]*fastapi.RateLimiter{"GetProfile": r.profile}
+}
+
+func (r *GuardedRouter) GetLogin(c *fastapi.Context) (string, error) { return "login", nil }
+
+func (r *GuardedRouter) GetProfile(c *fastapi.Context) (string, error) { return "profile", nil }
+
+func TestRateLimitOrder(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&GuardedRouter{
+		group:   fastapi.NewRateLimiter(2, time.Minute),
+		profile: fastapi.NewPrincipalRateLimiter(1, time.Minute),
+	})
+	client := NewClient(app)
+	bearer := func(token string) RequestOption {
+		return WithHeader(fastapi.HeaderAuthorization, "Bearer "+token)
+	}
+
+	t.Run("unauthorized-exhausts-ip", func(t *testing.T) {
+		// 以IP为标识的限流在认证之前执行, 认证失败的请求同样消耗额度
+		for _, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+			if resp := client.Get("/api/guarded/login"); resp.StatusCode != want {
+				t.Fatalf("status = %d, want %d, body = %s", resp.StatusCode, want, resp.String())
+			}
+		}
+	})
+
+	t.Run("principal-after-security", func(t *testing.T) {
+		// 以凭证为标识的限流在认证之后执行, 认证失败的请求不消耗额度
+		for i := 0; i < 3; i++ {
+			if resp := client.Get("/api/guarded/profile"); resp.StatusCode != http.StatusUnauthorized {
+				t.Fatalf("status = %d, body = %s", resp.StatusCode, resp.String())
+			}
+		}
+		for token, want := range map[string]int{"a": http.StatusOK, "b": http.StatusOK} {
+			if resp := client.Get("/api/guarded/profile", bearer(token)); resp.StatusCode != want {
+				t.Errorf("%s: status = %d, want %d, body = %s", token, resp.StatusCode, want, resp.String())
+			}
+		}
+		if resp := client.Get("/api/guarded/profile", bearer("a")); resp.StatusCode != http.StatusTooManyRequests {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package fastapitest
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Chendemo12/fastapi"
+)
+
+type Stock struct {
+	Code  string `json:"code" validate:"required"`
+	Count int    `json:"count" validate:"gte=0"`
+}
+
+type StockRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *StockRouter) Prefix() string { return "/api/stock" }
+
+// GetInvalid 返回一个不满足校验规则的响应
+func (r *StockRouter) GetInvalid(c *fastapi.Context) (*Stock, error) {
+	return &Stock{Code: "600000", Count: -1}, nil
+}
+
+func newStockClient(conf fastapi.Config) *Client {
+	conf.Title = "fastapitest"
+	app := fastapi.New(conf)
+	app.IncludeRouter(&StockRouter{})
+	return NewClient(app)
+}
+
+func TestResponseValidateMode(t *testing.T) {
+	tests := []struct {
+		name       string
+		conf       fastapi.Config
+		wantStatus int
+	}{
+		{name: "default", conf: fastapi.Config{}, wantStatus: http.StatusUnprocessableEntity},
+		{name: "always", conf: fastapi.Config{ResponseValidateMode: fastapi.ResponseValidateAlways}, wantStatus: http.StatusUnprocessableEntity},
+		{name: "off", conf: fastapi.Config{ResponseValidateMode: fastapi.ResponseValidateOff}, wantStatus: http.StatusOK},
+		{name: "disabled", conf: fastapi.Config{DisableResponseValidate: true}, wantStatus: http.StatusOK},
+		{
+			name:       "sampled-all",
+			conf:       fastapi.Config{ResponseValidateMode: fastapi.ResponseValidateSampled, ResponseValidateSampleRate: 100},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := newStockClient(tt.conf).Get("/api/stock/invalid")
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d, body = %s", resp.StatusCode, tt.wantStatus, resp.String())
+			}
+		})
+	}
+
+	t.Run("log-only", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		fastapi.ReplaceLogger(fastapi.NewLogger(buf, "", 0))
+		defer fastapi.ReplaceLogger(fastapi.NewDefaultLogger())
+
+		resp := newStockClient(fastapi.Config{ResponseValidateMode: fastapi.ResponseValidateLogOnly}).Get("/api/stock/invalid")
+		if resp.StatusCode != http.StatusOK || !strings.Contains(resp.String(), `"count":-1`) {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+		if !strings.Contains(buf.String(), "/api/stock/invalid") || !strings.Contains(buf.String(), "gte") {
+			t.Errorf("log = %q", buf.String())
+		}
+	})
+
+	t.Run("sampled-half", func(t *testing.T) {
+		client := newStockClient(fastapi.Config{ResponseValidateMode: fastapi.ResponseValidateSampled, ResponseValidateSampleRate: 50})
+		counts := map[int]int{}
+		for i := 0; i < 200; i++ {
+			counts[client.Get("/api/stock/invalid").StatusCode]++
+		}
+		if counts[http.StatusOK] == 0 || counts[http.StatusUnprocessableEntity] == 0 {
+			t.Errorf("status counts = %v", counts)
+		}
+	})
+}
+
+func TestResponseValidateMode_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		conf fastapi.Config
+	}{
+		{name: "unknown", conf: fastapi.Config{ResponseValidateMode: "sometimes"}},
+		{name: "zero-rate", conf: fastapi.Config{ResponseValidateMode: fastapi.ResponseValidateSampled}},
+		{name: "over-rate", conf: fastapi.Config{ResponseValidateMode: fastapi.ResponseValidateSampled, ResponseValidateSampleRate: 101}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("should panic for invalid response validate config")
+				}
+			}()
+			newStockClient(tt.conf)
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package fastapitest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Chendemo12/fastapi"
+	"github.com/Chendemo12/fastapi/openapi"
+)
+
+var (
+	bearerScheme = fastapi.HTTPBearer("bearer", "JWT")
+	apiKeyScheme = fastapi.APIKeyHeader("apiKey", "X-API-Key")
+	basicScheme  = fastapi.HTTPBasic("basic")
+	oauth2Scheme = fastapi.OAuth2Password("oauth2", "/api/auth/token", map[string]string{"read": "读取"})
+)
+
+type VaultRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *VaultRouter) Prefix() string { return "/api/vault" }
+
+func (r *VaultRouter) Security() []fastapi.SecurityScheme {
+	return []fastapi.SecurityScheme{bearerScheme, apiKeyScheme}
+}
+
+func (r *VaultRouter) RouteSecurity() map[string][]fastapi.SecurityScheme {
+	return map[string][]fastapi.SecurityScheme{
+		"GetPublic": {},
+		"GetBasic":  {basicScheme},
+		"GetMixed":  {bearerScheme, basicScheme, apiKeyScheme},
+	}
+}
+
+func (r *VaultRouter) GetSecret(c *fastapi.Context) (string, error) {
+	return c.Credentials().Scheme + ":" + c.Credentials().Token, nil
+}
+
+func (r *VaultRouter) GetPublic(c *fastapi.Context) (bool, error) {
+	return c.Credentials() == nil, nil
+}
+
+func (r *VaultRouter) GetBasic(c *fastapi.Context) (string, error) {
+	return c.Credentials().Username + ":" + c.Credentials().Password, nil
+}
+
+func (r *VaultRouter) GetMixed(c *fastapi.Context) (string, error) {
+	return c.Credentials().Scheme, nil
+}
+
+func basicAuth(value string) RequestOption {
+	return WithHeader(fastapi.HeaderAuthorization, "Basic "+base64.StdEncoding.EncodeToString([]byte(value)))
+}
+
+func TestSecurity(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&VaultRouter{}).IncludeRouter(&BookRouter{})
+	app.UseSecurity(oauth2Scheme)
+	app.AddSecurityScheme(fastapi.APIKeyQuery("queryKey", "api_key"))
+	client := NewClient(app)
+
+	tests := []struct {
+		name          string
+		path          string
+		opts          []RequestOption
+		wantStatus    int
+		wantBody      string
+		wantChallenge string
+	}{
+		{name: "missing", path: "/api/vault/secret", wantStatus: http.StatusUnauthorized, wantChallenge: "Bearer"},
+		{name: "wrong-scheme", path: "/api/vault/secret", opts: []RequestOption{WithHeader("Authorization", "Token abc")}, wantStatus: http.StatusUnauthorized, wantChallenge: "Bearer"},
+		{name: "bearer", path: "/api/vault/secret", opts: []RequestOption{WithHeader("Authorization", "bearer abc")}, wantStatus: http.StatusOK, wantBody: "bearer:abc"},
+		{name: "api-key", path: "/api/vault/secret", opts: []RequestOption{WithHeader("X-API-Key", "k1")}, wantStatus: http.StatusOK, wantBody: "apiKey:k1"},
+		{name: "public", path: "/api/vault/public", wantStatus: http.StatusOK, wantBody: "true"},
+		{name: "basic", path: "/api/vault/basic", opts: []RequestOption{basicAuth("lee:p:w")}, wantStatus: http.StatusOK, wantBody: "lee:p:w"},
+		{name: "basic-invalid", path: "/api/vault/basic", opts: []RequestOption{basicAuth("lee")}, wantStatus: http.StatusUnauthorized, wantChallenge: "Basic"},
+		{name: "mixed", path: "/api/vault/mixed", wantStatus: http.StatusUnauthorized, wantChallenge: "Bearer, Basic"},
+		{name: "global", path: "/api/book/info", wantStatus: http.StatusUnauthorized, wantChallenge: "Bearer"},
+		{name: "global-bearer", path: "/api/book/info", opts: []RequestOption{WithHeader("Authorization", "Bearer abc")}, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := client.Get(tt.path, tt.opts...)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", resp.StatusCode, tt.wantStatus, resp.String())
+			}
+			if tt.wantBody != "" && resp.String() != tt.wantBody {
+				t.Errorf("body = %s, want %s", resp.String(), tt.wantBody)
+			}
+			if got := resp.Header.Get(fastapi.HeaderWWWAuthenticate); got != tt.wantChallenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantChallenge)
+			}
+		})
+	}
+
+	t.Run("openapi", func(t *testing.T) {
+		doc := map[string]any{}
+		if err := client.Get("/openapi.json").JSON(&doc); err != nil {
+			t.Fatal(err)
+		}
+		schemes := doc["components"].(map[string]any)["securitySchemes"].(map[string]any)
+		want := map[string]string{
+			"bearer":   `{"bearerFormat":"JWT","scheme":"bearer","type":"http"}`,
+			"apiKey":   `{"in":"header","name":"X-API-Key","type":"apiKey"}`,
+			"basic":    `{"scheme":"basic","type":"http"}`,
+			"oauth2":   `{"flows":{"password":{"scopes":{"read":"读取"},"tokenUrl":"/api/auth/token"}},"type":"oauth2"}`,
+			"queryKey": `{"in":"query","name":"api_key","type":"apiKey"}`,
+		}
+		for name, w := range want {
+			if got := marshal(t, schemes[name]); got != w {
+				t.Errorf("%s = %s, want %s", name, got, w)
+			}
+		}
+
+		security := map[string]string{
+			"/api/vault/secret": `[{"bearer":[]},{"apiKey":[]}]`,
+			"/api/vault/public": `null`,
+			"/api/book/info":    `[{"oauth2":[]}]`,
+		}
+		for path, w := range security {
+			op := doc["paths"].(map[string]any)[path].(map[string]any)["get"].(map[string]any)
+			if got := marshal(t, op["security"]); got != w {
+				t.Errorf("%s security = %s, want %s", path, got, w)
+			}
+		}
+	})
+
+	t.Run("oauth2-redirect", func(t *testing.T) {
+		resp := client.Get("/docs/oauth2-redirect")
+		if resp.StatusCode != http.StatusOK || !strings.Contains(resp.String(), "swaggerUIRedirectOauth2") {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+	})
+}
+
+func TestSecurity_DuplicateName(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("should panic for duplicate security scheme name")
+		}
+	}()
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&VaultRouter{})
+	app.UseSecurity(fastapi.HTTPBearer("bearer"))
+	NewClient(app)
+}
+
+// 以键排序的 JSON 字符串比较文档片段
+func marshal(t *testing.T, v any) string {
+	t.Helper()
+	bs, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(bs)
+}
+
+// 从请求头 X-Role 中读取角色的认证方案
+type roleScheme struct{}
+
+func (s roleScheme) SecurityName() string { return "role" }
+
+func (s roleScheme) SecurityDoc() *openapi.SecurityScheme {
+	return &openapi.SecurityScheme{Type: openapi.SecurityTypeApiKey, Name: "X-Role", In: openapi.InHeader}
+}
+
+func (s roleScheme) Credentials(c *fastapi.Context) (*fastapi.Credentials, error) {
+	role := c.MuxContext().GetHeader("X-Role")
+	if role == "" {
+		return nil, fastapi.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+	return &fastapi.Credentials{Scheme: s.SecurityName(), Token: role, Scopes: strings.Split(role, ",")}, nil
+}
+
+type AdminRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *AdminRouter) Prefix() string { return "/api/admin" }
+
+func (r *AdminRouter) Security() []fastapi.SecurityScheme {
+	return []fastapi.SecurityScheme{roleScheme{}}
+}
+
+func (r *AdminRouter) RouteSecurity() map[string][]fastapi.SecurityScheme {
+	return map[string][]fastapi.SecurityScheme{"GetPublic": {}}
+}
+
+func (r *AdminRouter) Scopes() []string { return []string{"admin"} }
+
+func (r *AdminRouter) RouteScopes() map[string][]string {
+	return map[string][]string{
+		"GetAudit": {"admin", "audit"},
+		"GetSelf":  {},
+	}
+}
+
+func (r *AdminRouter) GetUsers(c *fastapi.Context) (string, error) { return "users", nil }
+
+func (r *AdminRouter) GetAudit(c *fastapi.Context) (string, error) { return "audit", nil }
+
+func (r *AdminRouter) GetSelf(c *fastapi.Context) (string, error) { return c.Credentials().Token, nil }
+
+func (r *AdminRouter) GetPublic(c *fastapi.Context) (string, error) { return "public", nil }
+
+type ScopeTypoRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *ScopeTypoRouter) Prefix() string { return "/api/typo" }
+
+func (r *ScopeTypoRouter) RouteScopes() map[string][]string {
+	return map[string][]string{"GetInfo": {"admin"}}
+}
+
+func (r *ScopeTypoRouter) GetInfo(c *fastapi.Context) (string, error) { return "", nil }
+
+func TestRouteScopes(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&AdminRouter{})
+	app.SetRouteErrorFormatter(func(c *fastapi.Context, err error) (int, any) {
+		if e, ok := err.(*fastapi.HTTPError); ok {
+			return e.StatusCode, "formatted: " + e.Detail
+		}
+		return http.StatusInternalServerError, err.Error()
+	})
+	client := NewClient(app)
+
+	tests := []struct {
+		name       string
+		path       string
+		role       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "unauthenticated", path: "/api/admin/users", wantStatus: http.StatusUnauthorized},
+		{name: "forbidden", path: "/api/admin/users", role: "user", wantStatus: http.StatusForbidden, wantBody: `"formatted: Not enough permissions"`},
+		{name: "group", path: "/api/admin/users", role: "user,admin", wantStatus: http.StatusOK, wantBody: "users"},
+		{name: "route-missing-one", path: "/api/admin/audit", role: "admin", wantStatus: http.StatusForbidden},
+		{name: "route", path: "/api/admin/audit", role: "admin,audit", wantStatus: http.StatusOK, wantBody: "audit"},
+		{name: "route-empty", path: "/api/admin/self", role: "user", wantStatus: http.StatusOK, wantBody: "user"},
+		{name: "public", path: "/api/admin/public", wantStatus: http.StatusOK, wantBody: "public"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []RequestOption
+			if tt.role != "" {
+				opts = append(opts, WithHeader("X-Role", tt.role))
+			}
+			resp := client.Get(tt.path, opts...)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", resp.StatusCode, tt.wantStatus, resp.String())
+			}
+			if tt.wantBody != "" && resp.String() != tt.wantBody {
+				t.Errorf("body = %s, want %s", resp.String(), tt.wantBody)
+			}
+		})
+	}
+
+	t.Run("openapi", func(t *testing.T) {
+		doc := map[string]any{}
+		if err := client.Get("/openapi.json").JSON(&doc); err != nil {
+			t.Fatal(err)
+		}
+		security := map[string]string{
+			"/api/admin/users":  `[{"role":["admin"]}]`,
+			"/api/admin/audit":  `[{"role":["admin","audit"]}]`,
+			"/api/admin/self":   `[{"role":[]}]`,
+			"/api/admin/public": `null`,
+		}
+		for path, w := range security {
+			op := doc["paths"].(map[string]any)[path].(map[string]any)["get"].(map[string]any)
+			if got := marshal(t, op["security"]); got != w {
+				t.Errorf("%s security = %s, want %s", path, got, w)
+			}
+		}
+	})
+}
+
+func TestRouteScopes_WithoutSecurity(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("should panic for scopes without security scheme")
+		}
+	}()
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&ScopeTypoRouter{})
+	NewClient(app)
+}
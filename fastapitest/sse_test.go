@@ -0,0 +1,203 @@
+package fastapitest
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Chendemo12/fastapi"
+)
+
+type Progress struct {
+	Percent int    `json:"percent" description:"进度"`
+	Stage   string `json:"stage" description:"阶段"`
+}
+
+type TaskRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *TaskRouter) Prefix() string { return "/api/task" }
+
+// GetProgress 从 Last-Event-ID 之后开始推送进度
+func (r *TaskRouter) GetProgress(c *fastapi.Context) (*fastapi.SSEResponse[Progress], error) {
+	start := 0
+	if id := c.LastEventID(); id != "" {
+		start, _ = strconv.Atoi(id)
+	}
+
+	ch := make(chan fastapi.SSEEvent[Progress])
+	done := c.Done() // Context 在响应结束后会被回收, 不可在协程中直接访问
+	go func() {
+		defer close(ch)
+		for i := start + 1; i <= 3; i++ {
+			select {
+			case <-done:
+				return
+			case ch <- fastapi.SSEEvent[Progress]{ID: strconv.Itoa(i), Event: "progress", Data: Progress{Percent: i * 10, Stage: "run"}}:
+			}
+		}
+	}()
+
+	return fastapi.SSE(ch), nil
+}
+
+// GetForever 只发送心跳, 直到客户端断开
+func (r *TaskRouter) GetForever(c *fastapi.Context) (*fastapi.SSEResponse[string], error) {
+	ch := make(chan fastapi.SSEEvent[string])
+	done := c.Done()
+	go func() {
+		ch <- fastapi.SSEEvent[string]{Data: "start"}
+		<-done
+		forever <- struct{}{}
+	}()
+	return fastapi.SSE(ch).Heartbeat(5 * time.Millisecond), nil
+}
+
+var forever = make(chan struct{}, 1)
+
+// GetIdle 不发送心跳, 发送一条事件后不再产生数据
+func (r *TaskRouter) GetIdle(c *fastapi.Context) (*fastapi.SSEResponse[string], error) {
+	ch := make(chan fastapi.SSEEvent[string])
+	done := c.Done()
+	go func() {
+		ch <- fastapi.SSEEvent[string]{Data: "start"}
+		<-done
+		idle <- struct{}{}
+	}()
+	return fastapi.SSE(ch), nil
+}
+
+var idle = make(chan struct{}, 1)
+
+// 记录响应结束的请求观察者, OnResponse 之后 Context 即被释放
+type releaseObserver struct {
+	released chan string
+}
+
+func (o *releaseObserver) OnRequest(c *fastapi.Context, route fastapi.RouteIface) {}
+
+func (o *releaseObserver) OnResponse(c *fastapi.Context, route fastapi.RouteIface) {
+	o.released <- c.MuxContext().Path()
+}
+
+func TestSSE(t *testing.T) {
+	observer := &releaseObserver{released: make(chan string, 8)}
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&TaskRouter{}).UseObserver(observer)
+	client := NewClient(app)
+
+	t.Run("events", func(t *testing.T) {
+		resp := client.Get("/api/task/progress")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", resp.StatusCode, resp.String())
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Errorf("Content-Type = %s", ct)
+		}
+		if resp.Header.Get("Cache-Control") != "no-cache" {
+			t.Errorf("Cache-Control = %s", resp.Header.Get("Cache-Control"))
+		}
+		want := "id: 1\nevent: progress\ndata: {\"percent\":10,\"stage\":\"run\"}\n\n"
+		if !strings.HasPrefix(resp.String(), want) || strings.Count(resp.String(), "event: progress") != 3 {
+			t.Errorf("body = %q", resp.String())
+		}
+	})
+
+	t.Run("last-event-id", func(t *testing.T) {
+		resp := client.Get("/api/task/progress", WithHeader(fastapi.HeaderLastEventID, "2"))
+		if strings.Count(resp.String(), "event: progress") != 1 || !strings.HasPrefix(resp.String(), "id: 3\n") {
+			t.Errorf("body = %q", resp.String())
+		}
+	})
+
+	t.Run("client-disconnect", func(t *testing.T) {
+		srv := httptest.NewServer(client.Handler())
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL + "/api/task/forever")
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader := bufio.NewReader(resp.Body)
+		for _, want := range []string{"data: start\n", "\n", ": heartbeat\n"} {
+			line, err := reader.ReadString('\n')
+			if err != nil || line != want {
+				t.Fatalf("line = %q, err = %v, want %q", line, err, want)
+			}
+		}
+		_ = resp.Body.Close()
+
+		// 客户端断开后心跳写入失败, 响应结束并关闭 routeCtx
+		select {
+		case <-forever:
+		case <-time.After(2 * time.Second):
+			t.Error("routeCtx should be done after client disconnected")
+		}
+	})
+
+	t.Run("cancel-without-heartbeat", func(t *testing.T) {
+		srv := httptest.NewServer(client.Handler())
+		defer srv.Close()
+		for len(observer.released) > 0 {
+			<-observer.released
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/task/idle", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		if err != nil || line != "data: start\n" {
+			t.Fatalf("line = %q, err = %v", line, err)
+		}
+		cancel()
+
+		// 没有心跳和事件写入, 同样需要察觉到客户端断开, 结束响应并释放 Context
+		select {
+		case path := <-observer.released:
+			if !strings.HasSuffix(path, "/api/task/idle") {
+				t.Errorf("released = %s", path)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Context should be released after the request was canceled")
+		}
+		select {
+		case <-idle:
+		case <-time.After(2 * time.Second):
+			t.Error("routeCtx should be done after the request was canceled")
+		}
+	})
+}
+
+func TestSSE_OpenApi(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&TaskRouter{})
+	resp := NewClient(app).Get("/openapi.json")
+
+	doc := map[string]any{}
+	if err := resp.JSON(&doc); err != nil {
+		t.Fatal(err)
+	}
+	item := doc["paths"].(map[string]any)["/api/task/progress"].(map[string]any)
+	content := item["get"].(map[string]any)["responses"].(map[string]any)["200"].(map[string]any)["content"].(map[string]any)
+	media, ok := content["text/event-stream"].(map[string]any)
+	if !ok {
+		t.Fatalf("200 content = %v", content)
+	}
+	ref, _ := media["schema"].(map[string]any)["$ref"].(string)
+	if !strings.HasSuffix(ref, "Progress") {
+		t.Errorf("event stream schema = %v", media["schema"])
+	}
+	if !strings.Contains(resp.String(), `"percent"`) {
+		t.Error("event payload model missing in components")
+	}
+}
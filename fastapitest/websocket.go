@@ -0,0 +1,274 @@
+package fastapitest
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Chendemo12/fastapi"
+	"github.com/Chendemo12/fastapi/websocket"
+)
+
+// WebSocketClient 用于测试的 websocket 客户端, 可以发送任意(包括不合法的)帧
+type WebSocketClient struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	Response *http.Response // 握手响应
+}
+
+// DialWebSocket 连接到 addr(host:port) 并发起握手请求, 握手响应不是101时也会返回客户端
+func DialWebSocket(addr, path string, header http.Header) (*WebSocketClient, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req := "GET " + path + " HTTP/1.1\r\nHost: " + addr + "\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n"
+	for k, values := range header {
+		for _, v := range values {
+			req += k + ": " + v + "\r\n"
+		}
+	}
+	if _, err = conn.Write([]byte(req + "\r\n")); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	c := &WebSocketClient{conn: conn, reader: bufio.NewReader(conn)}
+	c.Response, err = http.ReadResponse(c.reader, nil)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// WriteFrame 发送一个带掩码的帧
+func (c *WebSocketClient) WriteFrame(fin bool, opcode int, payload []byte) error {
+	b0 := byte(opcode)
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0}
+	switch {
+	case len(payload) <= 125:
+		frame = append(frame, 0x80|byte(len(payload)))
+	case len(payload) <= 0xffff:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+	mask := []byte{0x12, 0x34, 0x56, 0x78}
+	frame = append(frame, mask...)
+	for i, v := range payload {
+		frame = append(frame, v^mask[i%4])
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// ReadFrame 读取一个服务端帧
+func (c *WebSocketClient) ReadFrame() (opcode int, payload []byte, err error) {
+	head := make([]byte, 2)
+	if _, err = io.ReadFull(c.reader, head); err != nil {
+		return
+	}
+	length := int(head[1] & 0x7f)
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err = io.ReadFull(c.reader, ext); err != nil {
+			return
+		}
+		length = int(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err = io.ReadFull(c.reader, ext); err != nil {
+			return
+		}
+		length = int(binary.BigEndian.Uint64(ext))
+	}
+	payload = make([]byte, length)
+	_, err = io.ReadFull(c.reader, payload)
+	return int(head[0] & 0x0f), payload, err
+}
+
+func (c *WebSocketClient) Close() error { return c.conn.Close() }
+
+// ================================ 路由器一致性测试 ================================
+
+// 一致性测试使用的路由
+type conformanceRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *conformanceRouter) Prefix() string { return "/ws" }
+
+func (r *conformanceRouter) Path() map[string]string {
+	return map[string]string{"InfoWS": "info/:name"}
+}
+
+func (r *conformanceRouter) EchoWS(c *fastapi.Context, conn *fastapi.WebSocketConn) error {
+	conn.SetReadLimit(64)
+	for {
+		mt, p, err := conn.ReadMessage()
+		if err != nil {
+			return nil
+		}
+		if err = conn.WriteMessage(mt, p); err != nil {
+			return err
+		}
+	}
+}
+
+// InfoWS 协议升级之后读取请求信息
+func (r *conformanceRouter) InfoWS(c *fastapi.Context, conn *fastapi.WebSocketConn) error {
+	c.Logger().Info("websocket connected")
+	return conn.WriteJSON(map[string]any{
+		"name":       c.PathField("name"),
+		"lang":       c.Query("lang"),
+		"user":       c.MuxContext().GetHeader("X-User"),
+		"request_id": c.RequestID(),
+	})
+}
+
+func (r *conformanceRouter) PanicWS(c *fastapi.Context, conn *fastapi.WebSocketConn) error {
+	panic("websocket route panic")
+}
+
+// RunWebSocketConformance 对路由器的 websocket 实现进行一致性测试,
+// serve 需将 app 绑定到待测试的路由器上并开始监听, 返回监听地址(host:port)
+func RunWebSocketConformance(t *testing.T, serve func(app *fastapi.Wrapper) string) {
+	t.Helper()
+
+	var panics atomic.Int32
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&conformanceRouter{})
+	app.OnPanic(func(c *fastapi.Context, v any, stack []byte) {
+		c.Logger().Error("websocket panic", "error", v)
+		panics.Add(1)
+	})
+	addr := serve(app)
+
+	dial := func(t *testing.T, path string) *WebSocketClient {
+		t.Helper()
+		c, err := DialWebSocket(addr, path, http.Header{"X-User": {"lee"}, "X-Request-Id": {"ws-conformance"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		if c.Response.StatusCode != http.StatusSwitchingProtocols {
+			t.Fatalf("status = %d", c.Response.StatusCode)
+		}
+		return c
+	}
+	write := func(t *testing.T, c *WebSocketClient, fin bool, opcode int, payload string) {
+		t.Helper()
+		if err := c.WriteFrame(fin, opcode, []byte(payload)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect := func(t *testing.T, c *WebSocketClient, opcode int, payload string) {
+		t.Helper()
+		op, p, err := c.ReadFrame()
+		if err != nil || op != opcode || string(p) != payload {
+			t.Errorf("frame = %d, %q, %v; want %d, %q", op, p, err, opcode, payload)
+		}
+	}
+	expectClose := func(t *testing.T, c *WebSocketClient, code int) {
+		t.Helper()
+		op, p, err := c.ReadFrame()
+		if err != nil || op != websocket.CloseMessage || len(p) < 2 || int(binary.BigEndian.Uint16(p)) != code {
+			t.Errorf("frame = %d, %v, %v; want close %d", op, p, err, code)
+		}
+	}
+
+	t.Run("echo", func(t *testing.T) {
+		c := dial(t, "/ws/echo")
+		if accept := c.Response.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+			t.Errorf("Sec-WebSocket-Accept = %s", accept)
+		}
+		write(t, c, true, websocket.TextMessage, "hello")
+		expect(t, c, websocket.TextMessage, "hello")
+		write(t, c, true, websocket.CloseMessage, "\x03\xe8")
+		expectClose(t, c, websocket.CloseNormalClosure)
+	})
+
+	t.Run("fragmentation", func(t *testing.T) {
+		c := dial(t, "/ws/echo")
+		write(t, c, false, websocket.BinaryMessage, "ab")
+		write(t, c, false, 0, "cd")
+		write(t, c, true, 0, "ef")
+		expect(t, c, websocket.BinaryMessage, "abcdef")
+	})
+
+	t.Run("control-frame-inside-fragments", func(t *testing.T) {
+		c := dial(t, "/ws/echo")
+		write(t, c, false, websocket.TextMessage, "ab")
+		write(t, c, true, websocket.PingMessage, "p")
+		expect(t, c, websocket.PongMessage, "p")
+		write(t, c, true, 0, "cd")
+		expect(t, c, websocket.TextMessage, "abcd")
+	})
+
+	t.Run("oversized-frame", func(t *testing.T) {
+		c := dial(t, "/ws/echo")
+		write(t, c, true, websocket.BinaryMessage, strings.Repeat("a", 65))
+		expectClose(t, c, websocket.CloseMessageTooBig)
+	})
+
+	t.Run("oversized-fragments", func(t *testing.T) {
+		c := dial(t, "/ws/echo")
+		write(t, c, false, websocket.BinaryMessage, strings.Repeat("a", 40))
+		write(t, c, true, 0, strings.Repeat("a", 40))
+		expectClose(t, c, websocket.CloseMessageTooBig)
+	})
+
+	t.Run("invalid-close-code", func(t *testing.T) {
+		c := dial(t, "/ws/echo")
+		write(t, c, true, websocket.CloseMessage, "\x03\xed") // 1005 不能出现在关闭帧中
+		expectClose(t, c, websocket.CloseProtocolError)
+	})
+
+	t.Run("request-after-upgrade", func(t *testing.T) {
+		c := dial(t, "/ws/info/golang?lang=zh")
+		op, p, err := c.ReadFrame()
+		if err != nil || op != websocket.TextMessage {
+			t.Fatalf("frame = %d, %s, %v", op, p, err)
+		}
+		info := map[string]string{}
+		_ = json.Unmarshal(p, &info)
+		want := map[string]string{"name": "golang", "lang": "zh", "user": "lee", "request_id": "ws-conformance"}
+		for k, v := range want {
+			if info[k] != v {
+				t.Errorf("%s = %q, want %q", k, info[k], v)
+			}
+		}
+		expectClose(t, c, websocket.CloseNormalClosure)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		before := panics.Load()
+		c := dial(t, "/ws/panic")
+		expectClose(t, c, websocket.CloseInternalServerErr)
+		if panics.Load() != before+1 {
+			t.Errorf("OnPanic was not called")
+		}
+
+		// 服务仍然可用
+		c = dial(t, "/ws/echo")
+		write(t, c, true, websocket.TextMessage, "alive")
+		expect(t, c, websocket.TextMessage, "alive")
+	})
+}
@@ -0,0 +1,201 @@
+package fastapitest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Chendemo12/fastapi"
+	"github.com/Chendemo12/fastapi/websocket"
+)
+
+type ChatRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *ChatRouter) Prefix() string { return "/api/chat" }
+
+func (r *ChatRouter) Path() map[string]string {
+	return map[string]string{"RoomWS": "room/:name"}
+}
+
+// EchoWS 原样返回消息
+func (r *ChatRouter) EchoWS(c *fastapi.Context, conn *fastapi.WebSocketConn) error {
+	for {
+		mt, p, err := conn.ReadMessage()
+		if err != nil {
+			return nil
+		}
+		if err = conn.WriteMessage(mt, p); err != nil {
+			return err
+		}
+	}
+}
+
+// News 与 websocket 路由签名相同, 但 WS 前后缀区分大小写, 因此不是 websocket 路由
+func (r *ChatRouter) News(c *fastapi.Context, conn *fastapi.WebSocketConn) error { return nil }
+
+func (r *ChatRouter) WsdlImport(c *fastapi.Context, conn *fastapi.WebSocketConn) error { return nil }
+
+type RoomMessage struct {
+	Room string `json:"room"`
+	User string `json:"user"`
+}
+
+func (r *ChatRouter) RoomWS(c *fastapi.Context, conn *fastapi.WebSocketConn) error {
+	return conn.WriteJSON(&RoomMessage{Room: c.PathField("name"), User: c.GetString("user")})
+}
+
+func newWebSocketServer(t *testing.T) *httptest.Server {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&ChatRouter{})
+	app.UsePrevious(func(c *fastapi.Context) error {
+		user := c.MuxContext().GetHeader("X-User")
+		if user == "" {
+			c.Status(http.StatusUnauthorized)
+			return errors.New("unauthorized")
+		}
+		c.Set("user", user)
+		return nil
+	})
+
+	srv := httptest.NewServer(NewClient(app).Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// 发起握手请求, 返回连接和响应
+func dialWebSocket(t *testing.T, srv *httptest.Server, path string, header map[string]string) (*WebSocketClient, *http.Response) {
+	t.Helper()
+	h := http.Header{}
+	for k, v := range header {
+		h.Set(k, v)
+	}
+	c, err := DialWebSocket(strings.TrimPrefix(srv.URL, "http://"), path, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c, c.Response
+}
+
+func writeClientFrame(t *testing.T, c *WebSocketClient, opcode int, payload string) {
+	t.Helper()
+	if err := c.WriteFrame(true, opcode, []byte(payload)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readServerFrame(t *testing.T, c *WebSocketClient) (int, string) {
+	t.Helper()
+	op, p, err := c.ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return op, string(p)
+}
+
+func TestWebSocket(t *testing.T) {
+	srv := newWebSocketServer(t)
+
+	t.Run("echo", func(t *testing.T) {
+		conn, resp := dialWebSocket(t, srv, "/api/chat/echo", map[string]string{"X-User": "lee"})
+		if resp.StatusCode != http.StatusSwitchingProtocols {
+			t.Fatalf("status = %d", resp.StatusCode)
+		}
+		if resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+			t.Errorf("Sec-WebSocket-Accept = %s", resp.Header.Get("Sec-WebSocket-Accept"))
+		}
+
+		writeClientFrame(t, conn, websocket.TextMessage, "hello")
+		if op, p := readServerFrame(t, conn); op != websocket.TextMessage || p != "hello" {
+			t.Errorf("echo = %d, %s", op, p)
+		}
+
+		writeClientFrame(t, conn, websocket.CloseMessage, "\x03\xe8")
+		if op, _ := readServerFrame(t, conn); op != websocket.CloseMessage {
+			t.Errorf("opcode = %d, want close", op)
+		}
+	})
+
+	t.Run("path-param-and-deps", func(t *testing.T) {
+		conn, resp := dialWebSocket(t, srv, "/api/chat/room/golang", map[string]string{"X-User": "lee"})
+		if resp.StatusCode != http.StatusSwitchingProtocols {
+			t.Fatalf("status = %d", resp.StatusCode)
+		}
+		if op, p := readServerFrame(t, conn); op != websocket.TextMessage || p != `{"room":"golang","user":"lee"}` {
+			t.Errorf("message = %d, %s", op, p)
+		}
+		if op, _ := readServerFrame(t, conn); op != websocket.CloseMessage {
+			t.Errorf("opcode = %d, want close", op)
+		}
+	})
+
+	t.Run("previous-deps-rejected", func(t *testing.T) {
+		_, resp := dialWebSocket(t, srv, "/api/chat/echo", nil)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("bad-handshake", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/api/chat/echo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		// 依赖函数先于握手校验执行
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("status = %d", resp.StatusCode)
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api/chat/echo", nil)
+		req.Header.Set("X-User", "lee")
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestWebSocket_Conformance(t *testing.T) {
+	RunWebSocketConformance(t, func(app *fastapi.Wrapper) string {
+		srv := httptest.NewServer(NewClient(app).Handler())
+		t.Cleanup(srv.Close)
+		return strings.TrimPrefix(srv.URL, "http://")
+	})
+}
+
+func TestWebSocket_OpenApi(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "fastapitest"})
+	app.IncludeRouter(&ChatRouter{})
+	client := NewClient(app)
+
+	resp := client.Get("/openapi.json")
+	doc := map[string]any{}
+	if err := resp.JSON(&doc); err != nil {
+		t.Fatal(err)
+	}
+	item, ok := doc["paths"].(map[string]any)["/api/chat/room/{name}"].(map[string]any)
+	if !ok {
+		t.Fatalf("openapi.json missing websocket route: %s", resp.String())
+	}
+	responses := item["get"].(map[string]any)["responses"].(map[string]any)
+	if _, ok = responses["101"]; !ok {
+		t.Errorf("websocket route responses = %v", responses)
+	}
+	for _, path := range []string{"/api/chat/ne", "/api/chat/dl-import"} {
+		if _, ok = doc["paths"].(map[string]any)[path]; ok {
+			t.Errorf("'%s' should not be a websocket route", path)
+		}
+	}
+	if paths := doc["paths"].(map[string]any); len(paths) != 2 {
+		t.Errorf("paths = %v", paths)
+	}
+}
@@ -8,45 +8,60 @@ type Finder[T RouteIface] interface {
 	Range(fn func(item T) bool)
 }
 
+// IndexFinder 基于哈希表的查找器, 查找耗时与元素数量无关
+//
+// 哈希表采用开放寻址(线性探测)解决冲突, 其容量为不小于元素数量2倍的2的幂, 查找时会比较完整的唯一标识,
+// 因此哈希冲突不会返回错误的元素; 对于重复的唯一标识, 仅保留第一个元素
 type IndexFinder[T RouteIface] struct {
-	prototype  T                   `description:"todo"`
-	calc       func(id string) int `description:"输入索引获得元素所在集合的下标"`
-	cache      []T
-	elementNum int
+	prototype T
+	cache     []T      `description:"按照初始化顺序保存的元素"`
+	ids       []string `description:"元素的唯一标识, 与 cache 一一对应"`
+	slots     []int32  `description:"哈希表, 值为元素在 cache 中的下标+1, 0表示空槽"`
+	mask      uint64
 }
 
 func (f *IndexFinder[T]) Init(items []T) {
-	f.elementNum = len(items) // 记录元素总数量
-	f.cache = make([]T, f.elementNum)
-
-	const limit = 1 << 16
-	// TODO: 存在问题
-	if f.elementNum < limit {
-		f.calc = func(id string) int {
-			return f.checksum([]byte(id)) % f.elementNum
-		}
-	} else {
-		f.calc = func(id string) int {
-			return f.crc([]byte(id)) % f.elementNum
-		}
+	size := 1
+	for size < len(items)*2 {
+		size <<= 1
 	}
+	f.mask = uint64(size - 1)
+	f.slots = make([]int32, size)
+	f.cache = make([]T, 0, len(items))
+	f.ids = make([]string, 0, len(items))
 
 	for _, item := range items {
-		index := f.calc(item.Id())
-		f.cache[index] = item
+		id := item.Id()
+		index := fnv1a(id) & f.mask
+		for ; f.slots[index] != 0; index = (index + 1) & f.mask {
+			if f.ids[f.slots[index]-1] == id {
+				break
+			}
+		}
+		if f.slots[index] != 0 { // 重复的唯一标识
+			continue
+		}
+		f.cache = append(f.cache, item)
+		f.ids = append(f.ids, id)
+		f.slots[index] = int32(len(f.cache))
 	}
 }
 
 func (f *IndexFinder[T]) Get(id string) (T, bool) {
-	index := f.calc(id)
-	item := f.cache[index]
-	// TODO:
-	return item, true
+	if len(f.slots) == 0 {
+		return f.prototype, false
+	}
+	for index := fnv1a(id) & f.mask; f.slots[index] != 0; index = (index + 1) & f.mask {
+		if i := f.slots[index] - 1; f.ids[i] == id {
+			return f.cache[i], true
+		}
+	}
+	return f.prototype, false
 }
 
 // Range if false returned, for-loop will stop
 func (f *IndexFinder[T]) Range(fn func(item T) bool) {
-	for i := 0; i < f.elementNum; i++ {
+	for i := 0; i < len(f.cache); i++ {
 		b := fn(f.cache[i])
 		if !b {
 			return
@@ -54,24 +69,18 @@ func (f *IndexFinder[T]) Range(fn func(item T) bool) {
 	}
 }
 
-// 经典校验和算法, 适用于长度小于65535个元素
-func (f *IndexFinder[T]) checksum(data []byte) int {
-	sum := 0
-	for i := 0; i < len(data); i += 2 {
-		if i+1 == len(data) {
-			sum += int(data[i])
-		} else {
-			sum += int(data[i])<<8 + int(data[i+1])
-		}
+// 64位 FNV-1a 哈希算法
+func fnv1a(s string) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	hash := uint64(offset64)
+	for i := 0; i < len(s); i++ {
+		hash ^= uint64(s[i])
+		hash *= prime64
 	}
-	sum = (sum >> 16) + (sum & 0xffff)
-	sum += sum >> 16
-
-	return ^sum
-}
-
-func (f *IndexFinder[T]) crc(data []byte) int {
-	return f.checksum(data)
+	return hash
 }
 
 type SimpleFinder[T RouteIface] struct {
@@ -107,6 +116,7 @@ func (s *SimpleFinder[T]) Range(fn func(item T) bool) {
 	}
 }
 
+// DefaultFinder 默认的查找器
 func DefaultFinder() Finder[RouteIface] {
-	return &SimpleFinder[RouteIface]{}
+	return &IndexFinder[RouteIface]{}
 }
@@ -0,0 +1,141 @@
+package fastapi
+
+import (
+	"fmt"
+	"testing"
+	"testing/quick"
+)
+
+// 仅实现了 Id 方法的路由, 用于测试查找器
+type idRoute struct {
+	RouteIface
+	id string
+}
+
+func (r *idRoute) Id() string { return r.id }
+
+func newIdRoutes(ids []string) []RouteIface {
+	routes := make([]RouteIface, len(ids))
+	for i, id := range ids {
+		routes[i] = &idRoute{id: id}
+	}
+	return routes
+}
+
+func TestIndexFinder_Property(t *testing.T) {
+	// 任意一组唯一标识, 全部都可以被找到, 而未注册的唯一标识均无法找到
+	property := func(ids []string, unknown string) bool {
+		registered := map[string]bool{}
+		for _, id := range ids {
+			registered[id] = true
+		}
+
+		finder := &IndexFinder[RouteIface]{}
+		finder.Init(newIdRoutes(ids))
+		for id := range registered {
+			route, ok := finder.Get(id)
+			if !ok || route.Id() != id {
+				return false
+			}
+		}
+		_, ok := finder.Get(unknown)
+		return ok == registered[unknown]
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 500}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIndexFinder(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		finder := &IndexFinder[RouteIface]{}
+		if _, ok := finder.Get("get|/"); ok {
+			t.Error("Get() on uninitialized finder should miss")
+		}
+		finder.Init(nil)
+		if _, ok := finder.Get("get|/"); ok {
+			t.Error("Get() on empty finder should miss")
+		}
+	})
+
+	t.Run("slot-collision", func(t *testing.T) {
+		// 3个元素的哈希表容量为8, 构造落在同一个槽位的唯一标识
+		ids := []string{"get|/0"}
+		for i := 1; len(ids) < 3; i++ {
+			if id := fmt.Sprintf("get|/%d", i); fnv1a(id)&7 == fnv1a(ids[0])&7 {
+				ids = append(ids, id)
+			}
+		}
+		finder := &IndexFinder[RouteIface]{}
+		finder.Init(newIdRoutes(ids))
+		for _, id := range ids {
+			if route, ok := finder.Get(id); !ok || route.Id() != id {
+				t.Errorf("Get(%s) = %v, %v", id, route, ok)
+			}
+		}
+	})
+
+	t.Run("duplicate-and-order", func(t *testing.T) {
+		first := &idRoute{id: "get|/a"}
+		finder := &IndexFinder[RouteIface]{}
+		finder.Init([]RouteIface{first, &idRoute{id: "get|/b"}, &idRoute{id: "get|/a"}})
+		if route, _ := finder.Get("get|/a"); route != first {
+			t.Error("Get() should return the first registered route")
+		}
+
+		var got []string
+		finder.Range(func(item RouteIface) bool {
+			got = append(got, item.Id())
+			return true
+		})
+		if fmt.Sprint(got) != "[get|/a get|/b]" {
+			t.Errorf("Range() = %v", got)
+		}
+	})
+
+	t.Run("many", func(t *testing.T) {
+		ids := benchRouteIds(2000)
+		finder := &IndexFinder[RouteIface]{}
+		finder.Init(newIdRoutes(ids))
+		for _, id := range ids {
+			if route, ok := finder.Get(id); !ok || route.Id() != id {
+				t.Fatalf("Get(%s) = %v, %v", id, route, ok)
+			}
+			if _, ok := finder.Get(id + "/"); ok {
+				t.Fatalf("Get(%s/) should miss", id)
+			}
+		}
+	})
+}
+
+func benchRouteIds(n int) []string {
+	methods := []string{"get", "post", "put", "patch", "delete"}
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%s|/api/v1/group%d/resource%d", methods[i%len(methods)], i/20, i)
+	}
+	return ids
+}
+
+// 对比哈希查找与线性查找, 以最后注册的路由作为最坏情况
+func BenchmarkFinder_Get(b *testing.B) {
+	ids := benchRouteIds(400)
+	finders := []struct {
+		name   string
+		finder Finder[RouteIface]
+	}{
+		{name: "index", finder: &IndexFinder[RouteIface]{}},
+		{name: "simple", finder: &SimpleFinder[RouteIface]{}},
+	}
+	for _, f := range finders {
+		f.finder.Init(newIdRoutes(ids))
+		for _, target := range []struct{ name, id string }{{"first", ids[0]}, {"last", ids[len(ids)-1]}, {"miss", "get|/unknown"}} {
+			b.Run(f.name+"/"+target.name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					f.finder.Get(target.id)
+				}
+			})
+		}
+	}
+}
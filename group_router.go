@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"path"
 	"reflect"
+	"runtime"
 	"strings"
 	"time"
 	"unicode"
@@ -39,6 +40,21 @@ type GroupRouter interface {
 	Path() map[string]string
 }
 
+// GroupDependencies GroupRouter 的可选接口, 为路由组内的全部路由(包含 websocket 路由)添加依赖函数
+//
+//	依赖函数在 Wrapper.UseAfter 之后按顺序执行, 执行顺序为:
+//	UsePrevious -> (请求参数)Validate -> UseAfter -> RateLimit -> Security -> Scopes -> RateLimit(NewPrincipalRateLimiter) -> Dependencies -> RouteDependencies -> Provide -> (路由函数)RouteHandler
+type GroupDependencies interface {
+	Dependencies() []DependenceHandle
+}
+
+// RouteDependencies GroupRouter 的可选接口, 为单个路由方法添加依赖函数, 方法名:依赖函数
+//
+//	依赖函数在路由组的 GroupDependencies.Dependencies 之后执行, 方法名必须是路由组内的路由方法
+type RouteDependencies interface {
+	RouteDependencies() map[string][]DependenceHandle
+}
+
 // BaseGroupRouter (面向对象式)路由组基类
 // 需实现 GroupRouter 接口
 //
@@ -53,6 +69,7 @@ type GroupRouter interface {
 //		如果有多个参数, 除第一个参数和最后一个参数允许为结构体外, 其他参数必须为基本数据类型
 //		对于Get/Delete：除第一个参数外的其他参数均被作为查询参数处理，如果为一个结构体，则对结构体字段进行解析并确定是否必选，如果为基本类型则全部为可选参数;
 //		对于Post/Patch/Put: 其最后一个参数必须为一个 struct指针，此参数会作为请求体进行处理，其他参数则=全部为可选的查询参数
+//		除 Post/Patch/Put 的最后一个参数外, 类型已通过 Provide 注册了提供者的参数均作为依赖注入的参数, 由提供者的返回值填充;
 //
 //	2：返回值
 //
@@ -88,7 +105,7 @@ func (g *BaseGroupRouter) Description() map[string]string {
 
 // =================================== 👇 路由组元数据 ===================================
 
-const WebsocketMethod = "WS"
+const WebsocketMethod = openapi.WebsocketMethod
 const HttpMethodMinimumLength = len(http.MethodGet)
 const (
 	ReceiverParamOffset      = 0                      // 接收器参数的索引位置
@@ -125,8 +142,11 @@ type GroupRouterMeta struct {
 	routerValue    reflect.Value
 	pkg            string // 结构体.包名
 	routes         []*GroupRoute
+	wsRoutes       []*WebSocketRoute
 	tags           []string
 	errorFormatter RouteErrorFormatter
+	security       []SecurityScheme // 默认的认证方案, 由 Wrapper.UseSecurity 设置
+	schemes        []SecurityScheme // 路由组内使用的全部认证方案, 用于生成文档
 }
 
 // NewGroupRouteMeta 构建一个路由组的主入口
@@ -165,6 +185,7 @@ func (r *GroupRouterMeta) Scan() (err error) {
 	}
 
 	r.routes = make([]*GroupRoute, 0)
+	r.wsRoutes = make([]*WebSocketRoute, 0)
 
 	// 扫描tags
 	r.scanTags()
@@ -184,11 +205,21 @@ func (r *GroupRouterMeta) ScanInner() (err error) {
 		}
 	}
 
+	for _, route := range r.wsRoutes {
+		err = route.Init()
+		if err != nil {
+			return err
+		}
+	}
+
 	return
 }
 
 func (r *GroupRouterMeta) Routes() []*GroupRoute { return r.routes }
 
+// WebSocketRoutes websocket 路由
+func (r *GroupRouterMeta) WebSocketRoutes() []*WebSocketRoute { return r.wsRoutes }
+
 // 扫描tags, 由于接口方法允许留空，此处需处理默认值
 func (r *GroupRouterMeta) scanTags() {
 	obj := reflect.TypeOf(r.router)
@@ -247,21 +278,185 @@ func (r *GroupRouterMeta) scanMethod() (err error) {
 	for i := 0; i < obj.NumMethod(); i++ {
 		method := obj.Method(i)
 		swagger, isRoute := r.isRouteMethod(method)
+		isWebSocket := false
 		if !isRoute {
-			continue
+			swagger, isWebSocket = r.isWebSocketMethod(method)
+			if !isWebSocket {
+				continue
+			}
 		}
 		// 匹配到路由方法
 		swagger.Url = r.scanPath(swagger, method)
 		swagger.Summary = r.scanSummary(swagger, method)
 		swagger.Description = r.scanDescription(swagger, method)
-		swagger.Tags = append(r.tags)
+		swagger.Tags = append([]string{}, r.tags...)
+		deps, err := r.scanDependencies(swagger, method)
+		if err != nil {
+			return err
+		}
+
+		if isWebSocket {
+			route := NewWebSocketRoute(swagger, method, r)
+			route.deps = deps
+			r.wsRoutes = append(r.wsRoutes, route)
+		} else {
+			route := NewGroupRoute(swagger, method, r)
+			route.deps = deps
+			r.routes = append(r.routes, route)
+		}
+	}
+
+	return r.checkRouteDependencies()
+}
+
+// 合并路由组和路由方法的依赖函数, 并记录其名称用于文档展示; 依赖函数的顺序为:
+//
+//	限流(NewRateLimiter) -> 认证 -> 鉴权 -> 限流(NewPrincipalRateLimiter) -> 路由组依赖 -> 路由方法依赖
+//
+// 由 NewRateLimiter 创建的限流器在认证之前执行, 因此认证失败的请求同样会消耗额度
+func (r *GroupRouterMeta) scanDependencies(swagger *openapi.RouteSwagger, method reflect.Method) ([]DependenceHandle, error) {
+	var deps []DependenceHandle
+	if router, ok := r.router.(GroupDependencies); ok {
+		deps = append(deps, router.Dependencies()...)
+	}
+	if router, ok := r.router.(RouteDependencies); ok {
+		deps = append(deps, router.RouteDependencies()[method.Name]...)
+	}
+
+	for _, dep := range deps {
+		if dep == nil {
+			return nil, fmt.Errorf("method: '%s' has nil dependence", r.pkg+"."+method.Name)
+		}
+		swagger.Dependencies = append(swagger.Dependencies, funcName(reflect.ValueOf(dep)))
+	}
+
+	var security []DependenceHandle
+	if schemes := r.scanSecurity(method); len(schemes) > 0 {
+		for _, scheme := range schemes {
+			if scheme == nil {
+				return nil, fmt.Errorf("method: '%s' has nil security scheme", r.pkg+"."+method.Name)
+			}
+			swagger.Security = append(swagger.Security, scheme.SecurityName())
+		}
+		r.schemes = append(r.schemes, schemes...)
 
-		r.routes = append(r.routes, NewGroupRoute(swagger, method, r))
+		security = append(security, Security(schemes...))
+		if scopes, _ := r.scanScopes(method); len(scopes) > 0 {
+			swagger.Scopes = scopes
+			security = append(security, RequireScopes(scopes...))
+		}
+	} else if scopes, explicit := r.scanScopes(method); explicit && len(scopes) > 0 {
+		// 路由组的权限范围不作用于无需认证的路由, 但路由方法单独声明的权限范围必须有认证方案
+		return nil, fmt.Errorf("method: '%s' requires scopes but has no security scheme", r.pkg+"."+method.Name)
+	}
+
+	if limiter := r.scanRateLimit(method); limiter != nil {
+		swagger.Responses = append(swagger.Responses, limiter.response())
+		if limiter.afterAuth {
+			security = append(security, limiter.Dependence())
+		} else {
+			security = append([]DependenceHandle{limiter.Dependence()}, security...)
+		}
+	}
+
+	return append(security, deps...), nil
+}
+
+// 路由方法的认证方案, 优先级为: RouteSecurity > GroupSecurity > Wrapper.UseSecurity
+func (r *GroupRouterMeta) scanSecurity(method reflect.Method) []SecurityScheme {
+	schemes := r.security
+	if router, ok := r.router.(GroupSecurity); ok {
+		schemes = router.Security()
+	}
+	if router, ok := r.router.(RouteSecurity); ok {
+		if v, exist := router.RouteSecurity()[method.Name]; exist {
+			schemes = v
+		}
+	}
+
+	return schemes
+}
+
+// 路由方法所需的权限范围, 优先级为: RouteScopes > GroupScopes; explicit 表示由 RouteScopes 声明
+func (r *GroupRouterMeta) scanScopes(method reflect.Method) (scopes []string, explicit bool) {
+	if router, ok := r.router.(GroupScopes); ok {
+		scopes = router.Scopes()
+	}
+	if router, ok := r.router.(RouteScopes); ok {
+		if v, exist := router.RouteScopes()[method.Name]; exist {
+			return v, true
+		}
+	}
+
+	return scopes, false
+}
+
+// 路由方法的限流器, 优先级为: RouteRateLimit > GroupRateLimit
+func (r *GroupRouterMeta) scanRateLimit(method reflect.Method) *RateLimiter {
+	var limiter *RateLimiter
+	if router, ok := r.router.(GroupRateLimit); ok {
+		limiter = router.RateLimit()
+	}
+	if router, ok := r.router.(RouteRateLimit); ok {
+		if v, exist := router.RouteRateLimit()[method.Name]; exist {
+			limiter = v
+		}
+	}
+
+	return limiter
+}
+
+// 检查 RouteDependencies、RouteSecurity、RouteScopes 和 RouteRateLimit 中的方法名是否均为路由方法, 以避免拼写错误导致依赖函数未生效
+func (r *GroupRouterMeta) checkRouteDependencies() error {
+	names := make(map[string]bool)
+	for _, route := range r.routes {
+		names[route.method.Name] = true
+	}
+	for _, route := range r.wsRoutes {
+		names[route.method.Name] = true
+	}
+
+	if router, ok := r.router.(RouteDependencies); ok {
+		for name := range router.RouteDependencies() {
+			if !names[name] {
+				return fmt.Errorf("router: '%s' route dependencies: '%s' is not a route method", r.pkg, name)
+			}
+		}
+	}
+	if router, ok := r.router.(RouteSecurity); ok {
+		for name := range router.RouteSecurity() {
+			if !names[name] {
+				return fmt.Errorf("router: '%s' route security: '%s' is not a route method", r.pkg, name)
+			}
+		}
+	}
+	if router, ok := r.router.(RouteScopes); ok {
+		for name := range router.RouteScopes() {
+			if !names[name] {
+				return fmt.Errorf("router: '%s' route scopes: '%s' is not a route method", r.pkg, name)
+			}
+		}
+	}
+	if router, ok := r.router.(RouteRateLimit); ok {
+		for name := range router.RouteRateLimit() {
+			if !names[name] {
+				return fmt.Errorf("router: '%s' route rate limit: '%s' is not a route method", r.pkg, name)
+			}
+		}
 	}
 
 	return nil
 }
 
+// 函数的名称, 去除包路径, 例如: fastapi.RequireLogin
+func funcName(fn reflect.Value) string {
+	name := runtime.FuncForPC(fn.Pointer()).Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
 // 判断一个方法是不是路由对象
 func (r *GroupRouterMeta) isRouteMethod(method reflect.Method) (*openapi.RouteSwagger, bool) {
 	if len(method.Name) <= HttpMethodMinimumLength {
@@ -330,20 +525,28 @@ func (r *GroupRouterMeta) isRouteMethod(method reflect.Method) (*openapi.RouteSw
 	// 如果有多个入参:
 	//	1. 判断最后一个入参是否符合要求
 	// 	2. 判断请求体参数是否是结构体,20250816 不再支持非结构体参数
+	// 	3. 依赖注入的入参由提供者决定其类型, 无需判断; 但 POST/PATCH/PUT 的最后一个入参始终为请求体
 	if inParamNum > FirstInParamOffset {
+		isProvided := func(i int) bool {
+			_, ok := lookupProvider(method.Type.In(i))
+			return ok && !(notGetOrDelete && i == inParamNum-FirstInParamOffset)
+		}
 		lastInParam := method.Type.In(inParamNum - FirstInParamOffset)
 		if lastInParam.Kind() == reflect.Pointer {
 			// 通常情况是个结构体指针，此时获取实际的类型
 			lastInParam = lastInParam.Elem()
 		}
 		for _, k := range IllegalLastInParamType {
-			if lastInParam.Kind() == k {
+			if lastInParam.Kind() == k && !isProvided(inParamNum-FirstInParamOffset) {
 				// 返回值的第一个参数不符合要求
 				return nil, false
 			}
 		}
 
 		for i := FirstInParamOffset; i < inParamNum; i++ {
+			if isProvided(i) {
+				continue
+			}
 			param := method.Type.In(i)
 			if param.Kind() == reflect.Pointer {
 				// 通常情况是个结构体指针，此时获取实际的类型
@@ -400,13 +603,19 @@ type GroupRoute struct {
 	queryParamMode QueryParamMode        // 查询参数的定义模式
 	method         reflect.Method        // 路由方法所属的结构体方法, 用于API调用
 	queryBinders   []ModelBinder         // 查询参数，路径参数的校验器，不存在参数则为 NothingModelBinder
+	queryDefaults  []any                 // 查询参数的默认值, 与 queryBinders 一一对应
+	pathBinders    []ModelBinder         // 路径参数的校验器
 	inParams       []*openapi.RouteParam // 不包含第一个 Context 但包含最后一个“查询参数结构体”或“请求体”, 因此 handlerInNum - len(inParams) = 1
 	index          int                   // 当前方法所属的结构体方法的偏移量
-	structQuery    int                   // 结构体查询参数在 inParams 中的索引
+	structQueries  []int                 // 结构体查询参数在 inParams 中的索引, 可以存在多个
+	queryOwners    []string              // 查询参数所属的函数入参, 与 swagger.QueryFields 一一对应, 用于提示名称冲突
 	handlerInNum   int                   // 路由函数入参数量，包含 Context, 入参数量可以不固定,但第一个必须是 Context，如果>1:则最后一个视为请求体(Post/Patch/Post)或查询参数(Get/Delete)
 	handlerOutNum  int                   // 路由函数出参数量, 出参数量始终为2,最后一个必须是 error
 	fileParamIndex int                   // 文件参数索引, <1则不存在，因为入参第一个是Context，有效参数从第二个开始
 	getOrDelete    bool                  // GET 或 DELETE 方法
+	deps           []DependenceHandle    // 路由组及路由方法的依赖函数, 在 Wrapper.UseAfter 之后执行
+	injects        []int                 // 依赖注入的入参在路由方法中的位置, 不包含在 inParams 中
+	provides       *providePlan          // 依赖注入计划, 不存在依赖注入的入参则为nil
 }
 
 func NewGroupRoute(swagger *openapi.RouteSwagger, method reflect.Method, group *GroupRouterMeta) *GroupRoute {
@@ -415,7 +624,6 @@ func NewGroupRoute(swagger *openapi.RouteSwagger, method reflect.Method, group *
 	r.swagger = swagger
 	r.group = group
 	r.index = method.Index
-	r.structQuery = -1 // 不存在
 
 	r.queryBinders = make([]ModelBinder, 0)
 
@@ -431,6 +639,11 @@ func (r *GroupRoute) Init() (err error) {
 
 	r.outParam = openapi.NewRouteParam(r.method.Type.Out(FirstOutParamOffset), FirstOutParamOffset, openapi.RouteParamResponse)
 	for n := FirstCustomInParamOffset; n <= r.handlerInNum; n++ {
+		// 已注册提供者的类型作为依赖注入的入参, POST/PATCH/PUT 的最后一个入参除外
+		if _, ok := lookupProvider(r.method.Type.In(n)); ok && (r.getOrDelete || n != r.handlerInNum) {
+			r.injects = append(r.injects, n)
+			continue
+		}
 		if r.getOrDelete {
 			r.inParams = append(r.inParams, openapi.NewRouteParam(r.method.Type.In(n), n, openapi.RouteParamQuery))
 		} else {
@@ -467,6 +680,7 @@ func (r *GroupRoute) Scan() (err error) {
 	// 由于以下几个scan方法需读取内部的反射数据, swagger 层面无法读取,因此在此层面进行解析
 	links := []func() error{
 		r.scanInParams,  // 初始化模型文档
+		r.scanProviders, // 解析依赖注入
 		r.scanOutParams, // 解析返回值
 		r.ScanInner,     // 递归进入下层进行解析
 		r.scanBinders,
@@ -498,7 +712,7 @@ func (r *GroupRoute) scanInParams() (err error) {
 
 	// 遍历处理 swagger
 	for index, param := range r.inParams {
-		isLast := index == r.handlerInNum-FirstCustomInParamOffset
+		isLast := index == len(r.inParams)-1
 		switch param.SchemaType() {
 
 		case openapi.ArrayType:
@@ -517,34 +731,23 @@ func (r *GroupRoute) scanInParams() (err error) {
 			// 判断是否是时间类型, 时间类型全部解释为查询参数
 			qm, ok := scanHelper.InferTimeParam(param)
 			if ok {
-				r.swagger.QueryFields = append(r.swagger.QueryFields, qm)
+				r.addQueryFields(param, qm)
 			} else {
 				if !isLast { // 不是最后一个参数
-					if r.getOrDelete {
-						// GET/DELETE方法不支持多个结构体参数, 打印出结构体方法名，参数索引出从1开始, 排除接收器参数，直接取Index即可
-						// TODO: 后面应支持多个结构体参数
-						return errors.New(fmt.Sprintf(
-							"method: '%s' param: '%s', index: %d cannot be a %s",
-							r.group.pkg+"."+r.method.Name, param.Pkg, param.Index, param.SchemaType(),
-						))
+					if !r.getOrDelete && param.IsFile { // POST/PATCH/PUT 方法的文件参数
+						r.fileParamIndex = index + 1
+						r.swagger.RequestFile = true
 					} else {
-						// POST/PATCH/PUT 方法
-						if param.IsFile {
-							r.fileParamIndex = index + 1
-							r.swagger.RequestFile = true
-						} else {
-							// 非 File 对象，识别为结构体查询参数
-							r.structQuery = index
-							r.swagger.QueryFields = append(r.swagger.QueryFields, openapi.StructToQModels(param.CopyPrototype())...)
-						}
+						// 非 File 对象，识别为结构体查询参数, 支持多个结构体查询参数
+						r.structQueries = append(r.structQueries, index)
+						r.addQueryFields(param, openapi.StructToQModels(param.CopyPrototype())...)
 					}
 				} else {
 					// 最后一个参数, 对于GET/DELETE 视为查询参数, 结构体的每一个字段都将作为一个查询参数;
 					// 对于 POST/PATCH/PUT 接口, 如果是数组则作为请求体，如果是结构体则判断是否是文件，非文件则识别为请求体
 					if r.getOrDelete {
-						r.structQuery = index
-						qms := scanHelper.InferObjectQueryParam(param)
-						r.swagger.QueryFields = append(r.swagger.QueryFields, qms...)
+						r.structQueries = append(r.structQueries, index)
+						r.addQueryFields(param, scanHelper.InferObjectQueryParam(param)...)
 					} else {
 						if param.IsFile {
 							// 仅有一个文件参数，没有其他请求体
@@ -559,7 +762,69 @@ func (r *GroupRoute) scanInParams() (err error) {
 
 		default:
 			// NOTICE: 此处无法获得方法的参数名，只能获得参数类型的名称
-			r.swagger.QueryFields = append(r.swagger.QueryFields, scanHelper.InferBaseQueryParam(param, r.RouteType()))
+			r.addQueryFields(param, scanHelper.InferBaseQueryParam(param, r.RouteType()))
+		}
+	}
+
+	return nil
+}
+
+// 解析依赖注入的入参, 提供者声明的参数结构体会作为结构体查询参数, 排列在路由方法的结构体查询参数之后
+func (r *GroupRoute) scanProviders() (err error) {
+	if len(r.injects) == 0 {
+		return nil
+	}
+
+	types := make([]reflect.Type, len(r.injects))
+	for i, n := range r.injects {
+		types[i] = r.method.Type.In(n)
+	}
+	r.provides, err = newProvidePlan(types, len(r.structQueries))
+	if err != nil {
+		return fmt.Errorf("method: '%s' %w", r.group.pkg+"."+r.method.Name, err)
+	}
+
+	for _, rt := range r.provides.queries {
+		for _, qm := range openapi.StructToQModels(rt) {
+			r.queryOwners = append(r.queryOwners, rt.String())
+			r.swagger.QueryFields = append(r.swagger.QueryFields, qm)
+		}
+	}
+	for _, step := range r.provides.steps {
+		r.swagger.Dependencies = append(r.swagger.Dependencies, step.p.name)
+	}
+
+	return nil
+}
+
+// 添加查询参数并记录其所属的函数入参
+func (r *GroupRoute) addQueryFields(param *openapi.RouteParam, qms ...*openapi.QModel) {
+	for _, qm := range qms {
+		r.queryOwners = append(r.queryOwners, param.Pkg)
+		r.swagger.QueryFields = append(r.swagger.QueryFields, qm)
+	}
+}
+
+// 检查查询参数的名称冲突, 全部位置的参数值都以参数名称记录在 Context.queryFields 中, 因此不同位置的参数名称也不允许重复;
+// 与结构体查询参数引入时的规则一致, 名称比较时忽略大小写; 此方法需在 QModel 初始化之后执行
+func (r *GroupRoute) checkQueryNames() error {
+	names := make(map[string]string)
+	for i, qm := range r.swagger.QueryFields {
+		keys := []string{strings.ToLower(qm.JsonName())}
+		if qm.InStruct && qm.In() != openapi.InQuery {
+			// 请求头和cookie参数同样占用其 query 标签或字段名, 不能与其他参数冲突
+			if key := strings.ToLower(utils.QueryFieldTag(qm.Tag, openapi.QueryTagName, qm.Name)); key != keys[0] {
+				keys = append(keys, key)
+			}
+		}
+		for _, key := range keys {
+			if owner, ok := names[key]; ok {
+				return fmt.Errorf(
+					"method: '%s' %s param '%s' of '%s' conflicts with '%s'",
+					r.group.pkg+"."+r.method.Name, qm.In(), qm.JsonName(), r.queryOwners[i], owner,
+				)
+			}
+			names[key] = r.queryOwners[i]
 		}
 	}
 
@@ -569,27 +834,72 @@ func (r *GroupRoute) scanInParams() (err error) {
 // 从方法出参中初始化路由响应体,并推断出 ContentType
 func (r *GroupRoute) scanOutParams() (err error) {
 	// r.ScanInner -> RouteSwagger.Init -> ResponseModel.Init() 时会自行处理
-	r.swagger.ResponseModel = openapi.NewBaseModelMeta(r.outParam)
+	if !r.outParam.Prototype.Implements(eventStreamerType) {
+		r.swagger.ResponseModel = openapi.NewBaseModelMeta(r.outParam)
+		return err
+	}
+
+	// 服务端推送事件, 文档中显示事件数据的模型
+	dataType := reflect.Zero(r.outParam.Prototype).Interface().(eventStreamer).eventDataType()
+	if dataType.Kind() == reflect.Interface {
+		dataType = reflect.TypeOf(map[string]any{}) // 无法获得具体类型, 文档处缺省为map类型
+	}
+	param := openapi.NewRouteParam(dataType, FirstOutParamOffset, openapi.RouteParamResponse)
+	err = param.Init()
+	if err != nil {
+		return err
+	}
+	r.swagger.ResponseEventStream = true
+	r.swagger.ResponseModel = openapi.NewBaseModelMeta(param)
+
 	return err
 }
 
 // 此方法需在 scanInParams, scanOutParams，ScanInner 执行完成之后执行
 func (r *GroupRoute) scanBinders() (err error) {
-	r.responseBinder = scanHelper.InferResponseBinder(r.swagger.ResponseModel, r.RouteType())
+	err = r.checkQueryNames()
+	if err != nil {
+		return err
+	}
+
+	if r.swagger.ResponseEventStream { // 事件逐条写入, 无法在写入前校验
+		r.responseBinder = NewNothingModelBinder(r.swagger.ResponseModel, openapi.RouteParamResponse)
+	} else {
+		r.responseBinder = scanHelper.InferResponseBinder(r.swagger.ResponseModel, r.RouteType())
+	}
 
 	// 初始化请求体验证方法
-	r.inferRequestBinder()
+	err = r.inferRequestBinder()
+	if err != nil {
+		return err
+	}
+
+	r.pathBinders = scanHelper.InferPathBinders(r.swagger.PathFields, r.RouteType())
 
-	// 构建查询参数验证器
+	// 预先创建结构体查询参数的绑定计划
+	for _, index := range r.structQueries {
+		if _, err = PrepareStructQueryPlan(r.inParams[index].Prototype); err != nil {
+			return fmt.Errorf("method: '%s' %w", r.group.pkg+"."+r.method.Name, err)
+		}
+	}
+
+	// 构建查询参数验证器, 并通过验证器转换默认值
 	for _, qmodel := range r.swagger.QueryFields {
 		binder := scanHelper.InferQueryBinder(qmodel, r.RouteType())
+		value, err := scanHelper.InferQueryDefault(qmodel, binder)
+		if err != nil {
+			return fmt.Errorf("method: '%s' %w", r.group.pkg+"."+r.method.Name, err)
+		}
 		r.queryBinders = append(r.queryBinders, binder)
+		r.queryDefaults = append(r.queryDefaults, value)
 	}
 	return
 }
 
 func (r *GroupRoute) RouteType() RouteType { return RouteTypeGroup }
 
+func (r *GroupRoute) Dependencies() []DependenceHandle { return r.deps }
+
 func (r *GroupRoute) Swagger() *openapi.RouteSwagger {
 	return r.swagger
 }
@@ -598,33 +908,61 @@ func (r *GroupRoute) ResponseBinder() ModelBinder { return r.responseBinder }
 
 func (r *GroupRoute) RequestBinders() ModelBinder { return r.requestBinder }
 
+// PathBinders 路径参数校验方法
+func (r *GroupRoute) PathBinders() []ModelBinder { return r.pathBinders }
+
 // QueryBinders 查询参数校验方法
 func (r *GroupRoute) QueryBinders() []ModelBinder { return r.queryBinders }
 
-func (r *GroupRoute) HasStructQuery() bool { return r.structQuery != -1 }
+func (r *GroupRoute) QueryDefaults() []any { return r.queryDefaults }
+
+func (r *GroupRoute) HasStructQuery() bool {
+	return len(r.structQueries) > 0 || (r.provides != nil && len(r.provides.queries) > 0)
+}
+
+// Provide 调用依赖注入的提供者, 返回值缓存在 Context 内, 由 NewInParams 注入
+func (r *GroupRoute) Provide(ctx *Context) error {
+	if r.provides == nil {
+		return nil
+	}
+	return r.provides.call(ctx)
+}
 
 func (r *GroupRoute) HasFileRequest() bool {
 	return r.fileParamIndex > 0
 }
 
-// NewStructQuery 构造一个新的结构体查询参数实例
-func (r *GroupRoute) NewStructQuery() any {
-	var v reflect.Value
-	if r.inParams[r.structQuery].IsPtr {
-		v = reflect.New(r.inParams[r.structQuery].Prototype.Elem())
-	} else {
-		v = reflect.New(r.inParams[r.structQuery].Prototype)
+// NewStructQueries 构造新的结构体查询参数实例, 按照函数入参的顺序排列, 之后为提供者声明的参数结构体
+func (r *GroupRoute) NewStructQueries() []any {
+	queries := make([]any, len(r.structQueries))
+	for i, index := range r.structQueries {
+		if r.inParams[index].IsPtr {
+			queries[i] = reflect.New(r.inParams[index].Prototype.Elem()).Interface()
+		} else {
+			queries[i] = reflect.New(r.inParams[index].Prototype).Interface()
+		}
+	}
+	if r.provides != nil {
+		for _, rt := range r.provides.queries {
+			queries = append(queries, reflect.New(rt).Interface())
+		}
 	}
 
-	return v.Interface()
+	return queries
 }
 
 func (r *GroupRoute) NewInParams(ctx *Context) []reflect.Value {
-	params := make([]reflect.Value, len(r.inParams)+2) // 接收器 + *Context
-	params[0] = r.group.routerValue                    // 接收器
-	params[1] = reflect.ValueOf(ctx)                   // Context
+	params := make([]reflect.Value, r.handlerInNum+1) // 接收器 + *Context + 其他入参
+	params[0] = r.group.routerValue                   // 接收器
+	params[1] = reflect.ValueOf(ctx)                  // Context
+
+	// 依赖注入的入参, 提供者已在 Provide 中完成调用
+	for _, n := range r.injects {
+		params[n] = ctx.provided[r.method.Type.In(n)]
+	}
 
 	// 处理入参
+	structQuery := 0 // 结构体查询参数的序号
 	for i, param := range r.inParams {
 		var instance reflect.Value
 		isLast := i == len(r.inParams)-1 // 是否是最后一个参数
@@ -648,7 +986,8 @@ func (r *GroupRoute) NewInParams(ctx *Context) []reflect.Value {
 					instance = reflect.ValueOf(ctx.requestModel)
 				} else {
 					// 匹配到结构体查询参数
-					instance = reflect.ValueOf(ctx.queryStruct)
+					instance = reflect.ValueOf(ctx.queryStructs[structQuery])
+					structQuery++
 				}
 			}
 
@@ -657,9 +996,9 @@ func (r *GroupRoute) NewInParams(ctx *Context) []reflect.Value {
 		}
 
 		if param.IsPtr || param.IsTime {
-			params[i+FirstCustomInParamOffset] = instance
+			params[param.Index] = instance
 		} else {
-			params[i+FirstCustomInParamOffset] = instance.Elem()
+			params[param.Index] = instance.Elem()
 		}
 	}
 
@@ -676,20 +1015,24 @@ func (r *GroupRoute) Call(in []reflect.Value) []reflect.Value {
 	return r.method.Func.Call(in)
 }
 
-func (r *GroupRoute) inferRequestBinder() {
+func (r *GroupRoute) inferRequestBinder() error {
 	var nothing ModelBinder = &NothingModelBinder{modelName: "", paramType: openapi.RouteParamRequest}
 
 	if r.swagger.RequestContentType != openapi.MIMEApplicationJSON && r.swagger.RequestContentType != openapi.MIMEApplicationJSONCharsetUTF8 && r.swagger.RequestContentType != openapi.MIMEMultipartForm {
 		// 暂不支持非json和multiform-data的请求参数验证
 		r.requestBinder = nothing
-		return
+		return nil
 	}
 
 	if r.swagger.Method == http.MethodPost || r.swagger.Method == http.MethodPut || r.swagger.Method == http.MethodPatch {
 		if r.swagger.RequestFile {
 			// 存在上传文件定义，则从 multiform-data 中获取上传参数
 			if r.swagger.RequestModel != nil && r.swagger.RequestModel.SchemaPkg() != openapi.NoneRequestPkg { // file + json
-				binder := &FileWithParamModelBinder{}
+				defaults, err := scanHelper.InferFieldDefaults(r.swagger.RequestModel.Param.Prototype, openapi.RouteParamRequest)
+				if err != nil {
+					return fmt.Errorf("method: '%s' %w", r.group.pkg+"."+r.method.Name, err)
+				}
+				binder := &FileWithParamModelBinder{defaults: defaults}
 				binder.paramType = openapi.RouteParamRequest
 				binder.modelName = r.swagger.RequestModel.JsonName()
 				r.requestBinder = binder
@@ -708,4 +1051,6 @@ func (r *GroupRoute) inferRequestBinder() {
 	} else { // get/delete 方法没有请求体
 		r.requestBinder = nothing
 	}
+
+	return nil
 }
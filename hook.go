@@ -1,8 +1,13 @@
 package fastapi
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
 
 	"github.com/Chendemo12/fastapi/openapi"
 )
@@ -12,12 +17,31 @@ import (
 //	程序启动时会主动调用此方法用于生成openApi文档，所以此函数不应返回 map等类型，否则将无法生成openApi文档
 //
 //	当路由函数返回错误时，会调用此函数，返回值会作为响应码和响应内容, 返回值仅限于可以JSON序列化的消息体
-//	默认情况下，错误码为500，错误信息会作为字符串直接返回给客户端
+//	默认情况下，错误码为500，错误信息会作为字符串直接返回给客户端; 对于 HTTPError 则以其状态码返回其自身.
+//	请求路径不存在(404)或请求方法不被允许(405)时, 同样会以 HTTPError 调用此函数, 此时 Context 不包含路由信息, Context.RouteId 为空字符串
 type RouteErrorFormatter func(c *Context, err error) (statusCode int, resp any)
 
 // DependenceHandle 依赖函数 Depends/Hook
 type DependenceHandle func(c *Context) error
 
+// PanicHandle 路由发生panic时的钩子方法, v 为 recover 的返回值, stack 为发生panic时的调用栈
+type PanicHandle func(c *Context, v any, stack []byte)
+
+// RequestObserver 请求观察者, 通过 Wrapper.UseObserver 注册, 不可修改响应
+type RequestObserver interface {
+	// OnRequest 找到路由并创建 Context 之后, 在全部依赖函数之前调用; 发生 panic 时与路由函数一样被恢复并返回500响应
+	OnRequest(c *Context, route RouteIface)
+	// OnResponse 响应写入完成(包括响应流异步写入结束)之后, 释放 Context 之前调用, 与 OnRequest 一一对应;
+	// 对于异步写入的响应流, 此时 c.MuxContext() 仅保留请求方法、路径、客户端IP和路径参数, 发生 panic 时仅执行 OnPanic 钩子
+	OnResponse(c *Context, route RouteIface)
+}
+
+// 默认的panic钩子方法
+var defaultPanicHandle PanicHandle = func(c *Context, v any, stack []byte) {
+	structured.Error("panic recovered",
+		"method", c.muxCtx.Method(), "path", c.muxCtx.Path(), "route", c.RouteId(), "error", v, "stack", string(stack))
+}
+
 // RouteErrorOpt 错误处理函数选项, 用于在 SetRouteErrorFormatter 方法里同时设置错误码和响应内容等内容
 type RouteErrorOpt struct {
 	StatusCode   int                 `json:"statusCode" validate:"required" description:"请求错误时的状态码"`
@@ -28,6 +52,11 @@ type RouteErrorOpt struct {
 
 // 默认的错误处理函数
 var defaultRouteErrorFormatter RouteErrorFormatter = func(c *Context, err error) (statusCode int, resp any) {
+	var he *HTTPError
+	if errors.As(err, &he) {
+		return he.StatusCode, he
+	}
+
 	if c.response.StatusCode != 0 {
 		statusCode = c.response.StatusCode
 	} else {
@@ -47,22 +76,75 @@ var defaultRouteErrorFormatter RouteErrorFormatter = func(c *Context, err error)
 // 反之：
 //
 //  1. 申请一个 Context, 并初始化请求体、路由参数等
-//  2. 之后会校验并绑定路由参数（包含路径参数和查询参数）是否正确，如果错误则直接返回422错误，反之会继续序列化并绑定请求体（如果存在）序列化成功之后会校验请求参数的正确性，
-//  3. 校验通过后会调用 RouteIface.Call 并将返回值绑定在 Context 内的 Response 上
-//  4. 校验返回值，并返回422或将返回值写入到实际的 response
-func (f *Wrapper) Handler(ctx MuxContext) error {
-	route, exist := f.finder.Get(openapi.CreateRouteIdentify(ctx.Method(), ctx.Path()))
+//  2. UsePrevious: 执行校验前依赖函数
+//  3. Validate: 校验并绑定路由参数（包含路径参数和查询参数）和请求体, 校验失败则返回422
+//  4. UseAfter: 执行校验后依赖函数
+//  5. RateLimit: 由 NewRateLimiter 创建的限流器(GroupRateLimit, RouteRateLimit)
+//  6. Security -> Scopes: 路由组的认证方案(GroupSecurity, RouteSecurity)和权限范围(GroupScopes, RouteScopes)
+//  7. RateLimit: 由 NewPrincipalRateLimiter 创建的限流器
+//  8. Dependencies: 路由组及路由方法的依赖函数(GroupDependencies, RouteDependencies)
+//  9. Provide: 依赖注入的提供者
+//  10. RouteHandler: 调用 RouteIface.Call 并将返回值绑定在 Context 内的 Response 上
+//  11. Validate: 校验返回值，并返回422或将返回值写入到实际的 response
+//
+// 2~9 任一环节返回错误时均会终止后续流程, 并通过 RouteErrorFormatter 返回
+//
+// 以上任一环节发生panic时, 均会执行 Wrapper.OnPanic 钩子并通过 RouteErrorFormatter 返回500响应
+func (f *Wrapper) Handler(ctx MuxContext) (err error) {
+	if f.cors != nil {
+		if isPreflight(ctx) { // 自定义的 OPTIONS 路由同样不处理预检请求
+			return f.optionsHandler(ctx)
+		}
+		f.cors.writeHeaders(ctx)
+	}
+
+	method := ctx.Method()
+	if method == http.MethodHead { // HEAD 请求与 GET 请求共用路由, 由路由器丢弃响应体
+		method = http.MethodGet
+	}
+	route, exist := f.finder.Get(openapi.CreateRouteIdentify(method, ctx.Path()))
 	if !exist {
 		// 正常来说，通过 Wrapper 注册的路由，不会走到这个分支
 		return nil
 	}
 
 	// 找到定义的路由信息
-	wrapperCtx := f.acquireCtx(ctx)
-	defer f.releaseCtx(wrapperCtx)
+	wrapperCtx := f.acquireCtx(ctx, route)
+	defer func() {
+		if done := wrapperCtx.streamDone; done != nil {
+			// 响应流仍在异步写入, 待其结束后再释放; 路由器可能在 MuxHandler 返回后回收其 Context, 因此先复制请求信息
+			wrapperCtx.muxCtx = newDetachedMuxContext(wrapperCtx.muxCtx, nil, wrapperCtx.pathFields)
+			go func() {
+				defer f.releaseCtx(wrapperCtx)
+				defer func() { // 此协程不再由路由器恢复 panic
+					if v := recover(); v != nil {
+						f.onPanic(wrapperCtx, v, debug.Stack())
+					}
+				}()
+
+				<-done
+				if wrapperCtx.routeCancel != nil {
+					wrapperCtx.routeCancel()
+				}
+				f.observeResponse(wrapperCtx, route)
+			}()
+			return
+		}
+		f.observeResponse(wrapperCtx, route)
+		f.releaseCtx(wrapperCtx)
+	}()
+	defer func() {
+		if v := recover(); v != nil {
+			err = f.recoverPanic(wrapperCtx, route, v)
+		}
+	}()
+
+	// 观察者位于 defer 之后, 以便其发生 panic 时同样可以恢复并释放 Context
+	for _, observer := range f.observers {
+		observer.OnRequest(wrapperCtx, route)
+	}
 
 	// 校验前依赖函数
-	var err error
 	for _, dep := range f.previousDeps {
 		err = dep(wrapperCtx)
 		if err != nil {
@@ -89,6 +171,22 @@ func (f *Wrapper) Handler(ctx MuxContext) error {
 		}
 	}
 
+	// 执行路由组及路由方法的依赖函数
+	for _, dep := range route.Dependencies() {
+		err = dep(wrapperCtx)
+		if err != nil {
+			wrapperCtx.response.StatusCode, wrapperCtx.response.Content = f.routeErrorFormatter(wrapperCtx, err)
+			return f.write(wrapperCtx, route, openapi.MIMEApplicationJSONCharsetUTF8)
+		}
+	}
+
+	// 调用依赖注入的提供者
+	err = route.Provide(wrapperCtx)
+	if err != nil {
+		wrapperCtx.response.StatusCode, wrapperCtx.response.Content = f.routeErrorFormatter(wrapperCtx, err)
+		return f.write(wrapperCtx, route, openapi.MIMEApplicationJSONCharsetUTF8)
+	}
+
 	//
 	// 全部校验完成，执行处理函数并获取返回值, 此处已经完成全部请求参数的校验，调用失败也存在返回值
 	params := route.NewInParams(wrapperCtx)
@@ -100,7 +198,7 @@ func (f *Wrapper) Handler(ctx MuxContext) error {
 		wrapperCtx.response.Content = result[FirstOutParamOffset].Interface()
 
 		// 路由后的校验，校验失败就地修改 Response
-		hasError = wrapperCtx.afterWorkflow(route, f.conf.DisableResponseValidate, f.conf.StopImmediatelyWhenErrorOccurs)
+		hasError = wrapperCtx.afterWorkflow(route, f.conf.responseValidateMode(), f.conf.StopImmediatelyWhenErrorOccurs)
 		if hasError {
 			// 校验工作流不通过, 中断执行
 			return f.write(wrapperCtx, route, openapi.MIMEApplicationJSONCharsetUTF8)
@@ -117,6 +215,29 @@ func (f *Wrapper) Handler(ctx MuxContext) error {
 	}
 }
 
+// 通知请求观察者响应已写入完成
+func (f *Wrapper) observeResponse(c *Context, route RouteIface) {
+	for _, observer := range f.observers {
+		observer.OnResponse(c, route)
+	}
+}
+
+// 处理路由中发生的panic, 执行钩子并返回500响应; http.ErrAbortHandler 会被继续抛出以中断请求
+func (f *Wrapper) recoverPanic(c *Context, route RouteIface, v any) error {
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+
+	f.onPanic(c, v, debug.Stack())
+	if c.written { // 响应已经开始写入(包括响应流), 无法再修改响应, 也不再重复执行写入钩子和访问日志
+		return nil
+	}
+
+	c.response.StatusCode, c.response.Content = f.routeErrorFormatter(c, NewHTTPError(http.StatusInternalServerError))
+
+	return f.write(c, route, openapi.MIMEApplicationJSONCharsetUTF8)
+}
+
 // ----------------------------------------	路由前的各种校验工作 ----------------------------------------
 
 // 执行用户自定义钩子函数前的工作流
@@ -137,13 +258,18 @@ func (c *Context) beforeWorkflow(route RouteIface, stopImmediately bool) (hasErr
 
 // ----------------------------------------	路由后的响应体校验工作 ----------------------------------------
 
-// 主要是对响应体是否符合tag约束的校验，
-func (c *Context) afterWorkflow(route RouteIface, disableResponseValidate, stopImmediately bool) (hasError bool) {
+// 主要是对响应体是否符合tag约束的校验，对于 ResponseValidateLogOnly 模式, 校验失败时仅记录日志
+func (c *Context) afterWorkflow(route RouteIface, mode ResponseValidateMode, stopImmediately bool) (hasError bool) {
 	var ves []*openapi.ValidationError
 
 	for _, link := range responseValidateLinks {
-		ves = link(c, route, disableResponseValidate, stopImmediately)
+		ves = link(c, route, mode, stopImmediately)
 		if len(ves) > 0 { // 当任意环节校验失败时,即终止下文环节
+			if mode == ResponseValidateLogOnly {
+				structured.Warn("response validate failed",
+					"method", route.Swagger().Method, "path", route.Swagger().Url, "route", route.Id(), "error", ves[0].Error())
+				return false
+			}
 			// 校验不通过, 修改 Response.StatusCode 和 Response.Content
 			c.response.StatusCode = http.StatusUnprocessableEntity
 			c.response.Content = &openapi.HTTPValidationError{Detail: ves}
@@ -153,15 +279,31 @@ func (c *Context) afterWorkflow(route RouteIface, disableResponseValidate, stopI
 	return
 }
 
+// 执行写入响应之前的钩子, 访问日志的钩子始终在 UseBeforeWrite 之前执行
+func (f *Wrapper) runBeforeWrite(c *Context) {
+	if f.accessLog != nil {
+		f.accessLog.beforeWrite(c)
+	}
+	f.beforeWrite(c)
+}
+
 // 写入响应体, 依据 contentType 的不同，有不同的写入行为
 func (f *Wrapper) write(c *Context, route RouteIface, contentType openapi.ContentType) error {
+	c.written = true
+	if contentType == openapi.MIMETextEventStream { // 服务端推送事件, 由其自行关闭 routeCtx 和输出访问日志
+		return f.writeEventStream(c, route)
+	}
+	if f.accessLog != nil {
+		defer f.accessLog.log(c)
+	}
+
 	defer func() {
 		if c.routeCancel != nil {
 			c.routeCancel() // 当路由执行完毕时立刻关闭
 		}
 	}()
 
-	f.beforeWrite(c) // 执行钩子
+	f.runBeforeWrite(c) // 执行钩子
 
 	// 设置状态码
 	c.muxCtx.Status(c.response.StatusCode)
@@ -176,7 +318,7 @@ func (f *Wrapper) write(c *Context, route RouteIface, contentType openapi.Conten
 	case openapi.MIMEOctetStream: // 返回一个字节流或文件
 		if file, ok := c.response.Content.(*FileResponse); !ok {
 			c.muxCtx.Status(http.StatusInternalServerError)
-			return c.muxCtx.JSON(http.StatusInternalServerError, fmt.Sprintf("'%s' the return value type is not *FileResponse", route.Swagger().RelativePath))
+			return c.muxCtx.JSON(http.StatusInternalServerError, fmt.Sprintf("'%s' the return value type is not *FileResponse", routeRelativePath(c, route)))
 		} else { // 返回一个文件
 			switch file.mode {
 			case FileResponseModeSendFile:
@@ -191,7 +333,7 @@ func (f *Wrapper) write(c *Context, route RouteIface, contentType openapi.Conten
 				c.muxCtx.Header(openapi.HeaderContentType, string(contentType))
 				return c.muxCtx.SendStream(file.reader, -1)
 			default:
-				return c.muxCtx.JSON(http.StatusInternalServerError, fmt.Sprintf("'%s' the return value has wrong field", route.Swagger().RelativePath))
+				return c.muxCtx.JSON(http.StatusInternalServerError, fmt.Sprintf("'%s' the return value has wrong field", routeRelativePath(c, route)))
 			}
 		}
 
@@ -201,6 +343,99 @@ func (f *Wrapper) write(c *Context, route RouteIface, contentType openapi.Conten
 	}
 }
 
+// 以 text/event-stream 的形式逐条写入事件, 直到事件 channel 关闭、routeCtx 完成或客户端断开
+func (f *Wrapper) writeEventStream(c *Context, route RouteIface) error {
+	stream, ok := c.response.Content.(eventStreamer)
+	writer, canStream := c.muxCtx.(StreamWriter)
+	if !ok || !canStream {
+		defer func() {
+			if c.routeCancel != nil {
+				c.routeCancel()
+			}
+		}()
+		if f.accessLog != nil {
+			defer f.accessLog.log(c)
+		}
+		f.runBeforeWrite(c)
+		c.muxCtx.Status(http.StatusInternalServerError)
+		if !ok {
+			return c.muxCtx.JSON(http.StatusInternalServerError, fmt.Sprintf("'%s' the return value type is not *SSEResponse", routeRelativePath(c, route)))
+		}
+		return c.muxCtx.JSON(http.StatusInternalServerError, fmt.Sprintf("mux context '%T' does not implement fastapi.StreamWriter", c.muxCtx))
+	}
+
+	f.runBeforeWrite(c) // 执行钩子
+
+	c.muxCtx.Header(openapi.HeaderContentType, string(openapi.MIMETextEventStream))
+	c.muxCtx.Header("Cache-Control", "no-cache")
+	c.muxCtx.Header("Connection", "keep-alive")
+	c.muxCtx.Header("X-Accel-Buffering", "no") // 禁用 nginx 缓冲
+	c.muxCtx.Status(c.response.StatusCode)
+
+	done := c.Done()
+	if done == nil { // 未启用 context 自动派生
+		done = c.appCtx.Done()
+	}
+
+	// 响应流可能在 MuxContext 被回收之后才写入, 因此提前生成访问日志, 写入结束后再更新耗时和响应体字节数
+	var entry *AccessLog
+	if f.accessLog != nil {
+		entry = f.accessLog.entry(c)
+	}
+	startedAt := c.startedAt
+
+	finished := make(chan struct{})
+	err := writer.SendStreamWriter(func(w io.Writer, flush func() error, closed <-chan struct{}) error {
+		defer close(finished)
+		cw := &countWriter{w: w}
+		defer func() {
+			if entry != nil {
+				entry.Latency = float64(time.Since(startedAt).Microseconds()) / 1000
+				entry.ResponseSize = cw.n
+				f.accessLog.output(entry)
+			}
+		}()
+		return stream.serveEvents(done, closed, cw, flush)
+	})
+
+	select {
+	case <-finished:
+	default:
+		if err == nil { // 异步写入中, 由 Handler 在写入结束后关闭 routeCtx 并释放 Context
+			c.streamDone = finished
+			return nil
+		}
+		if entry != nil { // fn 未被调用
+			f.accessLog.output(entry)
+		}
+	}
+
+	if c.routeCancel != nil {
+		c.routeCancel()
+	}
+	return err
+}
+
+// 路由的相对路径, 用于错误信息; 没有匹配的路由时为请求路径
+func routeRelativePath(c *Context, route RouteIface) string {
+	if route == nil {
+		return c.muxCtx.Path()
+	}
+	return route.Swagger().RelativePath
+}
+
+// 记录写入的字节数
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
 var requestValidateLinks = []func(c *Context, route RouteIface, stopImmediately bool) []*openapi.ValidationError{
 	pathParamsValidate,  // 路径参数校验
 	queryParamsValidate, // 查询参数校验
@@ -208,11 +443,11 @@ var requestValidateLinks = []func(c *Context, route RouteIface, stopImmediately
 	requestBodyValidate, // 请求体自动校验
 }
 
-var responseValidateLinks = []func(c *Context, route RouteIface, disableResponseValidate, stopImmediately bool) []*openapi.ValidationError{
+var responseValidateLinks = []func(c *Context, route RouteIface, mode ResponseValidateMode, stopImmediately bool) []*openapi.ValidationError{
 	responseValidate, // 路由返回值校验
 }
 
-// 路径参数校验, 路径参数均为字符串类型，主要验证是否存在，不校验范围、值是否合理
+// 路径参数校验, 首先验证是否存在, 之后按照声明的类型进行转换并校验范围
 // 对于路径参数，如果缺少理论上不会匹配到相应的路由
 func pathParamsValidate(c *Context, route RouteIface, stopImmediately bool) []*openapi.ValidationError {
 	var ves []*openapi.ValidationError
@@ -226,7 +461,7 @@ func pathParamsValidate(c *Context, route RouteIface, stopImmediately bool) []*o
 			ves = append(ves, &openapi.ValidationError{
 				Loc:  []string{"path", p.SchemaTitle()},
 				Msg:  PathPsIsEmpty,
-				Type: string(openapi.StringType), // 路径参数都是字符串类型
+				Type: string(p.DataType),
 				Ctx:  whereClientError,
 			})
 			if stopImmediately {
@@ -235,6 +470,24 @@ func pathParamsValidate(c *Context, route RouteIface, stopImmediately bool) []*o
 		}
 	}
 
+	if len(ves) > 0 { // 存在缺失参数
+		return ves
+	}
+
+	// 按照声明的类型转换并校验路径参数, PathBinders 与 PathFields 一一对应
+	for i, binder := range route.PathBinders() {
+		name := route.Swagger().PathFields[i].JsonName()
+		value, err := binder.Validate(c, c.pathFields[name])
+		if err != nil {
+			ves = append(ves, err...)
+			if stopImmediately {
+				break
+			}
+		} else {
+			c.pathValues[name] = value
+		}
+	}
+
 	return ves
 }
 
@@ -243,7 +496,7 @@ func pathParamsValidate(c *Context, route RouteIface, stopImmediately bool) []*o
 //	对于查询参数，仅自定义校验非结构体查询参数，对于结构体查询参数通过反序列化结构体后利用validate实现
 //
 //	验证顺序：
-//		验证是否缺少必选参数, 缺少则break
+//		验证是否缺少必选参数, 缺少则break; 对于缺失但声明了默认值的参数, 使用启动时转换的默认值
 //		根据数据类型转换参数值, 不符合数值类型则break
 //		验证数值是否符合范围约束, 不符合则break
 //
@@ -253,18 +506,27 @@ func pathParamsValidate(c *Context, route RouteIface, stopImmediately bool) []*o
 func queryParamsValidate(c *Context, route RouteIface, stopImmediately bool) []*openapi.ValidationError {
 	var ves []*openapi.ValidationError
 
-	// 验证是否缺少必选参数
-	for _, q := range route.Swagger().QueryFields {
-		value := c.muxCtx.Query(q.JsonName(), "")
-		if value != "" {
+	// 验证是否缺少必选参数, 声明了默认值的参数不会缺失
+	defaults := route.QueryDefaults()
+	for i, q := range route.Swagger().QueryFields {
+		var value any
+		if q.IsArray {
+			if values := c.queryArray(q); len(values) > 0 {
+				value = values
+			}
+		} else if v := c.paramValue(q); v != "" {
+			value = v
+		}
+
+		if value != nil {
 			// 记录传入参数值，如果是空字符串则不记录，否则会影响 Query 方法的使用
 			c.queryFields[q.JsonName()] = value
 		} else {
-			if q.IsRequired() {
+			if q.IsRequired() && defaults[i] == nil {
 				// 但是此查询参数设置为必选
 				ves = append(ves, &openapi.ValidationError{
-					Loc:  []string{"query", q.JsonName()},
-					Msg:  QueryPsIsEmpty,
+					Loc:  []string{string(q.In()), q.JsonName()},
+					Msg:  paramEmptyMsg(q),
 					Type: string(q.DataType),
 					Ctx:  whereClientError,
 				})
@@ -281,46 +543,125 @@ func queryParamsValidate(c *Context, route RouteIface, stopImmediately bool) []*
 
 	// 根据数据类型转换并校验参数值，比如: 定义为int类型，但是参数值为“abc”，虽然是存在的但是不合法
 	// 转换规则按照 QModel 定义进行，只有转换成功后才进行校验
-	for _, binder := range route.QueryBinders() {
-		v, ok := c.queryFields[binder.ModelName()]
-		if !ok { // 此参数值不存在
+	// QueryBinders 与 QueryFields 一一对应, 对于结构体查询参数, binder.ModelName 为字段名而非查询参数名
+	for i, binder := range route.QueryBinders() {
+		q := route.Swagger().QueryFields[i]
+		name := q.JsonName()
+		v, ok := c.queryFields[name]
+		if !ok { // 此参数值不存在, 使用已经转换过的默认值(如果存在)
+			if defaults[i] != nil {
+				c.queryFields[name] = defaults[i]
+			}
 			continue
 		}
 
-		sv := v.(string)
-		value, err := binder.Validate(c, sv)
+		value, err := binder.Validate(c, v) // 字符串或字符串数组
 		if err != nil {
+			if q.In() != openapi.InQuery { // 请求头和cookie参数以其名称而非字段名显示
+				for _, ve := range err {
+					ve.Loc = []string{string(q.In()), name}
+				}
+			}
 			ves = append(ves, err...)
 			if stopImmediately {
 				break
 			}
 		} else {
-			c.queryFields[binder.ModelName()] = value
+			c.queryFields[name] = value
 		}
 	}
 
 	return ves
 }
 
+// 按照参数位置读取查询参数、请求头参数或cookie参数的原始值, 不存在则为空字符串
+func (c *Context) paramValue(q *openapi.QModel) string {
+	switch q.In() {
+	case openapi.InHeader:
+		return c.muxCtx.GetHeader(q.JsonName())
+	case openapi.InCookie:
+		v, err := c.muxCtx.Cookie(q.JsonName())
+		if err != nil {
+			return ""
+		}
+		return v
+	default:
+		return c.muxCtx.Query(q.JsonName(), "")
+	}
+}
+
+// 读取数组查询参数, 对于 explode=false 的参数按照分隔符拆分, 忽略空值
+func (c *Context) queryArray(q *openapi.QModel) []string {
+	var raw []string
+	if reader, ok := c.muxCtx.(QueryArrayReader); ok {
+		raw = reader.QueryArray(q.JsonName())
+	} else if v := c.muxCtx.Query(q.JsonName(), ""); v != "" {
+		raw = []string{v}
+	}
+
+	values := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if q.Explode {
+			if v != "" {
+				values = append(values, v)
+			}
+			continue
+		}
+		for _, item := range strings.Split(v, q.Delimiter()) {
+			if item != "" {
+				values = append(values, item)
+			}
+		}
+	}
+
+	return values
+}
+
+// 必选参数缺失时的错误信息
+func paramEmptyMsg(q *openapi.QModel) string {
+	switch q.In() {
+	case openapi.InHeader:
+		return HeaderPsIsEmpty
+	case openapi.InCookie:
+		return CookiePsIsEmpty
+	default:
+		return QueryPsIsEmpty
+	}
+}
+
 // 验证结构体查询参数(如果存在)
+//
+//	结构体按照启动时创建的 StructQueryPlan 直接从 Context.queryFields 中绑定属于自己的字段, 参数值已经过类型转换;
+//	对于多个结构体查询参数, 由于启动时已确保参数名称不存在冲突, 每一个结构体独立绑定和校验
 func structQueryValidate(c *Context, route RouteIface, stopImmediately bool) []*openapi.ValidationError {
 	if !route.HasStructQuery() { // 不存在
 		return nil
 	}
 
-	c.queryStruct = route.NewStructQuery()
+	c.queryStructs = route.NewStructQueries()
 
-	values := map[string]any{}
-	for _, q := range route.Swagger().QueryFields {
-		if q.InStruct {
-			v, ok := c.queryFields[q.JsonName()]
-			if ok {
-				values[q.JsonName()] = v
+	var ves []*openapi.ValidationError
+	for _, obj := range c.queryStructs {
+		ves = append(ves, structQueryBind.Bind(c.queryFields, obj)...)
+		if len(ves) > 0 && stopImmediately {
+			break
+		}
+	}
+
+	for _, ve := range ves {
+		// validate 校验失败时, loc 为 ["query", 字段名], 对于请求头和cookie参数需修正其位置
+		if len(ve.Loc) != 2 {
+			continue
+		}
+		for _, q := range route.Swagger().QueryFields {
+			if q.InStruct && q.In() != openapi.InQuery && q.Name == ve.Loc[1] {
+				ve.Loc = []string{string(q.In()), q.JsonName()}
+				break
 			}
 		}
 	}
 
-	return structQueryBind.Bind(values, c.queryStruct)
+	return ves
 }
 
 // 请求体校验, 支持识别文件
@@ -335,13 +676,13 @@ func requestBodyValidate(c *Context, route RouteIface, stopImmediately bool) []*
 }
 
 // 返回值校验入口
-func responseValidate(c *Context, route RouteIface, disableResponseValidate, stopImmediately bool) []*openapi.ValidationError {
-	if disableResponseValidate {
+// 对于不包含校验规则的返回值, 其校验器在路由创建时已被替换为 NothingModelBinder
+func responseValidate(c *Context, route RouteIface, mode ResponseValidateMode, stopImmediately bool) []*openapi.ValidationError {
+	if mode == ResponseValidateOff {
 		return nil
 	}
 
 	if c.response.StatusCode == http.StatusOK || c.response.StatusCode == 0 {
-		// TODO: 此校验浪费性能, 尝试通过某种方式绕过
 		_, ves := route.ResponseBinder().Validate(c, c.response.Content)
 		if len(ves) > 0 {
 			ves[0].Ctx[modelDescLabel] = route.Swagger().ResponseModel.SchemaDesc()
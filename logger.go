@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"os"
 )
 
@@ -18,6 +19,9 @@ const (
 
 var console LoggerIface = nil
 
+// 框架内部的结构化日志, 随 ReplaceLogger 替换
+var structured *slog.Logger
+
 func init() {
 	ReplaceLogger(NewDefaultLogger())
 }
@@ -86,11 +90,14 @@ func NewDefaultLogger() *DefaultLogger {
 	return NewLogger(os.Stdout, "", log.LstdFlags|log.Lshortfile)
 }
 
+// ReplaceLogger 替换框架的日志,
+// 若 logger 实现了 StructuredLogger (如 SlogLogger), 框架内部及 Context.Logger 的结构化日志也将写入其 slog.Logger
 func ReplaceLogger(logger LoggerIface) {
 	if logger == nil {
 		return
 	}
 	console = logger
+	structured = slogFrom(logger)
 
 	Debug = console.Debug
 	Info = console.Info
@@ -101,6 +108,9 @@ func ReplaceLogger(logger LoggerIface) {
 	Debugf = console.Debugf
 }
 
+// Structured 框架内部的结构化日志, 随 ReplaceLogger 替换, 供路由器等扩展输出与框架一致的日志
+func Structured() *slog.Logger { return structured }
+
 var Info func(args ...any)
 var Debug func(args ...any)
 var Warn func(args ...any)
@@ -0,0 +1,124 @@
+package fastapi
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Chendemo12/fastapi/openapi"
+)
+
+// HeaderAllow 响应头, 列出请求路径所支持的请求方法
+const HeaderAllow = "Allow"
+
+// 自动响应的请求方法, 以及 Allow 响应头中请求方法的顺序
+var allowMethodOrder = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPatch,
+	http.MethodPut,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// 为每一个 GET 路由注册 HEAD 路由, 为每一个路径注册 OPTIONS 路由, 并接管路由器的404和405响应
+//
+//	对于服务端推送事件路由, 由于其响应不会自行结束, 因此不注册 HEAD 路由;
+//	如果路径已经定义了 OPTIONS 路由, 则不再自动注册
+func (f *Wrapper) initMethods() *Wrapper {
+	methods := map[string][]string{} // 路径 -> 请求方法
+	paths := make([]string, 0)       // 保持注册顺序
+	f.finder.Range(func(route RouteIface) bool {
+		swagger := route.Swagger()
+		method := swagger.Method
+		if swagger.IsWebSocket() {
+			method = http.MethodGet // 握手请求为 GET
+		}
+		if _, ok := methods[swagger.Url]; !ok {
+			paths = append(paths, swagger.Url)
+		}
+		methods[swagger.Url] = append(methods[swagger.Url], method)
+
+		if method == http.MethodGet && !swagger.IsWebSocket() && swagger.ResponseContentType != openapi.MIMETextEventStream {
+			if err := f.mux.BindRoute(http.MethodHead, swagger.Url, f.Handler); err != nil {
+				structured.Error("route bind failed", "method", http.MethodHead, "path", swagger.Url, "error", err)
+			}
+			methods[swagger.Url] = append(methods[swagger.Url], http.MethodHead)
+		}
+		return true
+	})
+
+	f.allowMethods = make(map[string]string, len(paths))
+	for _, path := range paths {
+		custom := false
+		for _, method := range methods[path] {
+			custom = custom || method == http.MethodOptions
+		}
+		if !custom {
+			if err := f.mux.BindRoute(http.MethodOptions, path, f.optionsHandler); err != nil {
+				structured.Error("route bind failed", "method", http.MethodOptions, "path", path, "error", err)
+			}
+			methods[path] = append(methods[path], http.MethodOptions)
+		}
+
+		allowed := make([]string, 0, len(methods[path]))
+		for _, method := range allowMethodOrder {
+			for _, m := range methods[path] {
+				if m == method {
+					allowed = append(allowed, method)
+					break
+				}
+			}
+		}
+		f.allowMethods[path] = strings.Join(allowed, ", ")
+	}
+
+	if mux, ok := f.mux.(FallbackMuxWrapper); ok {
+		if err := mux.BindNotFound(f.notFoundHandler); err != nil {
+			structured.Error("not found handler bind failed", "error", err)
+		}
+		if err := mux.BindMethodNotAllowed(f.methodNotAllowedHandler); err != nil {
+			structured.Error("method not allowed handler bind failed", "error", err)
+		}
+	}
+
+	return f
+}
+
+// 自动注册的 OPTIONS 路由, 通过 Allow 响应头返回路径所支持的请求方法; 启用跨域时同时响应预检请求
+func (f *Wrapper) optionsHandler(ctx MuxContext) error {
+	ctx.Header(HeaderAllow, f.allowMethods[ctx.Path()])
+	if f.cors != nil {
+		if isPreflight(ctx) {
+			f.cors.writePreflight(ctx, f.allowMethods[ctx.Path()])
+		} else {
+			f.cors.writeHeaders(ctx)
+		}
+	}
+	ctx.Status(http.StatusNoContent)
+	return nil
+}
+
+// 请求路径不存在时的处理函数
+func (f *Wrapper) notFoundHandler(ctx MuxContext) error {
+	return f.writeHTTPError(ctx, NewHTTPError(http.StatusNotFound))
+}
+
+// 请求方法不被允许时的处理函数, Allow 响应头由路由器设置
+func (f *Wrapper) methodNotAllowedHandler(ctx MuxContext) error {
+	return f.writeHTTPError(ctx, NewHTTPError(http.StatusMethodNotAllowed))
+}
+
+// 通过 RouteErrorFormatter 格式化错误并写入响应, 响应状态码默认为错误的状态码
+func (f *Wrapper) writeHTTPError(ctx MuxContext, err *HTTPError) error {
+	if f.cors != nil {
+		f.cors.writeHeaders(ctx)
+	}
+	c := f.acquireCtx(ctx, nil)
+	defer f.releaseCtx(c)
+
+	c.response.StatusCode = err.StatusCode
+	c.response.StatusCode, c.response.Content = f.routeErrorFormatter(c, err)
+
+	return f.write(c, nil, openapi.MIMEApplicationJSONCharsetUTF8)
+}
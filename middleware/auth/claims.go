@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Chendemo12/fastapi"
+)
+
+// ClaimsKey 认证通过后, Claims 在 Context 中的键
+const ClaimsKey = "auth.claims"
+
+// NumericDate JWT 中的时间, 以秒为单位的时间戳, 解析时允许为小数
+type NumericDate int64
+
+// NewNumericDate 以秒为单位截断时间
+func NewNumericDate(t time.Time) NumericDate { return NumericDate(t.Unix()) }
+
+func (d NumericDate) Time() time.Time { return time.Unix(int64(d), 0) }
+
+func (d *NumericDate) UnmarshalJSON(b []byte) error {
+	var f float64
+	if err := json.Unmarshal(b, &f); err != nil {
+		return errors.New("numeric date should be a number")
+	}
+	*d = NumericDate(math.Floor(f))
+	return nil
+}
+
+// Audience JWT 的受众, 可以是单个字符串或字符串数组
+type Audience []string
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = Audience{s}
+		return nil
+	}
+	var ss []string
+	if err := json.Unmarshal(b, &ss); err != nil {
+		return errors.New("audience should be a string or an array of strings")
+	}
+	*a = ss
+	return nil
+}
+
+// Contains 是否包含指定的受众
+func (a Audience) Contains(aud string) bool { return slices.Contains(a, aud) }
+
+// Claims JWT 的注册声明, 其他自定义声明可通过 Claims.Decode 读取
+type Claims struct {
+	Issuer    string      `json:"iss,omitempty" description:"签发者"`
+	Subject   string      `json:"sub,omitempty" description:"主题, 通常为用户标识"`
+	Audience  Audience    `json:"aud,omitempty" description:"受众"`
+	ExpiresAt NumericDate `json:"exp,omitempty" description:"过期时间"`
+	NotBefore NumericDate `json:"nbf,omitempty" description:"生效时间"`
+	IssuedAt  NumericDate `json:"iat,omitempty" description:"签发时间"`
+	ID        string      `json:"jti,omitempty" description:"唯一标识"`
+	Scope     string      `json:"scope,omitempty" description:"权限范围, 以空格分隔"`
+	raw       []byte      `description:"原始的载荷"`
+}
+
+// Decode 将完整的载荷解析到自定义的结构体中, 用于读取自定义声明
+func (c *Claims) Decode(v any) error {
+	return json.Unmarshal(c.raw, v)
+}
+
+// Scopes 以空格分隔的权限范围, 认证通过后作为 fastapi.Credentials.Scopes
+func (c *Claims) Scopes() []string { return strings.Fields(c.Scope) }
+
+// ClaimsFrom 读取认证通过后的 Claims, 未经 JWT 认证则返回 false
+func ClaimsFrom(c *fastapi.Context) (*Claims, bool) {
+	v, ok := c.Get(ClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := v.(*Claims)
+	return claims, ok
+}
@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Chendemo12/fastapi"
+	"github.com/Chendemo12/fastapi/openapi"
+)
+
+// Algorithm JWT 签名算法
+type Algorithm string
+
+const (
+	HS256 Algorithm = "HS256" // HMAC + SHA-256, 使用 Config.Secret
+	RS256 Algorithm = "RS256" // RSASSA-PKCS1-v1_5 + SHA-256, 使用 *rsa.PublicKey 和 *rsa.PrivateKey
+	ES256 Algorithm = "ES256" // ECDSA P-256 + SHA-256, 使用 *ecdsa.PublicKey 和 *ecdsa.PrivateKey
+)
+
+// DefaultSchemeName 认证方案的默认名称
+const DefaultSchemeName = "jwt"
+
+// Config JWT 认证的配置项
+type Config struct {
+	Name       string            `json:"name" description:"认证方案名称, 显示在文档中, 默认为 jwt"`
+	Algorithm  Algorithm         `json:"algorithm" description:"签名算法, 令牌头部的 alg 必须与之相同"`
+	Secret     []byte            `json:"-" description:"HS256 密钥"`
+	PublicKey  crypto.PublicKey  `json:"-" description:"RS256/ES256 公钥, 用于校验签名; 为空时由 PrivateKey 导出"`
+	PrivateKey crypto.PrivateKey `json:"-" description:"RS256/ES256 私钥, 仅用于 JWT.Sign 签发令牌"`
+	Issuer     string            `json:"issuer" description:"不为空时校验 iss"`
+	Audience   string            `json:"audience" description:"不为空时校验 aud 是否包含此受众"`
+	Leeway     time.Duration     `json:"leeway" description:"校验 exp 和 nbf 时允许的时钟偏差"`
+}
+
+// JWT 基于标准库实现的 JWT 认证方案, 实现了 fastapi.SecurityScheme, 在文档中显示为 bearer 认证;
+// 校验签名和 exp/nbf/iss/aud, 通过后可由 ClaimsFrom 读取 Claims
+//
+//	# Usage
+//
+//	jwt, err := auth.NewJWT(auth.Config{Algorithm: auth.HS256, Secret: []byte("secret")})
+//	app.UseSecurity(jwt) // 或在路由组的 Security 方法中返回
+type JWT struct {
+	conf   Config
+	bearer fastapi.SecurityScheme
+	doc    *openapi.SecurityScheme
+	now    func() time.Time
+}
+
+// NewJWT 创建 JWT 认证方案, 算法与密钥不匹配时返回错误
+func NewJWT(conf Config) (*JWT, error) {
+	if conf.Name == "" {
+		conf.Name = DefaultSchemeName
+	}
+
+	switch conf.Algorithm {
+	case HS256:
+		if len(conf.Secret) == 0 {
+			return nil, errors.New("auth: HS256 requires a secret")
+		}
+	case RS256:
+		if conf.PublicKey == nil {
+			if key, ok := conf.PrivateKey.(*rsa.PrivateKey); ok {
+				conf.PublicKey = &key.PublicKey
+			}
+		}
+		if _, ok := conf.PublicKey.(*rsa.PublicKey); !ok {
+			return nil, errors.New("auth: RS256 requires a *rsa.PublicKey")
+		}
+	case ES256:
+		if conf.PublicKey == nil {
+			if key, ok := conf.PrivateKey.(*ecdsa.PrivateKey); ok {
+				conf.PublicKey = &key.PublicKey
+			}
+		}
+		if key, ok := conf.PublicKey.(*ecdsa.PublicKey); !ok || key.Curve != elliptic.P256() {
+			return nil, errors.New("auth: ES256 requires a P-256 *ecdsa.PublicKey")
+		}
+	default:
+		return nil, fmt.Errorf("auth: unsupported algorithm '%s'", conf.Algorithm)
+	}
+
+	return &JWT{
+		conf:   conf,
+		bearer: fastapi.HTTPBearer(conf.Name),
+		doc: &openapi.SecurityScheme{
+			Type:         openapi.SecurityTypeHTTP,
+			Scheme:       "bearer",
+			BearerFormat: "JWT",
+		},
+		now: time.Now,
+	}, nil
+}
+
+func (j *JWT) SecurityName() string { return j.conf.Name }
+
+func (j *JWT) SecurityDoc() *openapi.SecurityScheme { return j.doc }
+
+// Credentials 从请求头中提取并校验令牌, 通过后将 Claims 存储在 Context 中, 并以 scope 声明作为凭证的权限范围
+func (j *JWT) Credentials(c *fastapi.Context) (*fastapi.Credentials, error) {
+	credentials, err := j.bearer.Credentials(c)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := j.Parse(credentials.Token)
+	if err != nil {
+		return nil, fastapi.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	c.Set(ClaimsKey, claims)
+	credentials.Scopes = claims.Scopes()
+
+	return credentials, nil
+}
+
+// Dependence 依赖函数形式的 JWT 认证, 等同于 fastapi.Security(j)
+func (j *JWT) Dependence() fastapi.DependenceHandle { return fastapi.Security(j) }
+
+type header struct {
+	Alg Algorithm `json:"alg"`
+	Typ string    `json:"typ,omitempty"`
+}
+
+var encoding = base64.RawURLEncoding
+
+// Parse 校验令牌的签名和 exp/nbf/iss/aud, 并解析 Claims
+func (j *JWT) Parse(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	bs, err := encoding.DecodeString(parts[0])
+	h := &header{}
+	if err != nil || json.Unmarshal(bs, h) != nil {
+		return nil, errors.New("malformed token header")
+	}
+	if h.Alg != j.conf.Algorithm { // 禁止算法混淆, 包括 none
+		return nil, fmt.Errorf("unexpected signing algorithm '%s'", h.Alg)
+	}
+
+	signature, err := encoding.DecodeString(parts[2])
+	if err != nil || !j.verify(parts[0]+"."+parts[1], signature) {
+		return nil, errors.New("invalid token signature")
+	}
+
+	claims := &Claims{}
+	claims.raw, err = encoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("malformed token payload")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(claims.raw))
+	if err = decoder.Decode(claims); err != nil {
+		return nil, fmt.Errorf("malformed token payload: %s", err)
+	}
+
+	return claims, j.validate(claims)
+}
+
+// 校验注册声明
+func (j *JWT) validate(claims *Claims) error {
+	now := j.now()
+	if claims.ExpiresAt != 0 && now.After(claims.ExpiresAt.Time().Add(j.conf.Leeway)) {
+		return errors.New("token has expired")
+	}
+	if claims.NotBefore != 0 && now.Add(j.conf.Leeway).Before(claims.NotBefore.Time()) {
+		return errors.New("token is not valid yet")
+	}
+	if j.conf.Issuer != "" && claims.Issuer != j.conf.Issuer {
+		return errors.New("invalid token issuer")
+	}
+	if j.conf.Audience != "" && !claims.Audience.Contains(j.conf.Audience) {
+		return errors.New("invalid token audience")
+	}
+	return nil
+}
+
+func (j *JWT) verify(signingInput string, signature []byte) bool {
+	digest := sha256.Sum256([]byte(signingInput))
+	switch j.conf.Algorithm {
+	case HS256:
+		mac := hmac.New(sha256.New, j.conf.Secret)
+		mac.Write([]byte(signingInput))
+		return hmac.Equal(signature, mac.Sum(nil))
+	case RS256:
+		return rsa.VerifyPKCS1v15(j.conf.PublicKey.(*rsa.PublicKey), crypto.SHA256, digest[:], signature) == nil
+	case ES256:
+		if len(signature) != 64 { // r 和 s 各32字节
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(j.conf.PublicKey.(*ecdsa.PublicKey), digest[:], r, s)
+	}
+	return false
+}
+
+// Sign 签发令牌, claims 可以是 *Claims 或包含自定义声明的结构体;
+// RS256/ES256 需配置 Config.PrivateKey
+func (j *JWT) Sign(claims any) (string, error) {
+	h, err := json.Marshal(&header{Alg: j.conf.Algorithm, Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := encoding.EncodeToString(h) + "." + encoding.EncodeToString(payload)
+
+	var signature []byte
+	digest := sha256.Sum256([]byte(signingInput))
+	switch j.conf.Algorithm {
+	case HS256:
+		mac := hmac.New(sha256.New, j.conf.Secret)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case RS256:
+		key, ok := j.conf.PrivateKey.(*rsa.PrivateKey)
+		if !ok {
+			return "", errors.New("auth: RS256 requires a *rsa.PrivateKey to sign")
+		}
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	case ES256:
+		key, ok := j.conf.PrivateKey.(*ecdsa.PrivateKey)
+		if !ok {
+			return "", errors.New("auth: ES256 requires a *ecdsa.PrivateKey to sign")
+		}
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key, digest[:])
+		if err == nil {
+			signature = make([]byte, 64)
+			r.FillBytes(signature[:32])
+			s.FillBytes(signature[32:])
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + encoding.EncodeToString(signature), nil
+}
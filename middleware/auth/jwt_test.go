@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Chendemo12/fastapi"
+	"github.com/Chendemo12/fastapi/fastapitest"
+)
+
+func newTestJWTs(t *testing.T) map[Algorithm]*JWT {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwts := make(map[Algorithm]*JWT)
+	for _, conf := range []Config{
+		{Algorithm: HS256, Secret: []byte("secret")},
+		{Algorithm: RS256, PrivateKey: rsaKey},
+		{Algorithm: ES256, PrivateKey: ecKey},
+	} {
+		conf.Issuer = "fastapi"
+		conf.Audience = "api"
+		j, err := NewJWT(conf)
+		if err != nil {
+			t.Fatalf("NewJWT(%s) error = %v", conf.Algorithm, err)
+		}
+		jwts[conf.Algorithm] = j
+	}
+	return jwts
+}
+
+type customClaims struct {
+	Claims
+	Roles []string `json:"roles"`
+}
+
+func TestJWT_SignParse(t *testing.T) {
+	now := time.Now()
+	for alg, j := range newTestJWTs(t) {
+		t.Run(string(alg), func(t *testing.T) {
+			token, err := j.Sign(&customClaims{
+				Claims: Claims{Issuer: "fastapi", Subject: "lee", Audience: Audience{"api"}, ExpiresAt: NewNumericDate(now.Add(time.Minute))},
+				Roles:  []string{"admin"},
+			})
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+
+			claims, err := j.Parse(token)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if claims.Subject != "lee" || !claims.Audience.Contains("api") {
+				t.Errorf("Parse() = %+v", claims)
+			}
+			custom := &customClaims{}
+			if err = claims.Decode(custom); err != nil || len(custom.Roles) != 1 || custom.Roles[0] != "admin" {
+				t.Errorf("Decode() = %+v, %v", custom, err)
+			}
+
+			parts := strings.Split(token, ".")
+			tampered := parts[0] + "." + encoding.EncodeToString([]byte(`{"sub":"root","iss":"fastapi","aud":"api"}`)) + "." + parts[2]
+			if _, err = j.Parse(tampered); err == nil {
+				t.Error("Parse() accepted a tampered payload")
+			}
+		})
+	}
+}
+
+func TestJWT_Parse_Invalid(t *testing.T) {
+	jwts := newTestJWTs(t)
+	hs := jwts[HS256]
+	now := time.Now()
+
+	sign := func(j *JWT, claims *Claims) string {
+		token, err := j.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	valid := func() *Claims {
+		return &Claims{Issuer: "fastapi", Audience: Audience{"web", "api"}, ExpiresAt: NewNumericDate(now.Add(time.Minute))}
+	}
+	none := encoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + encoding.EncodeToString([]byte(`{"iss":"fastapi","aud":"api"}`)) + "."
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{name: "malformed", token: "abc.def", wantErr: "malformed token"},
+		{name: "none", token: none, wantErr: "unexpected signing algorithm 'none'"},
+		{name: "alg-mismatch", token: sign(jwts[RS256], valid()), wantErr: "unexpected signing algorithm 'RS256'"},
+		{name: "other-secret", token: sign(&JWT{conf: Config{Algorithm: HS256, Secret: []byte("other")}}, valid()), wantErr: "invalid token signature"},
+		{name: "expired", token: sign(hs, &Claims{Issuer: "fastapi", Audience: Audience{"api"}, ExpiresAt: NewNumericDate(now.Add(-time.Minute))}), wantErr: "token has expired"},
+		{name: "not-before", token: sign(hs, &Claims{Issuer: "fastapi", Audience: Audience{"api"}, NotBefore: NewNumericDate(now.Add(time.Minute))}), wantErr: "token is not valid yet"},
+		{name: "issuer", token: sign(hs, &Claims{Issuer: "other", Audience: Audience{"api"}}), wantErr: "invalid token issuer"},
+		{name: "audience", token: sign(hs, &Claims{Issuer: "fastapi", Audience: Audience{"web"}}), wantErr: "invalid token audience"},
+		{name: "valid", token: sign(hs, valid())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := hs.Parse(tt.token)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Parse() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Parse() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWT_Leeway(t *testing.T) {
+	j, err := NewJWT(Config{Algorithm: HS256, Secret: []byte("secret"), Leeway: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _ := j.Sign(&Claims{ExpiresAt: NewNumericDate(time.Now().Add(-30 * time.Second))})
+	if _, err = j.Parse(token); err != nil {
+		t.Errorf("Parse() error = %v, want accepted within leeway", err)
+	}
+}
+
+func TestNewJWT_Invalid(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+
+	for name, conf := range map[string]Config{
+		"unknown":   {Algorithm: "HS512", Secret: []byte("secret")},
+		"no-secret": {Algorithm: HS256},
+		"rsa-ec":    {Algorithm: RS256, PrivateKey: ecKey},
+		"ec-rsa":    {Algorithm: ES256, PublicKey: &rsaKey.PublicKey},
+		"ec-p384":   {Algorithm: ES256, PrivateKey: ecKey},
+	} {
+		if _, err := NewJWT(conf); err == nil {
+			t.Errorf("NewJWT(%s) error = nil", name)
+		}
+	}
+}
+
+var testJWT, _ = NewJWT(Config{Algorithm: HS256, Secret: []byte("secret")})
+
+type MeRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *MeRouter) Prefix() string { return "/api/me" }
+
+func (r *MeRouter) Security() []fastapi.SecurityScheme {
+	return []fastapi.SecurityScheme{testJWT}
+}
+
+func (r *MeRouter) GetSubject(c *fastapi.Context) (string, error) {
+	claims, ok := ClaimsFrom(c)
+	if !ok {
+		return "", fastapi.NewHTTPError(http.StatusInternalServerError, "claims not found")
+	}
+	return claims.Subject, nil
+}
+
+func (r *MeRouter) RouteScopes() map[string][]string {
+	return map[string][]string{"GetAdmin": {"admin"}}
+}
+
+func (r *MeRouter) GetAdmin(c *fastapi.Context) (string, error) { return "admin", nil }
+
+func TestJWT_Security(t *testing.T) {
+	app := fastapi.New(fastapi.Config{Title: "auth"})
+	app.IncludeRouter(&MeRouter{})
+	client := fastapitest.NewClient(app)
+
+	token, _ := testJWT.Sign(&Claims{Subject: "lee", ExpiresAt: NewNumericDate(time.Now().Add(time.Minute))})
+	expired, _ := testJWT.Sign(&Claims{Subject: "lee", ExpiresAt: NewNumericDate(time.Now().Add(-time.Minute))})
+
+	tests := []struct {
+		name       string
+		opts       []fastapitest.RequestOption
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "missing", wantStatus: http.StatusUnauthorized, wantBody: "Not authenticated"},
+		{name: "expired", opts: []fastapitest.RequestOption{fastapitest.WithHeader("Authorization", "Bearer "+expired)}, wantStatus: http.StatusUnauthorized, wantBody: "token has expired"},
+		{name: "valid", opts: []fastapitest.RequestOption{fastapitest.WithHeader("Authorization", "Bearer "+token)}, wantStatus: http.StatusOK, wantBody: "lee"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := client.Get("/api/me/subject", tt.opts...)
+			if resp.StatusCode != tt.wantStatus || !strings.Contains(resp.String(), tt.wantBody) {
+				t.Errorf("GET = %d %s, want %d %s", resp.StatusCode, resp.String(), tt.wantStatus, tt.wantBody)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") != "Bearer" {
+				t.Errorf("WWW-Authenticate = %q", resp.Header.Get("WWW-Authenticate"))
+			}
+		})
+	}
+
+	t.Run("scope", func(t *testing.T) {
+		admin, _ := testJWT.Sign(&Claims{Subject: "lee", Scope: "read admin"})
+		if resp := client.Get("/api/me/admin", fastapitest.WithHeader("Authorization", "Bearer "+token)); resp.StatusCode != http.StatusForbidden {
+			t.Errorf("GET without scope = %d %s", resp.StatusCode, resp.String())
+		}
+		if resp := client.Get("/api/me/admin", fastapitest.WithHeader("Authorization", "Bearer "+admin)); resp.StatusCode != http.StatusOK {
+			t.Errorf("GET with scope = %d %s", resp.StatusCode, resp.String())
+		}
+	})
+
+	doc := client.Get("/openapi.json").String()
+	if !strings.Contains(doc, `"bearerFormat":"JWT"`) || !strings.Contains(doc, `"security":[{"jwt":[]}]`) {
+		t.Errorf("openapi.json missing jwt security: %s", doc)
+	}
+}
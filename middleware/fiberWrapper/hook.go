@@ -7,6 +7,9 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// DefaultCORS 允许任意来源的跨域中间件, 仅适用于 fiber
+//
+// Deprecated: 使用 fastapi.Wrapper.UseCORS 代替, 其适用于全部 MuxWrapper, 且预检请求的请求方法来自已注册的路由
 func DefaultCORS(c *fiber.Ctx) error {
 	c.Set("Access-Control-Allow-Origin", "*")
 	c.Set("Access-Control-Allow-Headers", "*")
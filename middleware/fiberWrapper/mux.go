@@ -1,11 +1,14 @@
 package fiberWrapper
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/url"
 	"runtime"
 	"strings"
 	"sync"
@@ -14,7 +17,9 @@ import (
 	"github.com/Chendemo12/fastapi"
 	"github.com/Chendemo12/fastapi/openapi"
 	"github.com/Chendemo12/fastapi/utils"
+	"github.com/Chendemo12/fastapi/websocket"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	echo "github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/pelletier/go-toml/v2"
@@ -36,7 +41,10 @@ func ReleaseCtx(c *FiberContext) {
 }
 
 type FiberMux struct {
-	app *fiber.App
+	app          *fiber.App
+	notFound     fastapi.MuxHandler
+	notAllowed   fastapi.MuxHandler
+	fallbackOnce sync.Once
 }
 
 // NewWrapper 创建App实例
@@ -129,6 +137,20 @@ func (m *FiberMux) BindRoute(method, path string, handler fastapi.MuxHandler) er
 			mCtx := AcquireCtx(ctx)
 			defer ReleaseCtx(mCtx)
 
+			return handler(mCtx)
+		})
+	case http.MethodHead:
+		m.app.Head(path, func(ctx *fiber.Ctx) error {
+			mCtx := AcquireCtx(ctx)
+			defer ReleaseCtx(mCtx)
+
+			return handler(mCtx)
+		})
+	case http.MethodOptions:
+		m.app.Options(path, func(ctx *fiber.Ctx) error {
+			mCtx := AcquireCtx(ctx)
+			defer ReleaseCtx(mCtx)
+
 			return handler(mCtx)
 		})
 	default:
@@ -138,6 +160,95 @@ func (m *FiberMux) BindRoute(method, path string, handler fastapi.MuxHandler) er
 	return nil
 }
 
+// BindNotFound 注册请求路径不存在时的处理函数, 实现 fastapi.FallbackMuxWrapper
+func (m *FiberMux) BindNotFound(handler fastapi.MuxHandler) error {
+	m.notFound = handler
+	m.fallbackOnce.Do(m.useFallback)
+	return nil
+}
+
+// BindMethodNotAllowed 注册请求方法不被允许时的处理函数, 实现 fastapi.FallbackMuxWrapper
+func (m *FiberMux) BindMethodNotAllowed(handler fastapi.MuxHandler) error {
+	m.notAllowed = handler
+	m.fallbackOnce.Do(m.useFallback)
+	return nil
+}
+
+// 由于 fiber 未提供相应的钩子, 因此通过一个位于全部路由之后的中间件实现, 此中间件仅处理未被其之前的路由匹配的请求;
+// 对于其之后注册的路由(例如文档路由), 若请求路径和方法均匹配则交由其处理
+func (m *FiberMux) useFallback() {
+	conf := m.app.Config()
+
+	var once sync.Once
+	var routes []*fallbackRoute
+
+	m.app.Use(func(ctx *fiber.Ctx) error {
+		// 文档路由在此中间件之后注册, 因此在首个请求到来时再汇总路由表
+		once.Do(func() { routes = groupRoutes(m.app.GetRoutes(true)) })
+
+		allowed := make([]string, 0)
+		for _, route := range routes {
+			if !fiber.RoutePatternMatch(ctx.Path(), route.path, conf) {
+				continue
+			}
+			if utils.Has(route.methods, ctx.Method()) {
+				return ctx.Next()
+			}
+			for _, method := range route.methods {
+				if !utils.Has(allowed, method) {
+					allowed = append(allowed, method)
+				}
+			}
+		}
+
+		handler := m.notFound
+		if len(allowed) > 0 {
+			handler = m.notAllowed
+		}
+		if handler == nil {
+			return ctx.Next()
+		}
+
+		mCtx := AcquireCtx(ctx)
+		defer ReleaseCtx(mCtx)
+
+		if len(allowed) > 0 {
+			mCtx.Header(fastapi.HeaderAllow, strings.Join(allowed, ", "))
+		}
+		return handler(mCtx)
+	})
+}
+
+// fallbackRoute 同一路径下的全部请求方法
+type fallbackRoute struct {
+	path    string
+	methods []string
+}
+
+// groupRoutes 将路由按路径分组, 保持注册顺序
+func groupRoutes(routes []fiber.Route) []*fallbackRoute {
+	groups := make([]*fallbackRoute, 0)
+	index := make(map[string]*fallbackRoute)
+	for _, route := range routes {
+		group, ok := index[route.Path]
+		if !ok {
+			group = &fallbackRoute{path: route.Path}
+			index[route.Path] = group
+			groups = append(groups, group)
+		}
+		if !utils.Has(group.methods, route.Method) {
+			group.methods = append(group.methods, route.Method)
+		}
+	}
+
+	return groups
+}
+
+// BindWebSocket 注册 websocket 路由, 实现 fastapi.WebSocketMuxWrapper
+func (m *FiberMux) BindWebSocket(path string, handler fastapi.MuxHandler) error {
+	return m.BindRoute(http.MethodGet, path, handler)
+}
+
 type FiberContext struct {
 	ctx *fiber.Ctx
 }
@@ -164,6 +275,16 @@ func (c *FiberContext) Query(key string, undefined ...string) string {
 	return c.ctx.Query(key, undefined...)
 }
 
+// QueryArray 实现 fastapi.QueryArrayReader
+func (c *FiberContext) QueryArray(key string) []string {
+	values := c.ctx.Context().QueryArgs().PeekMulti(key)
+	result := make([]string, len(values))
+	for i, v := range values {
+		result[i] = string(v) // fasthttp 的字节切片会被复用, 需复制
+	}
+	return result
+}
+
 func (c *FiberContext) Params(key string, undefined ...string) string {
 	return c.ctx.Params(key, undefined...)
 }
@@ -174,7 +295,8 @@ func (c *FiberContext) MultipartForm() (*multipart.Form, error) {
 
 // GetHeader 获取请求头, 当key不存在时返回空字符串，如果存在多个时，返回逗号分隔的字符串
 func (c *FiberContext) GetHeader(key string) string {
-	headers, ok := c.ctx.GetReqHeaders()[key]
+	// fasthttp 会将请求头名称规范化, 如 Sec-WebSocket-Key => Sec-Websocket-Key
+	headers, ok := c.ctx.GetReqHeaders()[http.CanonicalHeaderKey(key)]
 	if !ok {
 		return ""
 	}
@@ -226,6 +348,71 @@ func (c *FiberContext) SendStream(stream io.Reader, size ...int) error {
 	return c.ctx.SendStream(stream, size...)
 }
 
+// ResponseSize 实现 fastapi.ResponseSizer, 流式响应的字节数无法统计, 此时返回-1
+func (c *FiberContext) ResponseSize() int64 {
+	resp := c.ctx.Response()
+	if resp.IsBodyStream() { // 读取 Body 会消费响应流
+		return -1
+	}
+	return int64(len(resp.Body()))
+}
+
+// SendStreamWriter 实现 fastapi.StreamWriter
+//
+//	fasthttp 会在路由函数返回之后, 才在新的协程中执行 fn, 此时 FiberContext 已被回收;
+//	fasthttp 不会通知客户端断开连接, 因此 closed 在写入或刷新失败、以及服务关闭(RequestCtx.Done)时关闭
+func (c *FiberContext) SendStreamWriter(fn func(w io.Writer, flush func() error, closed <-chan struct{}) error) error {
+	rc := c.ctx.Context()
+	rc.SetBodyStreamWriter(func(w *bufio.Writer) {
+		sw := newStreamWriter(w, rc.Done())
+		defer sw.stop()
+		_ = fn(sw, sw.Flush, sw.closed)
+	})
+	return nil
+}
+
+// 流式响应的写入器, 写入或刷新失败时视为客户端已断开
+type streamWriter struct {
+	w      *bufio.Writer
+	closed chan struct{}
+	once   sync.Once
+	exit   chan struct{}
+}
+
+func newStreamWriter(w *bufio.Writer, done <-chan struct{}) *streamWriter {
+	sw := &streamWriter{w: w, closed: make(chan struct{}), exit: make(chan struct{})}
+	if done != nil {
+		go func() {
+			select {
+			case <-done:
+				sw.close()
+			case <-sw.exit:
+			}
+		}()
+	}
+	return sw
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	if err != nil {
+		s.close()
+	}
+	return n, err
+}
+
+func (s *streamWriter) Flush() error {
+	err := s.w.Flush()
+	if err != nil {
+		s.close()
+	}
+	return err
+}
+
+func (s *streamWriter) close() { s.once.Do(func() { close(s.closed) }) }
+
+func (s *streamWriter) stop() { close(s.exit) }
+
 // RenderHTML 返回HTML模板
 func (c *FiberContext) RenderHTML(name string, bind interface{}, layouts ...string) error {
 	return c.ctx.Render(name, bind, layouts...)
@@ -280,6 +467,47 @@ func (c *FiberContext) SendString(s string) error {
 	return c.ctx.SendString(s)
 }
 
+// ResponseHeader 实现 fastapi.ResponseHeaderReader
+func (c *FiberContext) ResponseHeader(key string) string {
+	var values []string
+	c.ctx.Response().Header.VisitAll(func(k, v []byte) {
+		if strings.EqualFold(string(k), key) {
+			values = append(values, string(v))
+		}
+	})
+	return strings.Join(values, ", ")
+}
+
+// HandshakeRequest 实现 fastapi.WebSocketUpgrader, 返回由 fasthttp 请求转换而来的 *http.Request
+func (c *FiberContext) HandshakeRequest() *http.Request {
+	req, err := adaptor.ConvertRequest(c.ctx, true)
+	if err != nil { // 请求已被 fasthttp 解析, 仅在 RequestURI 非法时出错
+		return &http.Request{Method: c.ctx.Method(), Header: http.Header{}, URL: &url.URL{}}
+	}
+	return req
+}
+
+// UpgradeWebSocket 实现 fastapi.WebSocketUpgrader
+//
+//	fasthttp 会在路由函数返回并写入101响应之后, 才在新的协程中执行 handler, 此时 FiberContext 已被回收,
+//	且该协程不会恢复 handler 中的panic
+func (c *FiberContext) UpgradeWebSocket(handler func(conn *fastapi.WebSocketConn)) error {
+	accept, err := websocket.CheckHandshake(c)
+	if err != nil {
+		return err
+	}
+
+	c.ctx.Status(fiber.StatusSwitchingProtocols)
+	c.ctx.Set(fiber.HeaderUpgrade, "websocket")
+	c.ctx.Set(fiber.HeaderConnection, "Upgrade")
+	c.ctx.Set("Sec-WebSocket-Accept", accept)
+	c.ctx.Context().Hijack(func(conn net.Conn) {
+		handler(websocket.NewConn(conn, bufio.NewReader(conn)))
+	})
+
+	return nil
+}
+
 func (c *FiberContext) Write(p []byte) (int, error) {
 	return c.ctx.Write(p)
 }
@@ -292,12 +520,12 @@ func (c *FiberContext) JSON(statusCode int, data any) error {
 func customRecoverHandler(c *fiber.Ctx, e any) {
 	buf := make([]byte, 1024)
 	buf = buf[:runtime.Stack(buf, true)]
-	fastapi.Errorf("%s %s failed, Error: %s", c.Method(), c.Path(), string(buf))
+	fastapi.Structured().Error("panic recovered", "method", c.Method(), "path", c.Path(), "error", e, "stack", string(buf))
 }
 
 // customFiberErrorHandler 自定义fiber接口错误处理函数
 func customFiberErrorHandler(c *fiber.Ctx, e error) error {
-	fastapi.Warnf("%s %s, Error: %s", c.Method(), c.Path(), e.Error())
+	fastapi.Structured().Warn("fiber handler failed", "method", c.Method(), "path", c.Path(), "error", e)
 	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 		"code": fiber.StatusBadRequest,
 		"msg":  e.Error()},
@@ -0,0 +1,315 @@
+package fiberWrapper
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Chendemo12/fastapi"
+	"github.com/Chendemo12/fastapi/fastapitest"
+	"github.com/Chendemo12/fastapi/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Item struct {
+	Name string `json:"name" description:"名称"`
+}
+
+type ItemRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *ItemRouter) Prefix() string { return "/api/item" }
+
+func (r *ItemRouter) GetInfo(c *fastapi.Context) (*Item, error) {
+	c.MuxContext().Header("X-Total", "1")
+	return &Item{Name: "fiber"}, nil
+}
+
+func (r *ItemRouter) PostInfo(c *fastapi.Context, item *Item) (*Item, error) {
+	return item, nil
+}
+
+func newTestApp(t *testing.T) *fiber.App {
+	mux := NewWrapper(fiber.New(fiber.Config{CaseSensitive: true, StrictRouting: true}))
+	app := fastapi.New(fastapi.Config{Title: "fiber"})
+	app.IncludeRouter(&ItemRouter{}).SetMux(mux).Init()
+	return mux.App()
+}
+
+func request(t *testing.T, app *fiber.App, method, url string) (*http.Response, string) {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(method, url, nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func TestFiberMux_Methods(t *testing.T) {
+	app := newTestApp(t)
+
+	t.Run("get", func(t *testing.T) {
+		resp, body := request(t, app, http.MethodGet, "/api/item/info")
+		if resp.StatusCode != http.StatusOK || body != `{"name":"fiber"}` {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("head", func(t *testing.T) {
+		resp, body := request(t, app, http.MethodHead, "/api/item/info")
+		if resp.StatusCode != http.StatusOK || body != "" || resp.Header.Get("X-Total") != "1" {
+			t.Errorf("status = %d, body = %s, header = %v", resp.StatusCode, body, resp.Header)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		resp, _ := request(t, app, http.MethodOptions, "/api/item/info")
+		if resp.StatusCode != http.StatusNoContent || resp.Header.Get(fastapi.HeaderAllow) != "GET, HEAD, POST, OPTIONS" {
+			t.Errorf("status = %d, Allow = %s", resp.StatusCode, resp.Header.Get(fastapi.HeaderAllow))
+		}
+	})
+
+	t.Run("method-not-allowed", func(t *testing.T) {
+		resp, body := request(t, app, http.MethodPut, "/api/item/info")
+		if resp.StatusCode != http.StatusMethodNotAllowed || body != `{"detail":"Method Not Allowed"}` {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, body)
+		}
+		if allow := resp.Header.Get(fastapi.HeaderAllow); allow != "GET, HEAD, POST, OPTIONS" {
+			t.Errorf("Allow = %s", allow)
+		}
+	})
+}
+
+func TestFiberMux_NotFound(t *testing.T) {
+	app := newTestApp(t)
+
+	resp, body := request(t, app, http.MethodGet, "/api/unknown")
+	if resp.StatusCode != http.StatusNotFound || body != `{"detail":"Not Found"}` {
+		t.Errorf("status = %d, body = %s", resp.StatusCode, body)
+	}
+
+	// 文档路由注册于 fallback 中间件之后
+	resp, _ = request(t, app, http.MethodGet, "/openapi.json")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d", resp.StatusCode)
+	}
+	resp, _ = request(t, app, http.MethodPost, "/openapi.json")
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d", resp.StatusCode)
+	}
+}
+
+func TestFiberMux_WebSocket(t *testing.T) {
+	fastapitest.RunWebSocketConformance(t, func(app *fastapi.Wrapper) string {
+		mux := NewWrapper(fiber.New(fiber.Config{DisableStartupMessage: true}))
+		app.SetMux(mux).Init()
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() { _ = mux.App().Listener(ln) }()
+		t.Cleanup(func() { _ = mux.App().Shutdown() })
+		return ln.Addr().String()
+	})
+}
+
+type EventRouter struct {
+	fastapi.BaseGroupRouter
+	stopped chan struct{}
+}
+
+func (r *EventRouter) Prefix() string { return "/api/event" }
+
+// GetTicks 间隔发送3个事件
+func (r *EventRouter) GetTicks(c *fastapi.Context) (*fastapi.SSEResponse[int], error) {
+	ch := make(chan fastapi.SSEEvent[int])
+	go func() {
+		defer close(ch)
+		for i := 1; i <= 3; i++ {
+			time.Sleep(20 * time.Millisecond)
+			ch <- fastapi.SSEEvent[int]{Data: i}
+		}
+	}()
+	return fastapi.SSE(ch), nil
+}
+
+// GetForever 只发送心跳, 直到客户端断开
+func (r *EventRouter) GetForever(c *fastapi.Context) (*fastapi.SSEResponse[string], error) {
+	ch := make(chan fastapi.SSEEvent[string])
+	done := c.Done()
+	go func() {
+		ch <- fastapi.SSEEvent[string]{Data: "start"}
+		<-done
+		r.stopped <- struct{}{}
+	}()
+	return fastapi.SSE(ch).Heartbeat(5 * time.Millisecond), nil
+}
+
+// 在响应结束后读取请求信息的观察者
+type streamObserver struct {
+	responses chan string
+}
+
+func (o *streamObserver) OnRequest(c *fastapi.Context, route fastapi.RouteIface) {}
+
+func (o *streamObserver) OnResponse(c *fastapi.Context, route fastapi.RouteIface) {
+	o.responses <- c.MuxContext().Method() + " " + c.MuxContext().Path() + " " + c.RouteId()
+	if c.MuxContext().Path() == "/api/event/forever" {
+		panic("observer panic")
+	}
+}
+
+// 记录 Info 日志的 logger
+type recordLogger struct {
+	fastapi.LoggerIface
+	lines chan string
+}
+
+func (l *recordLogger) Info(args ...any) { l.lines <- fmt.Sprint(args...) }
+
+func TestFiberMux_SSE(t *testing.T) {
+	router := &EventRouter{stopped: make(chan struct{}, 1)}
+	logger := &recordLogger{LoggerIface: fastapi.NewDefaultLogger(), lines: make(chan string, 8)}
+	mux := NewWrapper(fiber.New(fiber.Config{DisableStartupMessage: true}))
+	app := fastapi.New(fastapi.Config{Title: "fiber"})
+	observer := &streamObserver{responses: make(chan string, 8)}
+	panics := make(chan any, 1)
+	app.IncludeRouter(router).UseAccessLog(fastapi.AccessLogConfig{Format: fastapi.AccessLogJSON, Logger: logger})
+	app.UseObserver(observer).OnPanic(func(c *fastapi.Context, v any, stack []byte) { panics <- v })
+	app.SetMux(mux).Init()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = mux.App().Listener(ln) }()
+	t.Cleanup(func() { _ = mux.App().Shutdown() })
+	base := "http://" + ln.Addr().String()
+
+	t.Run("access-log-after-stream", func(t *testing.T) {
+		resp, err := http.Get(base + "/api/event/ticks")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+
+		entry := &fastapi.AccessLog{}
+		select {
+		case line := <-logger.lines:
+			_ = json.Unmarshal([]byte(line), entry)
+		case <-time.After(time.Second):
+			t.Fatal("access log was not written")
+		}
+		if entry.Latency < 60 || entry.ResponseSize != int64(len(body)) || entry.ClientIP != "127.0.0.1" {
+			t.Errorf("log = %+v, body = %q", entry, body)
+		}
+
+		// 观察者在 FiberContext 被回收之后执行
+		want := "GET /api/event/ticks " + openapi.CreateRouteIdentify(http.MethodGet, "/api/event/ticks")
+		select {
+		case got := <-observer.responses:
+			if got != want {
+				t.Errorf("observer = %q, want %q", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("observer was not notified")
+		}
+	})
+
+	t.Run("client-disconnect", func(t *testing.T) {
+		resp, err := http.Get(base + "/api/event/forever")
+		if err != nil {
+			t.Fatal(err)
+		}
+		line, _ := bufio.NewReader(resp.Body).ReadString('\n')
+		if line != "data: start\n" {
+			t.Fatalf("line = %q", line)
+		}
+		_ = resp.Body.Close()
+
+		// 心跳写入失败后结束响应流并取消路由 context
+		select {
+		case <-router.stopped:
+		case <-time.After(2 * time.Second):
+			t.Fatal("stream should stop after the client disconnected")
+		}
+		<-logger.lines
+
+		// 观察者的 panic 被恢复并执行 OnPanic 钩子
+		<-observer.responses
+		select {
+		case v := <-panics:
+			if v != "observer panic" {
+				t.Errorf("panic = %v", v)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("OnPanic was not called")
+		}
+	})
+}
+
+func TestStreamWriter(t *testing.T) {
+	t.Run("flush-failed", func(t *testing.T) {
+		sw := newStreamWriter(bufio.NewWriter(failedWriter{}), nil)
+		defer sw.stop()
+		_, _ = sw.Write([]byte("a"))
+		if err := sw.Flush(); err == nil {
+			t.Fatal("Flush() should return error")
+		}
+		select {
+		case <-sw.closed:
+		default:
+			t.Error("closed should be closed after flush failed")
+		}
+	})
+
+	t.Run("server-done", func(t *testing.T) {
+		done := make(chan struct{})
+		sw := newStreamWriter(bufio.NewWriter(io.Discard), done)
+		defer sw.stop()
+		close(done)
+		select {
+		case <-sw.closed:
+		case <-time.After(time.Second):
+			t.Fatal("closed should be closed after the server shut down")
+		}
+	})
+}
+
+type failedWriter struct{}
+
+func (failedWriter) Write(p []byte) (int, error) { return 0, io.ErrClosedPipe }
+
+func TestFiberMux_CORSVary(t *testing.T) {
+	fiberApp := fiber.New()
+	fiberApp.Use(func(c *fiber.Ctx) error {
+		c.Vary("Accept-Encoding")
+		return c.Next()
+	})
+	mux := NewWrapper(fiberApp)
+	app := fastapi.New(fastapi.Config{Title: "fiber"})
+	app.UseCORS(fastapi.CORSConfig{AllowOrigins: []string{"https://app.example.com"}})
+	app.IncludeRouter(&ItemRouter{}).SetMux(mux).Init()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/item/info", nil)
+	req.Header.Set(fastapi.HeaderOrigin, "https://app.example.com")
+	resp, err := fiberApp.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if got := resp.Header.Get("Vary"); got != "Accept-Encoding, Origin" {
+		t.Errorf("Vary = %q", got)
+	}
+}
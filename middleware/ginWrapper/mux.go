@@ -8,10 +8,12 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/Chendemo12/fastapi"
+	"github.com/Chendemo12/fastapi/websocket"
 	"github.com/gin-gonic/gin"
 )
 
@@ -124,6 +126,28 @@ func (m *GinMux) BindRoute(method, path string, handler fastapi.MuxHandler) erro
 			mCtx := AcquireCtx(c)
 			defer ReleaseCtx(mCtx)
 
+			err := handler(mCtx)
+			if err != nil {
+				_ = c.Error(err)
+				c.Abort()
+			}
+		})
+	case http.MethodHead:
+		m.app.HEAD(path, func(c *gin.Context) {
+			mCtx := AcquireCtx(c)
+			defer ReleaseCtx(mCtx)
+
+			err := handler(mCtx)
+			if err != nil {
+				_ = c.Error(err)
+				c.Abort()
+			}
+		})
+	case http.MethodOptions:
+		m.app.OPTIONS(path, func(c *gin.Context) {
+			mCtx := AcquireCtx(c)
+			defer ReleaseCtx(mCtx)
+
 			err := handler(mCtx)
 			if err != nil {
 				_ = c.Error(err)
@@ -137,6 +161,45 @@ func (m *GinMux) BindRoute(method, path string, handler fastapi.MuxHandler) erro
 	return nil
 }
 
+// BindNotFound 注册请求路径不存在时的处理函数, 实现 fastapi.FallbackMuxWrapper
+func (m *GinMux) BindNotFound(handler fastapi.MuxHandler) error {
+	m.app.NoRoute(func(c *gin.Context) {
+		mCtx := AcquireCtx(c)
+		defer ReleaseCtx(mCtx)
+
+		err := handler(mCtx)
+		if err != nil {
+			_ = c.Error(err)
+			c.Abort()
+		}
+	})
+
+	return nil
+}
+
+// BindMethodNotAllowed 注册请求方法不被允许时的处理函数, 实现 fastapi.FallbackMuxWrapper;
+// Allow 响应头由 gin 设置
+func (m *GinMux) BindMethodNotAllowed(handler fastapi.MuxHandler) error {
+	m.app.HandleMethodNotAllowed = true
+	m.app.NoMethod(func(c *gin.Context) {
+		mCtx := AcquireCtx(c)
+		defer ReleaseCtx(mCtx)
+
+		err := handler(mCtx)
+		if err != nil {
+			_ = c.Error(err)
+			c.Abort()
+		}
+	})
+
+	return nil
+}
+
+// BindWebSocket 注册 websocket 路由, 实现 fastapi.WebSocketMuxWrapper
+func (m *GinMux) BindWebSocket(path string, handler fastapi.MuxHandler) error {
+	return m.BindRoute(http.MethodGet, path, handler)
+}
+
 type GinContext struct {
 	ctx *gin.Context
 }
@@ -187,6 +250,11 @@ func (c *GinContext) Query(key string, undefined ...string) string {
 	return value
 }
 
+// QueryArray 实现 fastapi.QueryArrayReader
+func (c *GinContext) QueryArray(key string) []string {
+	return c.ctx.QueryArray(key)
+}
+
 func (c *GinContext) MultipartForm() (*multipart.Form, error) {
 	return c.ctx.MultipartForm()
 }
@@ -219,6 +287,37 @@ func (c *GinContext) Status(statusCode int) {
 	c.ctx.Status(statusCode)
 }
 
+// ResponseHeader 实现 fastapi.ResponseHeaderReader
+func (c *GinContext) ResponseHeader(key string) string {
+	return strings.Join(c.ctx.Writer.Header().Values(key), ", ")
+}
+
+// HandshakeRequest 实现 fastapi.WebSocketUpgrader
+func (c *GinContext) HandshakeRequest() *http.Request { return c.ctx.Request }
+
+// UpgradeWebSocket 实现 fastapi.WebSocketUpgrader, handler 在当前协程中执行
+func (c *GinContext) UpgradeWebSocket(handler func(conn *fastapi.WebSocketConn)) error {
+	conn, err := websocket.UpgradeHTTP(c, c.ctx.Writer)
+	if err != nil {
+		return err
+	}
+
+	handler(conn)
+	return nil
+}
+
+// ResponseSize 实现 fastapi.ResponseSizer
+func (c *GinContext) ResponseSize() int64 { return int64(max(c.ctx.Writer.Size(), 0)) }
+
+// SendStreamWriter 实现 fastapi.StreamWriter, fn 在当前协程中执行, 客户端断开连接时请求的 context 被取消
+func (c *GinContext) SendStreamWriter(fn func(w io.Writer, flush func() error, closed <-chan struct{}) error) error {
+	c.ctx.Writer.WriteHeaderNow()
+	return fn(c.ctx.Writer, func() error {
+		c.ctx.Writer.Flush()
+		return nil
+	}, c.ctx.Request.Context().Done())
+}
+
 func (c *GinContext) Write(p []byte) (int, error) {
 	return c.ctx.Writer.Write(p)
 }
@@ -0,0 +1,138 @@
+package ginWrapper
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Chendemo12/fastapi"
+	"github.com/Chendemo12/fastapi/fastapitest"
+	"github.com/gin-gonic/gin"
+)
+
+type Item struct {
+	Name string `json:"name" description:"名称"`
+}
+
+type ItemRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *ItemRouter) Prefix() string { return "/api/item" }
+
+func (r *ItemRouter) GetInfo(c *fastapi.Context) (*Item, error) {
+	c.MuxContext().Header("X-Total", "1")
+	return &Item{Name: "gin"}, nil
+}
+
+func (r *ItemRouter) PostInfo(c *fastapi.Context, item *Item) (*Item, error) {
+	return item, nil
+}
+
+// newTestServer HEAD 请求的响应体由 net/http 丢弃, 因此需通过真实连接测试
+func newTestServer(t *testing.T) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	mux := NewWrapper(gin.New())
+	app := fastapi.New(fastapi.Config{Title: "gin"})
+	app.IncludeRouter(&ItemRouter{}).SetMux(mux).Init()
+
+	srv := httptest.NewServer(mux.App())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func request(t *testing.T, srv *httptest.Server, method, url string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func TestGinMux_Methods(t *testing.T) {
+	srv := newTestServer(t)
+
+	t.Run("get", func(t *testing.T) {
+		resp, body := request(t, srv, http.MethodGet, "/api/item/info")
+		if resp.StatusCode != http.StatusOK || body != `{"name":"gin"}` {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("head", func(t *testing.T) {
+		resp, body := request(t, srv, http.MethodHead, "/api/item/info")
+		if resp.StatusCode != http.StatusOK || body != "" || resp.Header.Get("X-Total") != "1" {
+			t.Errorf("status = %d, body = %s, header = %v", resp.StatusCode, body, resp.Header)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		resp, _ := request(t, srv, http.MethodOptions, "/api/item/info")
+		if resp.StatusCode != http.StatusNoContent || resp.Header.Get(fastapi.HeaderAllow) != "GET, HEAD, POST, OPTIONS" {
+			t.Errorf("status = %d, Allow = %s", resp.StatusCode, resp.Header.Get(fastapi.HeaderAllow))
+		}
+	})
+
+	t.Run("method-not-allowed", func(t *testing.T) {
+		resp, body := request(t, srv, http.MethodPut, "/api/item/info")
+		if resp.StatusCode != http.StatusMethodNotAllowed || body != `{"detail":"Method Not Allowed"}` {
+			t.Errorf("status = %d, body = %s", resp.StatusCode, body)
+		}
+		if allow := resp.Header.Get(fastapi.HeaderAllow); allow == "" {
+			t.Error("Allow header missing")
+		}
+	})
+}
+
+func TestGinMux_NotFound(t *testing.T) {
+	srv := newTestServer(t)
+
+	resp, body := request(t, srv, http.MethodGet, "/api/unknown")
+	if resp.StatusCode != http.StatusNotFound || body != `{"detail":"Not Found"}` {
+		t.Errorf("status = %d, body = %s", resp.StatusCode, body)
+	}
+
+	resp, _ = request(t, srv, http.MethodGet, "/openapi.json")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d", resp.StatusCode)
+	}
+}
+
+func TestGinMux_WebSocket(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fastapitest.RunWebSocketConformance(t, func(app *fastapi.Wrapper) string {
+		mux := NewWrapper(gin.New())
+		app.SetMux(mux).Init()
+
+		srv := httptest.NewServer(mux.App())
+		t.Cleanup(srv.Close)
+		return strings.TrimPrefix(srv.URL, "http://")
+	})
+}
+
+func TestGinMux_CORSVary(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) { c.Header("Vary", "Accept-Encoding") })
+	mux := NewWrapper(engine)
+	app := fastapi.New(fastapi.Config{Title: "gin"})
+	app.UseCORS(fastapi.CORSConfig{AllowOrigins: []string{"https://app.example.com"}})
+	app.IncludeRouter(&ItemRouter{}).SetMux(mux).Init()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/item/info", nil)
+	req.Header.Set(fastapi.HeaderOrigin, "https://app.example.com")
+	rec := httptest.NewRecorder()
+	mux.App().ServeHTTP(rec, req)
+	if got := rec.Header().Get("Vary"); got != "Accept-Encoding, Origin" {
+		t.Errorf("Vary = %q", got)
+	}
+}
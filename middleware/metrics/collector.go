@@ -0,0 +1,259 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 直方图桶的上界标签
+const bucketLabel = "le"
+
+// DefaultBuckets 默认的直方图桶, 适用于以秒为单位的请求耗时
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// 一组标签值对应的时间序列
+type series[T any] struct {
+	values []string
+	value  *T
+}
+
+// 按照标签值区分的一组时间序列, 标签值的数量需与标签名一致, 否则 panic
+type vec[T any] struct {
+	desc   Desc
+	mu     sync.RWMutex
+	series map[string]*series[T]
+}
+
+func newVec[T any](name, help string, typ MetricType, labels []string) vec[T] {
+	return vec[T]{
+		desc:   Desc{Name: name, Help: help, Type: typ, Labels: append([]string{}, labels...)},
+		series: map[string]*series[T]{},
+	}
+}
+
+func (v *vec[T]) Describe() Desc { return v.desc }
+
+func (v *vec[T]) get(values []string) *T {
+	if len(values) != len(v.desc.Labels) {
+		panic(fmt.Sprintf("metric: '%s' expects %d label values, got %d", v.desc.Name, len(v.desc.Labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	s, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return s.value
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok = v.series[key]; !ok {
+		s = &series[T]{values: append([]string{}, values...), value: new(T)}
+		v.series[key] = s
+	}
+	return s.value
+}
+
+// 按照标签值排序遍历全部时间序列, 以使输出稳定
+func (v *vec[T]) each(fn func(labels []Label, value *T)) {
+	v.mu.RLock()
+	all := make([]*series[T], 0, len(v.series))
+	for _, s := range v.series {
+		all = append(all, s)
+	}
+	v.mu.RUnlock()
+
+	sort.Slice(all, func(i, j int) bool { return slices.Compare(all[i].values, all[j].values) < 0 })
+	for _, s := range all {
+		labels := make([]Label, len(s.values))
+		for i, value := range s.values {
+			labels[i] = Label{Name: v.desc.Labels[i], Value: value}
+		}
+		fn(labels, s.value)
+	}
+}
+
+// 并发安全的浮点数
+type value struct {
+	mu sync.Mutex
+	v  float64
+}
+
+func (f *value) add(delta float64) {
+	f.mu.Lock()
+	f.v += delta
+	f.mu.Unlock()
+}
+
+func (f *value) set(v float64) {
+	f.mu.Lock()
+	f.v = v
+	f.mu.Unlock()
+}
+
+func (f *value) load() float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.v
+}
+
+// Counter 只增不减的计数器
+type Counter struct {
+	vec[value]
+}
+
+// NewCounter 创建计数器, labels 为标签名, 计数器名通常以 _total 结尾
+func NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{vec: newVec[value](name, help, TypeCounter, labels)}
+}
+
+// Inc 计数加1, values 为与标签名一一对应的标签值
+func (c *Counter) Inc(values ...string) { c.Add(1, values...) }
+
+// Add 增加计数, delta 不可为负数, 否则 panic
+func (c *Counter) Add(delta float64, values ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metric: counter '%s' cannot decrease", c.desc.Name))
+	}
+	c.get(values).add(delta)
+}
+
+// Value 计数器的当前值
+func (c *Counter) Value(values ...string) float64 { return c.get(values).load() }
+
+func (c *Counter) Collect() []Sample {
+	var samples []Sample
+	c.each(func(labels []Label, v *value) {
+		samples = append(samples, Sample{Labels: labels, Value: v.load()})
+	})
+	return samples
+}
+
+// Gauge 可增可减的仪表盘
+type Gauge struct {
+	vec[value]
+}
+
+// NewGauge 创建仪表盘, labels 为标签名
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{vec: newVec[value](name, help, TypeGauge, labels)}
+}
+
+func (g *Gauge) Set(v float64, values ...string) { g.get(values).set(v) }
+
+func (g *Gauge) Add(delta float64, values ...string) { g.get(values).add(delta) }
+
+func (g *Gauge) Inc(values ...string) { g.Add(1, values...) }
+
+func (g *Gauge) Dec(values ...string) { g.Add(-1, values...) }
+
+// Value 仪表盘的当前值
+func (g *Gauge) Value(values ...string) float64 { return g.get(values).load() }
+
+func (g *Gauge) Collect() []Sample {
+	var samples []Sample
+	g.each(func(labels []Label, v *value) {
+		samples = append(samples, Sample{Labels: labels, Value: v.load()})
+	})
+	return samples
+}
+
+// 直方图的单个时间序列, counts 为落入每个桶(不累计)的观测次数, 最后一个为 +Inf
+type histogramValue struct {
+	mu     sync.Mutex
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Histogram 直方图, 统计观测值的分布
+type Histogram struct {
+	vec[histogramValue]
+	buckets []float64
+}
+
+// NewHistogram 创建直方图, buckets 为递增的桶上界, 为空时使用 DefaultBuckets; labels 为标签名
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = slices.Clone(buckets)
+	if math.IsInf(buckets[len(buckets)-1], 1) { // +Inf 桶始终存在
+		buckets = buckets[:len(buckets)-1]
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			panic(fmt.Sprintf("metric: histogram '%s' buckets must be in increasing order", name))
+		}
+	}
+
+	return &Histogram{vec: newVec[histogramValue](name, help, TypeHistogram, labels), buckets: buckets}
+}
+
+// Observe 记录一个观测值
+func (h *Histogram) Observe(v float64, values ...string) {
+	hv := h.get(values)
+	i := sort.SearchFloat64s(h.buckets, v) // 第一个 >= v 的桶
+
+	hv.mu.Lock()
+	defer hv.mu.Unlock()
+	if hv.counts == nil {
+		hv.counts = make([]uint64, len(h.buckets)+1)
+	}
+	hv.counts[i]++
+	hv.sum += v
+	hv.count++
+}
+
+// Count 观测次数
+func (h *Histogram) Count(values ...string) uint64 {
+	hv := h.get(values)
+	hv.mu.Lock()
+	defer hv.mu.Unlock()
+	return hv.count
+}
+
+func (h *Histogram) Collect() []Sample {
+	var samples []Sample
+	h.each(func(labels []Label, hv *histogramValue) {
+		hv.mu.Lock()
+		counts := slices.Clone(hv.counts)
+		sum, count := hv.sum, hv.count
+		hv.mu.Unlock()
+		if counts == nil {
+			counts = make([]uint64, len(h.buckets)+1)
+		}
+
+		var cumulative uint64
+		for i, upper := range append(slices.Clone(h.buckets), math.Inf(1)) {
+			cumulative += counts[i]
+			bucket := append(slices.Clone(labels), Label{Name: bucketLabel, Value: formatFloat(upper)})
+			samples = append(samples, Sample{Suffix: "_bucket", Labels: bucket, Value: float64(cumulative)})
+		}
+		samples = append(samples,
+			Sample{Suffix: "_sum", Labels: labels, Value: sum},
+			Sample{Suffix: "_count", Labels: labels, Value: float64(count)},
+		)
+	})
+	return samples
+}
+
+// GaugeFunc 在导出时通过函数获取值的仪表盘, 适用于协程数、连接数等已由其他组件统计的值
+type GaugeFunc struct {
+	desc Desc
+	fn   func() float64
+}
+
+// NewGaugeFunc 创建仪表盘, fn 需并发安全
+func NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	return &GaugeFunc{desc: Desc{Name: name, Help: help, Type: TypeGauge}, fn: fn}
+}
+
+func (g *GaugeFunc) Describe() Desc { return g.desc }
+
+func (g *GaugeFunc) Collect() []Sample { return []Sample{{Value: g.fn()}} }
@@ -0,0 +1,134 @@
+// Package metrics 以 Prometheus 文本格式导出路由指标, 不依赖 Prometheus 客户端库
+//
+//	# Usage
+//
+//	m := metrics.New(metrics.Config{})
+//	app.IncludeRouter(m).UseObserver(m) // GET /metrics
+//
+//	requests := metrics.NewCounter("orders_total", "Created orders.", "channel")
+//	m.MustRegister(requests)
+//	requests.Inc("web")
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Chendemo12/fastapi"
+	"github.com/Chendemo12/fastapi/openapi"
+	"github.com/Chendemo12/fastapi/pathschema"
+)
+
+// DefaultNamespace 内置指标名的默认前缀
+const DefaultNamespace = "fastapi"
+
+// Config 指标配置
+type Config struct {
+	Namespace string    `description:"内置指标名的前缀, 默认为 fastapi"`
+	Prefix    string    `description:"路由前缀, 默认为空, 即 /metrics"`
+	Buckets   []float64 `description:"请求耗时直方图的桶, 单位秒, 默认为 DefaultBuckets"`
+	Registry  *Registry `description:"指标注册表, 默认创建新的注册表"`
+}
+
+// Metrics 指标路由组, 同时作为 fastapi.RequestObserver 统计每个路由的请求;
+// 内置指标的标签为: route(路由ID), method(请求方法), status(状态码分类, 如 2xx)
+//
+//	<namespace>_http_requests_total{route,method,status}				请求总数
+//	<namespace>_http_request_duration_seconds{route,method,status}		请求耗时直方图
+//	<namespace>_http_requests_in_flight{route,method}					正在处理的请求数
+//	<namespace>_http_validation_failures_total{route,method}			422校验失败次数
+type Metrics struct {
+	fastapi.BaseGroupRouter
+	prefix   string
+	registry *Registry
+	requests *Counter
+	duration *Histogram
+	inFlight *Gauge
+	failures *Counter
+}
+
+// New 创建指标路由组, 需通过 Wrapper.IncludeRouter 和 Wrapper.UseObserver 同时注册
+func New(conf ...Config) *Metrics {
+	c := Config{}
+	if len(conf) > 0 {
+		c = conf[0]
+	}
+	if c.Namespace == "" {
+		c.Namespace = DefaultNamespace
+	}
+	if c.Registry == nil {
+		c.Registry = NewRegistry()
+	}
+
+	m := &Metrics{
+		prefix:   c.Prefix,
+		registry: c.Registry,
+		requests: NewCounter(c.Namespace+"_http_requests_total",
+			"Total number of HTTP requests.", "route", "method", "status"),
+		duration: NewHistogram(c.Namespace+"_http_request_duration_seconds",
+			"HTTP request latency in seconds.", c.Buckets, "route", "method", "status"),
+		inFlight: NewGauge(c.Namespace+"_http_requests_in_flight",
+			"Number of HTTP requests currently being served.", "route", "method"),
+		failures: NewCounter(c.Namespace+"_http_validation_failures_total",
+			"Total number of requests rejected with 422 validation errors.", "route", "method"),
+	}
+	m.registry.MustRegister(m.requests, m.duration, m.inFlight, m.failures)
+
+	return m
+}
+
+// Registry 指标注册表
+func (m *Metrics) Registry() *Registry { return m.registry }
+
+// Register 注册自定义指标
+func (m *Metrics) Register(collectors ...Collector) error {
+	return m.registry.Register(collectors...)
+}
+
+// MustRegister 注册自定义指标, 发生错误时 panic
+func (m *Metrics) MustRegister(collectors ...Collector) {
+	m.registry.MustRegister(collectors...)
+}
+
+// OnRequest 实现 fastapi.RequestObserver
+func (m *Metrics) OnRequest(c *fastapi.Context, route fastapi.RouteIface) {
+	m.inFlight.Inc(route.Id(), c.MuxContext().Method())
+}
+
+// OnResponse 实现 fastapi.RequestObserver
+func (m *Metrics) OnResponse(c *fastapi.Context, route fastapi.RouteIface) {
+	id, method := route.Id(), c.MuxContext().Method()
+	status := statusClass(c.Response().StatusCode)
+
+	m.inFlight.Dec(id, method)
+	m.requests.Inc(id, method, status)
+	m.duration.Observe(time.Since(c.StartedAt()).Seconds(), id, method, status)
+	if _, ok := c.Response().Content.(*openapi.HTTPValidationError); ok && c.Response().StatusCode == http.StatusUnprocessableEntity {
+		m.failures.Inc(id, method)
+	}
+}
+
+func (m *Metrics) Prefix() string { return m.prefix }
+
+func (m *Metrics) Tags() []string { return []string{"Metrics"} }
+
+func (m *Metrics) PathSchema() pathschema.RoutePathSchema { return pathschema.Default() }
+
+func (m *Metrics) Summary() map[string]string {
+	return map[string]string{"GetMetrics": "Prometheus 指标"}
+}
+
+// GetMetrics 以 Prometheus 文本格式导出全部指标
+func (m *Metrics) GetMetrics(c *fastapi.Context) (string, error) {
+	c.MuxContext().Header(openapi.HeaderContentType, ContentType)
+	return m.registry.String(), nil
+}
+
+// 状态码分类, 如 200 => 2xx
+func statusClass(code int) string {
+	if code < 100 || code > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Chendemo12/fastapi"
+	"github.com/Chendemo12/fastapi/fastapitest"
+	"github.com/Chendemo12/fastapi/openapi"
+)
+
+type Order struct {
+	Id int `json:"id" validate:"required,gt=0" description:"订单号"`
+}
+
+type OrderRouter struct {
+	fastapi.BaseGroupRouter
+}
+
+func (r *OrderRouter) Prefix() string { return "/api/order" }
+
+func (r *OrderRouter) PostInfo(c *fastapi.Context, order *Order) (*Order, error) {
+	return order, nil
+}
+
+func (r *OrderRouter) GetFail(c *fastapi.Context) (string, error) {
+	return "", fastapi.NewHTTPError(http.StatusServiceUnavailable)
+}
+
+func TestMetrics(t *testing.T) {
+	m := New(Config{Namespace: "shop", Buckets: []float64{1}})
+	orders := NewCounter("shop_orders_total", "Created orders.", "channel")
+	m.MustRegister(orders)
+
+	app := fastapi.New(fastapi.Config{Title: "metrics"})
+	app.IncludeRouter(&OrderRouter{}).IncludeRouter(m).UseObserver(m)
+	client := fastapitest.NewClient(app)
+
+	post := openapi.CreateRouteIdentify(http.MethodPost, "/api/order/info")
+	fail := openapi.CreateRouteIdentify(http.MethodGet, "/api/order/fail")
+
+	client.Post("/api/order/info", &Order{Id: 1})
+	client.Post("/api/order/info", &Order{Id: 2})
+	if resp := client.Post("/api/order/info", &Order{Id: -1}); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	client.Get("/api/order/fail")
+	client.Get("/api/order/unknown") // 404 不统计
+	orders.Inc("web")
+
+	if v := m.requests.Value(post, http.MethodPost, "2xx"); v != 2 {
+		t.Errorf("2xx requests = %v", v)
+	}
+	if v := m.requests.Value(post, http.MethodPost, "4xx"); v != 1 {
+		t.Errorf("4xx requests = %v", v)
+	}
+	if v := m.failures.Value(post, http.MethodPost); v != 1 {
+		t.Errorf("validation failures = %v", v)
+	}
+	if v := m.requests.Value(fail, http.MethodGet, "5xx"); v != 1 {
+		t.Errorf("5xx requests = %v", v)
+	}
+	if v := m.failures.Value(fail, http.MethodGet); v != 0 {
+		t.Errorf("validation failures = %v", v)
+	}
+	if v := m.duration.Count(post, http.MethodPost, "2xx"); v != 2 {
+		t.Errorf("duration count = %v", v)
+	}
+	if v := m.inFlight.Value(post, http.MethodPost); v != 0 {
+		t.Errorf("in flight = %v", v)
+	}
+
+	resp := client.Get("/metrics")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(openapi.HeaderContentType) != ContentType {
+		t.Fatalf("status = %d, content-type = %q", resp.StatusCode, resp.Header.Get(openapi.HeaderContentType))
+	}
+	body := resp.String()
+	for _, want := range []string{
+		"# TYPE shop_http_requests_total counter\n",
+		`shop_http_requests_total{route="` + post + `",method="POST",status="2xx"} 2` + "\n",
+		`shop_http_request_duration_seconds_bucket{route="` + post + `",method="POST",status="2xx",le="+Inf"} 2` + "\n",
+		`shop_http_validation_failures_total{route="` + post + `",method="POST"} 1` + "\n",
+		"# TYPE shop_http_requests_in_flight gauge\n",
+		`shop_orders_total{channel="web"} 1` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics does not contain %q:\n%s", want, body)
+		}
+	}
+	// 正在处理的 /metrics 请求
+	metrics := openapi.CreateRouteIdentify(http.MethodGet, "/metrics")
+	if !strings.Contains(body, `shop_http_requests_in_flight{route="`+metrics+`",method="GET"} 1`) {
+		t.Errorf("metrics request should be in flight:\n%s", body)
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	for code, want := range map[int]string{200: "2xx", 204: "2xx", 301: "3xx", 422: "4xx", 503: "5xx", 0: "unknown", 600: "unknown"} {
+		if got := statusClass(code); got != want {
+			t.Errorf("statusClass(%d) = %s, want %s", code, got, want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType Prometheus 文本格式的内容类型
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// MetricType 指标类型
+type MetricType string
+
+const (
+	TypeCounter   MetricType = "counter"
+	TypeGauge     MetricType = "gauge"
+	TypeHistogram MetricType = "histogram"
+	TypeUntyped   MetricType = "untyped"
+)
+
+var (
+	metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRegexp  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Desc 指标的描述信息
+type Desc struct {
+	Name   string     `description:"指标名"`
+	Help   string     `description:"指标说明"`
+	Type   MetricType `description:"指标类型"`
+	Labels []string   `description:"标签名"`
+}
+
+// Label 样本的标签
+type Label struct {
+	Name  string `description:"标签名"`
+	Value string `description:"标签值"`
+}
+
+// Sample 样本, 即文本格式中的一行
+type Sample struct {
+	Suffix string  `description:"样本名后缀, 如直方图的 _bucket, _sum 和 _count"`
+	Labels []Label `description:"标签"`
+	Value  float64 `description:"样本值"`
+}
+
+// Collector 可注册到 Registry 的指标, Counter, Gauge, Histogram 和 GaugeFunc 均已实现此接口;
+// 实现此接口即可注册自定义的指标
+type Collector interface {
+	// Describe 指标的描述信息, 注册时校验, 之后不可修改
+	Describe() Desc
+	// Collect 采集全部样本, 每次导出时调用, 需并发安全
+	Collect() []Sample
+}
+
+// Registry 指标注册表, 并发安全
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []Collector
+	names      map[string]struct{}
+}
+
+// NewRegistry 创建一个空的注册表
+func NewRegistry() *Registry {
+	return &Registry{names: map[string]struct{}{}}
+}
+
+// Register 注册指标, 指标名和标签名需符合 Prometheus 规范, 且指标名不可重复
+func (r *Registry) Register(collectors ...Collector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range collectors {
+		desc := c.Describe()
+		if err := validateDesc(desc); err != nil {
+			return err
+		}
+		if _, ok := r.names[desc.Name]; ok {
+			return fmt.Errorf("metric: '%s' already registered", desc.Name)
+		}
+		r.names[desc.Name] = struct{}{}
+		r.collectors = append(r.collectors, c)
+	}
+	return nil
+}
+
+// MustRegister 注册指标, 发生错误时 panic
+func (r *Registry) MustRegister(collectors ...Collector) {
+	if err := r.Register(collectors...); err != nil {
+		panic(err)
+	}
+}
+
+// Write 以 Prometheus 文本格式写入全部指标, 指标按照注册的顺序排列
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.RLock()
+	collectors := append([]Collector{}, r.collectors...)
+	r.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		desc := c.Describe()
+		if desc.Help != "" {
+			_, _ = fmt.Fprintf(bw, "# HELP %s %s\n", desc.Name, helpEscaper.Replace(desc.Help))
+		}
+		_, _ = fmt.Fprintf(bw, "# TYPE %s %s\n", desc.Name, desc.Type)
+
+		for _, sample := range c.Collect() {
+			_, _ = bw.WriteString(desc.Name)
+			_, _ = bw.WriteString(sample.Suffix)
+			if len(sample.Labels) > 0 {
+				_ = bw.WriteByte('{')
+				for i, label := range sample.Labels {
+					if i > 0 {
+						_ = bw.WriteByte(',')
+					}
+					_, _ = fmt.Fprintf(bw, `%s="%s"`, label.Name, labelEscaper.Replace(label.Value))
+				}
+				_ = bw.WriteByte('}')
+			}
+			_ = bw.WriteByte(' ')
+			_, _ = bw.WriteString(formatFloat(sample.Value))
+			_ = bw.WriteByte('\n')
+		}
+	}
+
+	return bw.Flush()
+}
+
+// String 以 Prometheus 文本格式返回全部指标
+func (r *Registry) String() string {
+	sb := &strings.Builder{}
+	_ = r.Write(sb)
+	return sb.String()
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+func validateDesc(desc Desc) error {
+	if !metricNameRegexp.MatchString(desc.Name) {
+		return fmt.Errorf("metric: invalid name '%s'", desc.Name)
+	}
+	switch desc.Type {
+	case TypeCounter, TypeGauge, TypeHistogram, TypeUntyped:
+	default:
+		return fmt.Errorf("metric: '%s' has unsupported type '%s'", desc.Name, desc.Type)
+	}
+
+	seen := map[string]struct{}{}
+	for _, label := range desc.Labels {
+		if !labelNameRegexp.MatchString(label) || strings.HasPrefix(label, "__") {
+			return fmt.Errorf("metric: '%s' has invalid label name '%s'", desc.Name, label)
+		}
+		if desc.Type == TypeHistogram && label == bucketLabel {
+			return fmt.Errorf("metric: '%s' label name '%s' is reserved", desc.Name, label)
+		}
+		if _, ok := seen[label]; ok {
+			return fmt.Errorf("metric: '%s' has duplicate label name '%s'", desc.Name, label)
+		}
+		seen[label] = struct{}{}
+	}
+	return nil
+}
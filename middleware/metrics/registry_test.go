@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	registry := NewRegistry()
+	counter := NewCounter("jobs_total", "Processed jobs.\nSecond line.", "queue")
+	gauge := NewGauge("temperature", "", "room")
+	histogram := NewHistogram("latency_seconds", "Latency.", []float64{0.1, 1, math.Inf(1)})
+	registry.MustRegister(counter, gauge, histogram, NewGaugeFunc("up", "Up.", func() float64 { return 1 }))
+
+	counter.Inc("b")
+	counter.Add(2.5, `a"\`+"\n")
+	gauge.Set(21.5, "kitchen")
+	gauge.Dec("kitchen")
+	histogram.Observe(0.05)
+	histogram.Observe(0.1)
+	histogram.Observe(3)
+
+	want := strings.Join([]string{
+		`# HELP jobs_total Processed jobs.\nSecond line.`,
+		`# TYPE jobs_total counter`,
+		`jobs_total{queue="a\"\\\n"} 2.5`,
+		`jobs_total{queue="b"} 1`,
+		`# TYPE temperature gauge`,
+		`temperature{room="kitchen"} 20.5`,
+		`# HELP latency_seconds Latency.`,
+		`# TYPE latency_seconds histogram`,
+		`latency_seconds_bucket{le="0.1"} 2`,
+		`latency_seconds_bucket{le="1"} 2`,
+		`latency_seconds_bucket{le="+Inf"} 3`,
+		`latency_seconds_sum 3.15`,
+		`latency_seconds_count 3`,
+		`# HELP up Up.`,
+		`# TYPE up gauge`,
+		`up 1`,
+		``,
+	}, "\n")
+	if got := registry.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRegistry_Register_Invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		collector Collector
+		want      string
+	}{
+		{name: "name", collector: NewCounter("1jobs", ""), want: "invalid name"},
+		{name: "label", collector: NewCounter("jobs", "", "queue-name"), want: "invalid label name"},
+		{name: "reserved label", collector: NewCounter("jobs", "", "__queue"), want: "invalid label name"},
+		{name: "bucket label", collector: NewHistogram("jobs", "", nil, "le"), want: "is reserved"},
+		{name: "duplicate label", collector: NewGauge("jobs", "", "a", "a"), want: "duplicate label name"},
+		{name: "duplicate name", collector: NewGauge("registered", ""), want: "already registered"},
+	}
+
+	registry := NewRegistry()
+	registry.MustRegister(NewCounter("registered", ""))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := registry.Register(tt.collector)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCounter_Panics(t *testing.T) {
+	counter := NewCounter("jobs_total", "", "queue")
+	for name, fn := range map[string]func(){
+		"label values": func() { counter.Inc() },
+		"decrease":     func() { counter.Add(-1, "a") },
+		"buckets":      func() { NewHistogram("latency", "", []float64{1, 0.5}) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			fn()
+		})
+	}
+}
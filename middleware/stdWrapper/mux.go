@@ -0,0 +1,477 @@
+package stdWrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Chendemo12/fastapi"
+	"github.com/Chendemo12/fastapi/openapi"
+	"github.com/Chendemo12/fastapi/pathschema"
+	"github.com/Chendemo12/fastapi/utils"
+	"github.com/Chendemo12/fastapi/websocket"
+)
+
+// DefaultMultipartMemory multipart/form-data 解析时允许驻留在内存中的最大字节数, 超出部分将写入临时文件
+var DefaultMultipartMemory int64 = 32 << 20
+
+var pool = &sync.Pool{New: func() any { return &StdContext{} }}
+
+func AcquireCtx(w http.ResponseWriter, r *http.Request, path string) *StdContext {
+	obj := pool.Get().(*StdContext)
+	obj.writer = w
+	obj.req = r
+	obj.path = path
+
+	return obj
+}
+
+func ReleaseCtx(c *StdContext) {
+	c.writer = nil
+	c.req = nil
+	c.path = ""
+	c.query = nil
+	c.keys = nil
+	c.statusCode = 0
+	c.wroteHeader = false
+	c.written = 0
+	pool.Put(c)
+}
+
+// StdMux 基于标准库 http.ServeMux 的路由器, 依赖于 go1.22 之后的 "METHOD /path/{param}" 路由模式
+type StdMux struct {
+	mux        *http.ServeMux
+	srv        *http.Server
+	notFound   fastapi.MuxHandler
+	notAllowed fastapi.MuxHandler
+}
+
+// Default 创建一个新的 http.ServeMux 并包装
+func Default() *StdMux {
+	return NewWrapper(http.NewServeMux())
+}
+
+// NewWrapper 创建App实例
+func NewWrapper(mux *http.ServeMux) *StdMux {
+	return &StdMux{
+		mux: mux,
+	}
+}
+
+func (m *StdMux) App() *http.ServeMux { return m.mux }
+
+// ServeHTTP 实现 http.Handler 接口, 可直接用于 httptest 或其他 http.Server
+func (m *StdMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if m.notFound != nil || m.notAllowed != nil {
+		if _, pattern := m.mux.Handler(r); pattern == "" { // 404 或 405
+			allowed := m.allowedMethods(r)
+			switch {
+			case len(allowed) > 0 && m.notAllowed != nil:
+				w.Header().Set(fastapi.HeaderAllow, strings.Join(allowed, ", "))
+				m.serveFallback(w, r, m.notAllowed)
+				return
+			case len(allowed) == 0 && m.notFound != nil:
+				m.serveFallback(w, r, m.notFound)
+				return
+			}
+		}
+	}
+	m.mux.ServeHTTP(w, r)
+}
+
+// 以其他请求方法探测路由, 获得请求路径所支持的请求方法
+func (m *StdMux) allowedMethods(r *http.Request) []string {
+	allowed := make([]string, 0)
+	probe := *r
+	for _, method := range probeMethods {
+		if method == r.Method {
+			continue
+		}
+		probe.Method = method
+		if _, pattern := m.mux.Handler(&probe); pattern != "" {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// 与 fastapi.Context.RouteId 一致的路由标识, HEAD 请求与 GET 请求共用路由
+func routeId(method, path string) string {
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	return openapi.CreateRouteIdentify(method, path)
+}
+
+func (m *StdMux) serveFallback(w http.ResponseWriter, r *http.Request, handler fastapi.MuxHandler) {
+	mCtx := AcquireCtx(w, r, "")
+	defer ReleaseCtx(mCtx)
+
+	if err := handler(mCtx); err != nil {
+		fastapi.Structured().Warn("fallback handler failed", "method", r.Method, "path", r.URL.Path, "route", "", "error", err)
+	}
+	mCtx.flushHeader()
+}
+
+// BindNotFound 注册请求路径不存在时的处理函数, 实现 fastapi.FallbackMuxWrapper
+func (m *StdMux) BindNotFound(handler fastapi.MuxHandler) error {
+	m.notFound = handler
+	return nil
+}
+
+// BindMethodNotAllowed 注册请求方法不被允许时的处理函数, 实现 fastapi.FallbackMuxWrapper
+func (m *StdMux) BindMethodNotAllowed(handler fastapi.MuxHandler) error {
+	m.notAllowed = handler
+	return nil
+}
+
+// 探测路由时使用的请求方法
+var probeMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPatch,
+	http.MethodPut,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+func (m *StdMux) Listen(addr string) error {
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: m,
+	}
+	m.srv = srv
+	return srv.ListenAndServe()
+}
+
+func (m *StdMux) ShutdownWithTimeout(timeout time.Duration) error {
+	if m.srv == nil {
+		return nil
+	}
+
+	// 关闭服务器
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := m.srv.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *StdMux) BindRoute(method, path string, handler fastapi.MuxHandler) (err error) {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPatch, http.MethodPut, http.MethodDelete, http.MethodOptions:
+	default:
+		return errors.New(fmt.Sprintf("unknow method:'%s' for path: '%s'", method, path))
+	}
+
+	// http.ServeMux 在路由冲突时会直接 panic
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("bind route failed, %v", v)
+		}
+	}()
+
+	m.mux.HandleFunc(method+" "+ToServeMuxPattern(path), func(w http.ResponseWriter, r *http.Request) {
+		mCtx := AcquireCtx(w, r, path)
+		defer ReleaseCtx(mCtx)
+
+		err := handler(mCtx)
+		if err != nil {
+			// 通常情况下此方法不会返回错误，如果发生错误，错误通常为写流错误
+			fastapi.Structured().Warn("route handler failed",
+				"method", r.Method, "path", r.URL.Path, "route", routeId(method, path), "error", err)
+			if !mCtx.wroteHeader {
+				mCtx.statusCode = http.StatusInternalServerError
+				_ = mCtx.SendString(err.Error())
+			}
+			return
+		}
+		mCtx.flushHeader()
+	})
+
+	return nil
+}
+
+// BindWebSocket 注册 websocket 路由, 实现 fastapi.WebSocketMuxWrapper
+func (m *StdMux) BindWebSocket(path string, handler fastapi.MuxHandler) error {
+	return m.BindRoute(http.MethodGet, path, handler)
+}
+
+// ToServeMuxPattern 将 fastapi 格式的路由转换为 http.ServeMux 格式的路由
+//
+//	Example:
+//		Input: "/api/rcst/:no"
+//		Output: "/api/rcst/{no}"
+//		Input: "/api/rcst/:no?"		// 不支持可选路径参数, 视为必选
+//		Output: "/api/rcst/{no}"
+//		Input: "/api/rcst/*"
+//		Output: "/api/rcst/{path...}"
+//		Input: "/api/rcst/"			// 仅匹配此路由本身，而非以此为前缀的全部路由
+//		Output: "/api/rcst/{$}"
+//		Input: "openapi.json"			// 缺少的 / 会被补全
+//		Output: "/openapi.json"
+func ToServeMuxPattern(path string) string {
+	if !strings.HasPrefix(path, pathschema.PathSeparator) {
+		path = pathschema.PathSeparator + path
+	}
+
+	paths := strings.Split(path, pathschema.PathSeparator)
+	for i := 0; i < len(paths); i++ {
+		switch {
+		case strings.HasPrefix(paths[i], pathschema.PathParamPrefix):
+			name := strings.TrimSuffix(paths[i][1:], pathschema.OptionalQueryParamPrefix)
+			paths[i] = "{" + name + "}"
+		case paths[i] == "*":
+			paths[i] = "{path...}"
+		}
+	}
+
+	pattern := strings.Join(paths, pathschema.PathSeparator)
+	if strings.HasSuffix(pattern, pathschema.PathSeparator) {
+		pattern += "{$}"
+	}
+
+	return pattern
+}
+
+// StdContext http.Request 和 http.ResponseWriter 的包装
+//
+// 响应状态码会被延迟到第一次写入响应体时才写入，因此允许先调用 Status 再调用 Header
+type StdContext struct {
+	writer      http.ResponseWriter
+	req         *http.Request
+	path        string
+	query       url.Values
+	keys        map[string]any
+	statusCode  int
+	wroteHeader bool
+	written     int64
+}
+
+func (c *StdContext) Method() string { return c.req.Method }
+
+// Path 注册时的路由模式, 而非请求Url
+func (c *StdContext) Path() string { return c.path }
+
+// Ctx 返回自身, 可通过 Request 和 Writer 获取原始对象
+func (c *StdContext) Ctx() any { return c }
+
+func (c *StdContext) Request() *http.Request { return c.req }
+
+func (c *StdContext) Writer() http.ResponseWriter { return c.writer }
+
+func (c *StdContext) Set(key string, value any) {
+	if c.keys == nil {
+		c.keys = make(map[string]any)
+	}
+	c.keys[key] = value
+}
+
+func (c *StdContext) Get(key string) (value any, exists bool) {
+	value, exists = c.keys[key]
+	return
+}
+
+func (c *StdContext) ClientIP() string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(c.req.RemoteAddr))
+	if err != nil {
+		return c.req.RemoteAddr
+	}
+	return host
+}
+
+// ContentType 请求体的 Content-Type, 不包含 charset 等参数
+func (c *StdContext) ContentType() string {
+	ct := c.req.Header.Get(openapi.HeaderContentType)
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return ct
+	}
+	return mediaType
+}
+
+// GetHeader 读取请求头, 当key不存在时返回空字符串
+func (c *StdContext) GetHeader(key string) string {
+	return c.req.Header.Get(key)
+}
+
+func (c *StdContext) Cookie(name string) (string, error) {
+	cookie, err := c.req.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return cookie.Value, nil
+}
+
+// Params 解析路径参数
+func (c *StdContext) Params(key string, undefined ...string) string {
+	value := c.req.PathValue(key)
+	if value == "" && len(undefined) > 0 {
+		return undefined[0]
+	}
+	return value
+}
+
+func (c *StdContext) Query(key string, undefined ...string) string {
+	if c.query == nil {
+		c.query = c.req.URL.Query() // 仅解析一次
+	}
+	value := c.query.Get(key)
+	if value == "" && len(undefined) > 0 {
+		return undefined[0]
+	}
+	return value
+}
+
+// QueryArray 实现 fastapi.QueryArrayReader
+func (c *StdContext) QueryArray(key string) []string {
+	if c.query == nil {
+		c.query = c.req.URL.Query()
+	}
+	return c.query[key]
+}
+
+func (c *StdContext) MultipartForm() (*multipart.Form, error) {
+	if c.req.MultipartForm == nil {
+		err := c.req.ParseMultipartForm(DefaultMultipartMemory)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.req.MultipartForm, nil
+}
+
+// ShouldBind 仅支持json请求体, 标准库没有校验方法，因此需返回 false
+func (c *StdContext) ShouldBind(obj any) (validated bool, err error) {
+	if c.req.Body == nil {
+		return false, errors.New("request body is empty")
+	}
+	body, err := io.ReadAll(c.req.Body)
+	if err != nil {
+		return false, err
+	}
+
+	return false, utils.JsonUnmarshal(body, obj)
+}
+
+func (c *StdContext) Header(key, value string) {
+	c.writer.Header().Set(key, value)
+}
+
+func (c *StdContext) SetCookie(cookie *http.Cookie) {
+	http.SetCookie(c.writer, cookie)
+}
+
+func (c *StdContext) Redirect(code int, location string) error {
+	http.Redirect(c.writer, c.req, location, code)
+	c.wroteHeader = true
+	return nil
+}
+
+func (c *StdContext) Status(code int) {
+	c.statusCode = code
+}
+
+func (c *StdContext) SendString(s string) error {
+	if c.writer.Header().Get(openapi.HeaderContentType) == "" {
+		c.Header(openapi.HeaderContentType, string(openapi.MIMETextPlainCharsetUTF8))
+	}
+	_, err := io.WriteString(c, s)
+	return err
+}
+
+func (c *StdContext) JSON(code int, data any) error {
+	bytes, err := utils.JsonMarshal(data)
+	if err != nil {
+		return err
+	}
+	c.statusCode = code
+	c.Header(openapi.HeaderContentType, string(openapi.MIMEApplicationJSONCharsetUTF8))
+	_, err = c.Write(bytes)
+	return err
+}
+
+func (c *StdContext) SendStream(stream io.Reader, size ...int) error {
+	var err error
+	if len(size) > 0 && size[0] >= 0 {
+		c.Header("Content-Length", fmt.Sprintf("%d", size[0]))
+		_, err = io.CopyN(c, stream, int64(size[0]))
+	} else {
+		_, err = io.Copy(c, stream)
+	}
+	return err
+}
+
+func (c *StdContext) File(filepath string) error {
+	http.ServeFile(c.writer, c.req, filepath)
+	c.wroteHeader = true
+	c.written, _ = strconv.ParseInt(c.writer.Header().Get("Content-Length"), 10, 64)
+	return nil
+}
+
+func (c *StdContext) FileAttachment(filepath, filename string) error {
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	return c.File(filepath)
+}
+
+// ResponseHeader 实现 fastapi.ResponseHeaderReader
+func (c *StdContext) ResponseHeader(key string) string {
+	return strings.Join(c.writer.Header().Values(key), ", ")
+}
+
+// HandshakeRequest 实现 fastapi.WebSocketUpgrader
+func (c *StdContext) HandshakeRequest() *http.Request { return c.req }
+
+// UpgradeWebSocket 实现 fastapi.WebSocketUpgrader, handler 在当前协程中执行
+func (c *StdContext) UpgradeWebSocket(handler func(conn *fastapi.WebSocketConn)) error {
+	conn, err := websocket.UpgradeHTTP(c, c.writer)
+	if err != nil {
+		return err
+	}
+	c.wroteHeader = true // 连接已被劫持
+
+	handler(conn)
+	return nil
+}
+
+// SendStreamWriter 实现 fastapi.StreamWriter, fn 在当前协程中执行, 客户端断开连接时请求的 context 被取消
+func (c *StdContext) SendStreamWriter(fn func(w io.Writer, flush func() error, closed <-chan struct{}) error) error {
+	rc := http.NewResponseController(c.writer)
+	c.flushHeader()
+	return fn(c, rc.Flush, c.req.Context().Done())
+}
+
+func (c *StdContext) Write(p []byte) (int, error) {
+	c.flushHeader()
+	n, err := c.writer.Write(p)
+	c.written += int64(n)
+	return n, err
+}
+
+// ResponseSize 实现 fastapi.ResponseSizer
+func (c *StdContext) ResponseSize() int64 { return c.written }
+
+// 写入响应状态码, 仅首次调用有效
+func (c *StdContext) flushHeader() {
+	if c.wroteHeader {
+		return
+	}
+	c.wroteHeader = true
+	if c.statusCode == 0 {
+		c.statusCode = http.StatusOK
+	}
+	c.writer.WriteHeader(c.statusCode)
+}
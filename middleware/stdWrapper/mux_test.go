@@ -0,0 +1,94 @@
+package stdWrapper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Chendemo12/fastapi"
+)
+
+func TestToServeMuxPattern(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/api/rcst/:no", want: "/api/rcst/{no}"},
+		{path: "/api/rcst/:no?", want: "/api/rcst/{no}"},
+		{path: "/api/:day/rcst", want: "/api/{day}/rcst"},
+		{path: "/api/rcst/*", want: "/api/rcst/{path...}"},
+		{path: "/api/rcst/", want: "/api/rcst/{$}"},
+		{path: "/api/rcst", want: "/api/rcst"},
+		{path: "openapi.json", want: "/openapi.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := ToServeMuxPattern(tt.path); got != tt.want {
+				t.Errorf("ToServeMuxPattern() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStdMux_BindRoute(t *testing.T) {
+	mux := Default()
+
+	err := mux.BindRoute(http.MethodGet, "/api/user/:id", func(c fastapi.MuxContext) error {
+		if c.Path() != "/api/user/:id" {
+			t.Errorf("Path() = %s, want route pattern", c.Path())
+		}
+		c.Status(http.StatusAccepted)
+		c.Header("X-Id", c.Params("id"))
+		return c.JSON(http.StatusAccepted, map[string]string{"name": c.Query("name", "none")})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type Form struct {
+		Name string `json:"name"`
+	}
+	err = mux.BindRoute(http.MethodPost, "/api/user", func(c fastapi.MuxContext) error {
+		form := &Form{}
+		if _, err := c.ShouldBind(form); err != nil {
+			return err
+		}
+		return c.SendString(form.Name)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 重复绑定
+	if err = mux.BindRoute(http.MethodPost, "/api/user", nil); err == nil {
+		t.Error("BindRoute() duplicated route should return error")
+	}
+	if err = mux.BindRoute("TRACE", "/api/user", nil); err == nil {
+		t.Error("BindRoute() unknown method should return error")
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/user/12?name=lee", nil))
+	if w.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+	if w.Header().Get("X-Id") != "12" {
+		t.Errorf("path param = %s, want 12", w.Header().Get("X-Id"))
+	}
+	if !strings.Contains(w.Body.String(), `"lee"`) {
+		t.Errorf("body = %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/user", strings.NewReader(`{"name":"jack"}`)))
+	if w.Code != http.StatusOK || w.Body.String() != "jack" {
+		t.Errorf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/user", strings.NewReader(`{"name":`)))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}
@@ -20,6 +20,65 @@ type MuxWrapper interface {
 	BindRoute(method, path string, handler MuxHandler) error
 }
 
+// WebSocketMuxWrapper MuxWrapper 的可选能力, 实现此接口的路由器才能注册 websocket 路由
+type WebSocketMuxWrapper interface {
+	// BindWebSocket 注册 websocket 路由, 握手请求为 GET 请求;
+	// 传递给 handler 的 MuxContext 必须实现 WebSocketUpgrader 接口
+	BindWebSocket(path string, handler MuxHandler) error
+}
+
+// FallbackMuxWrapper MuxWrapper 的可选能力, 实现此接口的路由器会将未匹配到路由的请求交由 Wrapper 处理,
+// 以便通过 RouteErrorFormatter 返回与路由错误一致的JSON响应; 未实现此接口时由路由器自行处理.
+// 两个方法均会在全部路由注册完成之后调用
+type FallbackMuxWrapper interface {
+	// BindNotFound 注册请求路径不存在时的处理函数
+	BindNotFound(handler MuxHandler) error
+	// BindMethodNotAllowed 注册请求路径存在但请求方法不匹配时的处理函数, 路由器需在调用 handler 之前设置 Allow 响应头
+	BindMethodNotAllowed(handler MuxHandler) error
+}
+
+// WebSocketUpgrader 通过 WebSocketMuxWrapper.BindWebSocket 注册的路由, 其 MuxContext 需实现此接口,
+// websocket 协议可通过 websocket 包实现
+type WebSocketUpgrader interface {
+	// UpgradeWebSocket 校验握手请求并升级协议, 之后将连接交由 handler 处理, handler 返回后连接将被关闭;
+	// 若握手失败则返回错误且不会调用 handler, 此时尚未写入任何响应.
+	//
+	//	注意: 对于 fiber(fasthttp) 等框架, handler 会在 MuxHandler 返回之后才在新的协程中执行,
+	//	因此 handler 中不应再访问 MuxContext
+	UpgradeWebSocket(handler func(conn *WebSocketConn)) error
+	// HandshakeRequest 握手请求的副本, 需在 UpgradeWebSocket 之前调用;
+	// 协议升级之后 Context 通过其读取请求头、查询参数和cookie, 而不再访问 MuxContext
+	HandshakeRequest() *http.Request
+}
+
+// ResponseSizer MuxContext 的可选能力, 返回已写入的响应体字节数, 用于访问日志
+type ResponseSizer interface {
+	ResponseSize() int64
+}
+
+// StreamWriter MuxContext 的可选能力, 实现此接口才能返回 SSEResponse 等需要逐条刷新的流式响应
+type StreamWriter interface {
+	// SendStreamWriter 以流的形式写入响应体, 调用 flush 会将已写入 w 的数据立刻发送给客户端, fn 返回后响应结束;
+	// closed 在客户端断开连接时关闭, 以便没有数据写入时也能及时结束; 无法察觉断开的路由器可在写入或刷新失败时关闭;
+	// 调用前状态码和响应头均已设置完毕, 若返回错误则不会调用 fn.
+	//
+	//	注意: 对于 fiber(fasthttp) 等框架, fn 会在 MuxHandler 返回之后才在新的协程中执行,
+	//	因此 fn 中不应再访问 MuxContext
+	SendStreamWriter(fn func(w io.Writer, flush func() error, closed <-chan struct{}) error) error
+}
+
+// ResponseHeaderReader MuxContext 的可选能力, 用于读取已设置的响应头, 存在多个值时以逗号分隔;
+// 实现此接口后 CORS 会将 Vary 合并到已有的值中, 否则直接覆盖
+type ResponseHeaderReader interface {
+	ResponseHeader(key string) string
+}
+
+// QueryArrayReader MuxContext 的可选能力, 用于读取重复的查询参数, 例如: ?id=1&id=2;
+// 未实现此接口时, 数组查询参数仅能读取到第一个值
+type QueryArrayReader interface {
+	QueryArray(key string) []string // 读取查询参数的全部值, 不存在则返回空
+}
+
 // MuxContext Web引擎的 Context，例如 fiber.Ctx, gin.Context
 // 此接口定义的方法无需全部实现
 //
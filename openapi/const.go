@@ -7,12 +7,23 @@ const ApiVersion = "3.1.0"
 var (
 	ValidateTagName    = "validate"
 	QueryTagName       = "query"
+	HeaderTagName      = "header"
+	CookieTagName      = "cookie"
+	StyleTagName       = "style"
+	ExplodeTagName     = "explode"
 	JsonTagName        = "json"
 	DescriptionTagName = "description"
 	DefaultValueTagNam = "default"
 	ParamRequiredLabel = requiredTag
 )
 
+// 数组查询参数的序列化方式, 参见 https://swagger.io/docs/specification/serialization/
+const (
+	StyleForm           = "form"           // ?id=1&id=2 (explode=true) 或 ?id=1,2 (explode=false)
+	StyleSpaceDelimited = "spaceDelimited" // ?id=1%202
+	StylePipeDelimited  = "pipeDelimited"  // ?id=1|2
+)
+
 type DataType string
 
 func (m DataType) IsBaseType() bool {
@@ -60,6 +71,9 @@ const (
 // RouteMethodSeparator 路由分隔符，用于分割路由方法和路径
 const RouteMethodSeparator = "=|_0#0_|="
 
+// WebsocketMethod websocket 路由的方法名, 其握手请求为 GET, 在文档中也显示为 GET
+const WebsocketMethod = "WS"
+
 // 用于swagger的一些静态文件，来自FastApi
 const (
 	SwaggerCssName    = "swagger-ui.css"
@@ -70,6 +84,7 @@ const (
 	JsonUrl           = "openapi.json"
 	DocumentUrl       = "/docs"
 	ReDocumentUrl     = "/redoc"
+	Oauth2RedirectUrl = DocumentUrl + "/oauth2-redirect"
 	SwaggerFaviconUrl = "https://fastapi.tiangolo.com/img/" + FaviconName
 	SwaggerCssUrl     = "https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/" + SwaggerCssName
 	SwaggerJsUrl      = "https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/" + SwaggerJsName
@@ -147,6 +162,7 @@ const (
 	MIMETextJavaScript             ContentType = "text/javascript"
 	MIMEApplicationForm            ContentType = "application/x-www-form-urlencoded"
 	MIMEOctetStream                ContentType = "application/octet-stream"
+	MIMETextEventStream            ContentType = "text/event-stream"
 	MIMEMultipartForm              ContentType = "multipart/form-data"
 	MIMETextXML                    ContentType = "text/xml"
 	MIMETextXMLCharsetUTF8         ContentType = "text/xml; charset=utf-8"
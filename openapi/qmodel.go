@@ -1,7 +1,9 @@
 package openapi
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
 	"unicode"
 
 	"github.com/Chendemo12/fastapi/utils"
@@ -18,8 +20,16 @@ type QModel struct {
 	Kind     reflect.Kind      `json:"Kind,omitempty" description:"反射类型"`
 	Required bool              `json:"required,omitempty" description:"是否必须"`
 	InPath   bool              `json:"in_path,omitempty" description:"是否是路径参数"`
+	InHeader bool              `json:"in_header,omitempty" description:"是否是请求头参数"`
+	InCookie bool              `json:"in_cookie,omitempty" description:"是否是cookie参数"`
 	InStruct bool              `json:"in_struct,omitempty" description:"是否是结构体字段参数"`
 	IsTime   bool              `json:"is_time,omitempty" description:"是否是时间类型"`
+	IsArray  bool              `json:"is_array,omitempty" description:"是否是数组类型"`
+	ElemKind reflect.Kind      `json:"elem_kind,omitempty" description:"数组元素的反射类型"`
+	Style    string            `json:"style,omitempty" description:"数组参数的序列化方式"`
+	Explode  bool              `json:"explode,omitempty" description:"数组参数是否以重复的参数名传递"`
+	Default  string            `json:"default,omitempty" description:"默认值, 未经类型转换"`
+	Index    []int             `json:"index,omitempty" description:"结构体字段的索引序列, 嵌入结构体的字段包含多级索引"`
 }
 
 // Init 解析并缓存字段名
@@ -31,7 +41,18 @@ func (q *QModel) Init() (err error) {
 	}
 	// 解析并缓存字段名
 	q.Desc = utils.QueryFieldTag(q.Tag, DescriptionTagName, q.Name)
-	q.QName = utils.QueryFieldTag(q.Tag, QueryTagName, utils.QueryFieldTag(q.Tag, JsonTagName, q.SchemaTitle()))
+	switch {
+	case q.InHeader:
+		q.QName = utils.QueryFieldTag(q.Tag, HeaderTagName, q.SchemaTitle())
+	case q.InCookie:
+		q.QName = utils.QueryFieldTag(q.Tag, CookieTagName, q.SchemaTitle())
+	default:
+		q.QName = utils.QueryFieldTag(q.Tag, QueryTagName, utils.QueryFieldTag(q.Tag, JsonTagName, q.SchemaTitle()))
+	}
+
+	if !q.InPath { // 路径参数总是存在的, 默认值无意义
+		q.Default = utils.QueryFieldTag(q.Tag, DefaultValueTagNam, "")
+	}
 
 	if q.InStruct {
 		q.JName = q.QName
@@ -39,17 +60,102 @@ func (q *QModel) Init() (err error) {
 		q.JName = utils.QueryFieldTag(q.Tag, JsonTagName, q.SchemaTitle())
 	}
 
+	if q.IsArray {
+		err = q.scanArrayStyle()
+	}
+
 	return
 }
 
+// 解析数组参数的序列化方式, 默认为 form 且 explode=true, 即: ?id=1&id=2
+func (q *QModel) scanArrayStyle() error {
+	if q.In() != InQuery {
+		return fmt.Errorf("param '%s' is an array, only query param support array", q.Name)
+	}
+
+	q.Style = utils.QueryFieldTag(q.Tag, StyleTagName, StyleForm)
+	switch q.Style {
+	case StyleForm:
+		q.Explode = utils.QueryFieldTag(q.Tag, ExplodeTagName, "true") != "false"
+	case StyleSpaceDelimited, StylePipeDelimited:
+		q.Explode = false // 分隔符形式仅支持 explode=false
+	default:
+		return fmt.Errorf("param '%s' style '%s' is not supported", q.Name, q.Style)
+	}
+
+	return nil
+}
+
+// Delimiter 数组参数的分隔符, 仅在 explode=false 时有效
+func (q *QModel) Delimiter() string {
+	switch q.Style {
+	case StyleSpaceDelimited:
+		return " "
+	case StylePipeDelimited:
+		return "|"
+	default:
+		return ","
+	}
+}
+
+// HasDefault 是否声明了默认值
+func (q *QModel) HasDefault() bool { return q.Default != "" }
+
+// DefaultParam 以请求参数的形式返回默认值, 不存在默认值时为nil;
+// 对于数组参数按照分隔符拆分为 []string, 其他参数为 string
+func (q *QModel) DefaultParam() any {
+	if !q.HasDefault() {
+		return nil
+	}
+	if q.IsArray {
+		return strings.Split(q.Default, q.Delimiter())
+	}
+	return q.Default
+}
+
+// DefaultValue 文档中显示的默认值, 按照数据类型转换
+func (q *QModel) DefaultValue() any {
+	if !q.HasDefault() {
+		return nil
+	}
+	if q.IsArray {
+		items := strings.Split(q.Default, q.Delimiter())
+		values := make([]any, len(items))
+		for i, item := range items {
+			values[i] = parseDefaultV(item, q.ElemType())
+		}
+		return values
+	}
+	return parseDefaultV(q.Default, q.SchemaType())
+}
+
+// ElemType 数组元素的数据类型
+func (q *QModel) ElemType() DataType { return ReflectKindToType(q.ElemKind) }
+
 func (q *QModel) SchemaTitle() string { return q.Name }
 
 func (q *QModel) SchemaPkg() string { return q.Name }
 
 // JsonName 对于查询参数结构体，其文档名称 tag 默认为 query
 // query -> json -> fieldName
+//
+// 对于请求头和cookie参数, 则分别为 header 和 cookie 标签名
 func (q *QModel) JsonName() string { return q.JName }
 
+// In 参数位置
+func (q *QModel) In() ParameterInType {
+	switch {
+	case q.InPath:
+		return InPath
+	case q.InHeader:
+		return InHeader
+	case q.InCookie:
+		return InCookie
+	default:
+		return InQuery
+	}
+}
+
 // SchemaDesc 结构体文档注释
 func (q *QModel) SchemaDesc() string { return q.Desc }
 
@@ -76,14 +182,10 @@ func (q *QModel) Schema() (m map[string]any) {
 	m["schema"] = dict{
 		"title":   q.SchemaTitle(),
 		"type":    q.SchemaType(),
-		"default": GetDefaultV(q.Tag, q.SchemaType()),
+		"default": q.DefaultValue(),
 	}
 	m["name"] = q.SchemaPkg()
-	if q.InPath {
-		m["in"] = "path"
-	} else {
-		m["in"] = "query"
-	}
+	m["in"] = q.In()
 	if q.IsTime {
 		m["format"] = "date-time"
 	}
@@ -98,7 +200,18 @@ func (q *QModel) InnerSchema() []SchemaIface {
 }
 
 // StructToQModels 将一个结构体的每一个导出字段都转换为一个查询参数
-// 对于结构体字段，仅支持基本的数据类型和time.Time类型，不支持数组类型和自定义结构体类型
+// 对于结构体字段，仅支持基本的数据类型、基本数据类型的切片和time.Time类型，不支持自定义结构体类型
+//
+// 对于切片字段, 可通过 style 和 explode 标签指定序列化方式, 例如:
+//
+//	Ids  []int    `query:"id"`                          // ?id=1&id=2
+//	Tags []string `query:"tags" explode:"false"`        // ?tags=a,b
+//	Keys []string `query:"keys" style:"pipeDelimited"`  // ?keys=a|b
+//
+// 具有 header 或 cookie 标签的字段则分别作为请求头参数和cookie参数, 例如:
+//
+//	TenantId string `header:"X-Tenant-Id" validate:"required"`
+//	Session  string `cookie:"session"`
 func StructToQModels(rt reflect.Type) []*QModel {
 	if rt.Kind() == reflect.Ptr {
 		rt = rt.Elem() // 上浮指针
@@ -111,10 +224,10 @@ func StructToQModels(rt reflect.Type) []*QModel {
 	// 当此model作为查询参数时，此struct的每一个字段都将作为一个查询参数
 	// 对于字段类型，仅支持基本类型和time类型，不能为结构体类型
 
-	return extractQModelField(rt)
+	return extractQModelField(rt, nil)
 }
 
-func extractQModelField(rt reflect.Type) []*QModel {
+func extractQModelField(rt reflect.Type, parent []int) []*QModel {
 	qms := make([]*QModel, 0)
 	for i := 0; i < rt.NumField(); i++ {
 		field := rt.Field(i)
@@ -122,17 +235,34 @@ func extractQModelField(rt reflect.Type) []*QModel {
 		if unicode.IsLower(rune(field.Name[0])) {
 			continue
 		}
+		index := append(append([]int{}, parent...), i)
 
 		// Future-231203.8: 模型支持嵌入
 		if field.Anonymous && field.Type.Kind() == reflect.Struct { // 不支持嵌入结构体指针类型
-			qms = append(qms, extractQModelField(field.Type)...)
+			qms = append(qms, extractQModelField(field.Type, index)...)
 		}
 
 		// 此结构体的任意字段有且仅支持 基本数据类型
 		dataType := ReflectKindToType(field.Type.Kind())
 		switch dataType {
 		case ArrayType:
-			// 不支持数组类型的查询参数
+			// 数组类型的查询参数仅支持基本数据类型的切片, 例如: ?id=1&id=2 或 ?tags=a,b
+			elem := field.Type.Elem()
+			if field.Type.Kind() == reflect.Slice && elem.Kind() != reflect.Uint8 && ReflectKindToType(elem.Kind()).IsBaseType() {
+				qms = append(qms, &QModel{
+					Name:     field.Name,
+					DataType: ArrayType,
+					Tag:      field.Tag,
+					Kind:     field.Type.Kind(),
+					InPath:   false,
+					InStruct: true,
+					IsArray:  true,
+					ElemKind: elem.Kind(),
+					InHeader: utils.QueryFieldTag(field.Tag, HeaderTagName, "") != "",
+					InCookie: utils.QueryFieldTag(field.Tag, CookieTagName, "") != "",
+					Index:    index,
+				})
+			}
 		case ObjectType: // 结构体仅支持 time.Time
 			if field.Type.String() == TimePkg {
 				qms = append(qms, &QModel{
@@ -143,6 +273,9 @@ func extractQModelField(rt reflect.Type) []*QModel {
 					InPath:   false,
 					InStruct: true,
 					IsTime:   true,
+					InHeader: utils.QueryFieldTag(field.Tag, HeaderTagName, "") != "",
+					InCookie: utils.QueryFieldTag(field.Tag, CookieTagName, "") != "",
+					Index:    index,
 				})
 			}
 		default:
@@ -154,6 +287,9 @@ func extractQModelField(rt reflect.Type) []*QModel {
 				InPath:   false,
 				InStruct: true,
 				IsTime:   false,
+				InHeader: utils.QueryFieldTag(field.Tag, HeaderTagName, "") != "",
+				InCookie: utils.QueryFieldTag(field.Tag, CookieTagName, "") != "",
+				Index:    index,
 			})
 		}
 	}
@@ -19,6 +19,8 @@ type RouteParamType string
 const (
 	RouteParamQuery    RouteParamType = "query"
 	RouteParamPath     RouteParamType = "path"
+	RouteParamHeader   RouteParamType = "header"
+	RouteParamCookie   RouteParamType = "cookie"
 	RouteParamRequest  RouteParamType = "requestBody"
 	RouteParamResponse RouteParamType = "responseBody"
 )
@@ -35,6 +37,7 @@ type RouteSwagger struct {
 	RequestModel        *BaseModelMeta `description:"请求体元数据"`
 	ResponseModel       *BaseModelMeta `description:"响应体元数据"`
 	RequestFile         bool           `json:"-" description:"是否存在文件"`
+	ResponseEventStream bool           `json:"-" description:"是否是服务端推送事件, 此时 ResponseModel 为事件数据的模型"`
 	Summary             string         `json:"summary" description:"摘要描述"`
 	Url                 string         `json:"url" description:"完整请求路由"`
 	Description         string         `json:"description" description:"详细描述"`
@@ -47,18 +50,23 @@ type RouteSwagger struct {
 	PathFields          []*QModel      `json:"-" description:"路径参数"`
 	QueryFields         []*QModel      `json:"-" description:"查询参数"`
 	Deprecated          bool           `json:"deprecated" description:"是否禁用"`
+	Dependencies        []string       `json:"-" description:"路由组及路由方法的依赖函数名称, 显示在文档的详细描述中"`
+	Security            []string       `json:"-" description:"认证方案名称, 满足其一即可"`
+	Scopes              []string       `json:"-" description:"所需的权限范围或角色, 需全部满足"`
+	Responses           []*Response    `json:"-" description:"除200和422外的其他响应, 例如限流时的429"`
 }
 
 func (r *RouteSwagger) Init() (err error) {
 	// 由于查询参数和请求体需要从方法入参中提取, 以及响应体需要从方法出参中提取,因此在上层进行解析
-	if r.ResponseModel == nil { // 返回值不允许为nil, 此处错误为上层忘记初始化模型参数
+	// 返回值不允许为nil, 此处错误为上层忘记初始化模型参数; websocket 路由没有响应体
+	if r.ResponseModel == nil && !r.IsWebSocket() {
 		return errors.New("ResponseModel is not init")
 	}
 
+	// 请求体可以为nil; 路径参数的类型声明会在 scanPath 中从 Url 中移除, 因此需在其后生成标识
+	err = r.Scan()
 	r.Api = CreateRouteIdentify(r.Method, r.Url)
 
-	// 请求体可以为nil
-	err = r.Scan()
 	return
 }
 
@@ -108,7 +116,12 @@ func (r *RouteSwagger) ScanInner() (err error) {
 	}
 
 	// 推断响应体类型
-	if r.ResponseModel.Param.IsFile {
+	if r.ResponseModel == nil { // websocket
+		return
+	}
+	if r.ResponseEventStream {
+		r.ResponseContentType = MIMETextEventStream
+	} else if r.ResponseModel.Param.IsFile {
 		r.ResponseContentType = MIMEOctetStream
 	} else {
 		switch r.ResponseModel.Param.SchemaType() {
@@ -125,7 +138,12 @@ func (r *RouteSwagger) ScanInner() (err error) {
 func (r *RouteSwagger) scanPath() (err error) {
 	// 提取路由中的路径参数
 	// 通过结构体方法名称确定路由的方式无法包含路径参数的, 但是如果定义了重载方法 GroupRouter.Path() 则可以包含路径参数
-	for _, p := range strings.Split(r.Url, pathschema.PathSeparator) {
+	segments := strings.Split(r.Url, pathschema.PathSeparator)
+	defer func() { // 移除路径参数的类型声明
+		r.Url = strings.Join(segments, pathschema.PathSeparator)
+	}()
+
+	for i, p := range segments {
 		if p == "" {
 			continue
 		}
@@ -146,7 +164,17 @@ func (r *RouteSwagger) scanPath() (err error) {
 		}
 
 		if strings.HasPrefix(p, pathschema.PathParamPrefix) {
-			// 识别到路径参数
+			// 识别到路径参数, 允许声明类型: /api/user/:id<int>
+			p, typ := pathschema.SplitPathParamType(p)
+			if typ != "" {
+				kind, ok := PathParamKinds[typ]
+				if !ok {
+					return fmt.Errorf("route '%s' path parameter type '%s' is not supported", r.Url, typ)
+				}
+				qm.Kind = kind
+				qm.DataType = ReflectKindToType(kind)
+				segments[i] = p
+			}
 			qm.InPath = true
 			qm.Name = p[1:]
 			// 通过Tag标识这是一个必须的参数: `json:"eventType" validate:"required"`
@@ -163,6 +191,9 @@ func (r *RouteSwagger) scanPath() (err error) {
 
 func (r *RouteSwagger) Id() string { return r.Api }
 
+// IsWebSocket 是否是 websocket 路由
+func (r *RouteSwagger) IsWebSocket() bool { return r.Method == WebsocketMethod }
+
 // RouteParam 路由参数的原始类型信息（由反射获得）
 // 具体包含查询参数,路径参数,请求体参数和响应体参数
 type RouteParam struct {
@@ -382,6 +413,25 @@ func ToFastApiRoutePath(path string) string {
 	return strings.Join(paths, pathschema.PathSeparator)
 }
 
+// PathParamKinds 路径参数支持的类型声明, 未声明类型的路径参数为字符串类型
+var PathParamKinds = map[string]reflect.Kind{
+	"string":  reflect.String,
+	"bool":    reflect.Bool,
+	"int":     reflect.Int,
+	"int8":    reflect.Int8,
+	"int16":   reflect.Int16,
+	"int32":   reflect.Int32,
+	"int64":   reflect.Int64,
+	"uint":    reflect.Uint,
+	"uint8":   reflect.Uint8,
+	"uint16":  reflect.Uint16,
+	"uint32":  reflect.Uint32,
+	"uint64":  reflect.Uint64,
+	"float":   reflect.Float64,
+	"float32": reflect.Float32,
+	"float64": reflect.Float64,
+}
+
 // ReflectKindToType 转换reflect.Kind为swagger类型说明
 //
 //	@param	ReflectKind	reflect.Kind	反射类型,不进一步对指针类型进行上浮
@@ -411,8 +461,11 @@ func ReflectKindToType(kind reflect.Kind) (name DataType) {
 func IsFieldRequired(tag reflect.StructTag) bool {
 	bindings := strings.Split(utils.QueryFieldTag(tag, ValidateTagName, ""), ",") // binding 存在多个值
 	for i := 0; i < len(bindings); i++ {
-		if strings.TrimSpace(bindings[i]) == ParamRequiredLabel {
+		switch strings.TrimSpace(bindings[i]) {
+		case ParamRequiredLabel:
 			return true
+		case diveTag: // dive 之后的约束作用于数组元素
+			return false
 		}
 	}
 
@@ -426,19 +479,25 @@ func GetDefaultV(tag reflect.StructTag, otype DataType) (v any) {
 	if defaultV == "" {
 		v = nil
 	} else { // 存在默认值
-		switch otype {
+		v = parseDefaultV(defaultV, otype)
+	}
+	return
+}
 
-		case StringType:
-			v = defaultV
-		case IntegerType:
-			v, _ = strconv.Atoi(defaultV)
-		case NumberType:
-			v, _ = strconv.ParseFloat(defaultV, 64)
-		case BoolType:
-			v, _ = strconv.ParseBool(defaultV)
-		default:
-			v = defaultV
-		}
+// 按照数据类型转换默认值, 转换失败时为零值
+func parseDefaultV(defaultV string, otype DataType) (v any) {
+	switch otype {
+
+	case StringType:
+		v = defaultV
+	case IntegerType:
+		v, _ = strconv.Atoi(defaultV)
+	case NumberType:
+		v, _ = strconv.ParseFloat(defaultV, 64)
+	case BoolType:
+		v, _ = strconv.ParseBool(defaultV)
+	default:
+		v = defaultV
 	}
 	return
 }
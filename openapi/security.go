@@ -0,0 +1,45 @@
+package openapi
+
+// SecuritySchemeType 认证方案类型
+type SecuritySchemeType string
+
+const (
+	SecurityTypeHTTP   SecuritySchemeType = "http"   // HTTP 认证, 通过 Scheme 区分 bearer 和 basic
+	SecurityTypeApiKey SecuritySchemeType = "apiKey" // API Key, 可位于请求头、查询参数或cookie中
+	SecurityTypeOAuth2 SecuritySchemeType = "oauth2" // OAuth2, 目前仅支持 password 模式
+)
+
+// SecurityScheme 认证方案文档, 显示在 components.securitySchemes 内部
+// 无需重写序列化方法
+type SecurityScheme struct {
+	Type         SecuritySchemeType `json:"type" description:"认证方案类型"`
+	Description  string             `json:"description,omitempty" description:"说明"`
+	Name         string             `json:"name,omitempty" description:"参数名称, 仅 apiKey"`
+	In           ParameterInType    `json:"in,omitempty" description:"参数位置, 仅 apiKey"`
+	Scheme       string             `json:"scheme,omitempty" description:"认证方式, 仅 http, 例如 bearer, basic"`
+	BearerFormat string             `json:"bearerFormat,omitempty" description:"令牌格式, 仅 http bearer, 例如 JWT"`
+	Flows        *OAuthFlows        `json:"flows,omitempty" description:"授权流程, 仅 oauth2"`
+}
+
+// OAuthFlows OAuth2 授权流程
+type OAuthFlows struct {
+	Password *OAuthFlow `json:"password,omitempty" description:"密码模式"`
+}
+
+// OAuthFlow OAuth2 授权流程的配置
+type OAuthFlow struct {
+	TokenUrl string            `json:"tokenUrl" description:"获取令牌的地址"`
+	Scopes   map[string]string `json:"scopes" description:"可用的权限范围, 范围:说明"`
+}
+
+// SecurityRequirement 认证要求, 认证方案名称:权限范围;
+// 对于一个路由, 满足其中任意一个认证要求即可
+type SecurityRequirement map[string][]string
+
+// AddSecurityScheme 添加一个认证方案, 可通过名称在 SecurityRequirement 中引用
+func (c *Components) AddSecurityScheme(name string, scheme *SecurityScheme) {
+	if c.SecuritySchemes == nil {
+		c.SecuritySchemes = make(map[string]*SecurityScheme)
+	}
+	c.SecuritySchemes[name] = scheme
+}
@@ -2,6 +2,7 @@ package openapi
 
 import (
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/Chendemo12/fastapi/utils"
@@ -74,6 +75,35 @@ func (o *OpenApi) AddContact(info Contact) *OpenApi {
 	return o
 }
 
+// AddErrorResponse 添加一个可被引用的公共错误响应, 例如404和405;
+// 若通过 SetRouteErrorResponse 设置了错误响应体, 则以其作为响应体模型, 反之为 HTTPError
+func (o *OpenApi) AddErrorResponse(name string, statusCode int) *OpenApi {
+	o.AddDefinition(errorResponseSchema())
+	o.Components.AddResponse(name, NewErrorResponse(statusCode))
+
+	return o
+}
+
+// NewErrorResponse 创建一个错误响应, 响应体模型与 AddErrorResponse 相同
+func NewErrorResponse(statusCode int) *Response {
+	return &Response{
+		StatusCode:  statusCode,
+		Description: http.StatusText(statusCode),
+		Content: &PathModelContent{
+			MIMEType: MIMEApplicationJSONCharsetUTF8,
+			Schema:   errorResponseSchema(),
+		},
+	}
+}
+
+// 错误响应体模型, 若通过 SetRouteErrorResponse 设置了错误响应体, 则以其作为响应体模型, 反之为 HTTPError
+func errorResponseSchema() SchemaIface {
+	if routeErrorOption.ResponseMode != nil {
+		return routeErrorOption.ResponseMode
+	}
+	return &HTTPError{}
+}
+
 // AddDefinition 手动添加一个模型文档
 func (o *OpenApi) AddDefinition(meta SchemaIface) *OpenApi {
 	o.Components.AddModel(meta)
@@ -151,6 +181,14 @@ func (o *OpenApi) modelFrom(swagger *RouteSwagger) {
 	if routeErrorOption.ResponseMode != nil {
 		o.AddDefinition(routeErrorOption.ResponseMode)
 	}
+	for _, resp := range swagger.Responses {
+		if resp.Content == nil {
+			continue
+		}
+		if schema, ok := resp.Content.Schema.(SchemaIface); ok {
+			o.AddDefinition(schema)
+		}
+	}
 }
 
 func (o *OpenApi) pathFrom(swagger *RouteSwagger) {
@@ -184,13 +222,22 @@ func (o *OpenApi) pathFrom(swagger *RouteSwagger) {
 		Parameters:  append(pathParams, queryParams...),
 		Deprecated:  swagger.Deprecated,
 	}
-	if utils.Has[string]([]string{http.MethodGet, http.MethodDelete}, swagger.Method) {
-		// GET/DELETE 无请求体，不显示
+	// 在详细描述中列出路由的依赖函数
+	if len(swagger.Dependencies) > 0 {
+		operation.Description += "\n\nDependencies: `" + strings.Join(swagger.Dependencies, "`, `") + "`"
+	}
+	// 满足任意一个认证方案即可, 且需具有全部权限范围
+	for _, name := range swagger.Security {
+		operation.Security = append(operation.Security, SecurityRequirement{name: append([]string{}, swagger.Scopes...)})
+	}
+	if utils.Has[string]([]string{http.MethodGet, http.MethodDelete, WebsocketMethod}, swagger.Method) {
+		// GET/DELETE/WS 无请求体，不显示
 		operation.RequestBody = nil
 	} else {
 		operation.RequestBodyFrom(swagger)
 	}
 	operation.ResponseFrom(swagger)
+	operation.Responses = append(operation.Responses, swagger.Responses...)
 
 	// 绑定到操作方法
 	switch swagger.Method {
@@ -208,7 +255,7 @@ func (o *OpenApi) pathFrom(swagger *RouteSwagger) {
 	case http.MethodTrace:
 		item.Trace = operation
 
-	default:
+	default: // GET, websocket
 		item.Get = operation
 	}
 }
@@ -280,7 +327,9 @@ type ComponentScheme struct {
 // Components openapi 的模型部分
 // 需要重写序列化方法
 type Components struct {
-	Scheme []*ComponentScheme `json:"scheme" description:"模型文档"`
+	Scheme          []*ComponentScheme         `json:"scheme" description:"模型文档"`
+	Responses       map[string]*Response       `json:"responses" description:"公共响应, 名称:响应"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes" description:"认证方案, 名称:认证方案"`
 }
 
 // MarshalJSON 重载序列化方法
@@ -294,7 +343,22 @@ func (c *Components) MarshalJSON() ([]byte, error) {
 	m[ValidationErrorDefinition.SchemaPkg()] = ValidationErrorDefinition.Schema()
 	m[ValidationErrorResponseDefinition.SchemaPkg()] = ValidationErrorResponseDefinition.Schema()
 
-	return json.Marshal(map[string]any{"schemas": m})
+	components := map[string]any{"schemas": m}
+	if len(c.Responses) > 0 {
+		components["responses"] = c.Responses
+	}
+	if len(c.SecuritySchemes) > 0 {
+		components["securitySchemes"] = c.SecuritySchemes
+	}
+	return json.Marshal(components)
+}
+
+// AddResponse 添加一个公共响应, 可通过 #/components/responses/{name} 引用
+func (c *Components) AddResponse(name string, resp *Response) {
+	if c.Responses == nil {
+		c.Responses = make(map[string]*Response)
+	}
+	c.Responses[name] = resp
 }
 
 // AddModel 添加一个模型文档
@@ -324,15 +388,18 @@ type ParameterBase struct {
 }
 
 type ParameterSchema struct {
-	Type   DataType `json:"type" description:"数据类型"`
-	Title  string   `json:"title"`
-	Format string   `json:"format,omitempty" description:"针对特殊类型的格式化参数"`
+	Items  *ParameterSchema `json:"items,omitempty" description:"数组元素模型"`
+	Type   DataType         `json:"type" description:"数据类型"`
+	Title  string           `json:"title,omitempty"`
+	Format string           `json:"format,omitempty" description:"针对特殊类型的格式化参数"`
 }
 
 // Parameter 路径参数或者查询参数
 type Parameter struct {
 	Default any              `json:"default,omitempty" description:"默认值"`
 	Schema  *ParameterSchema `json:"schema,omitempty" description:"字段模型"`
+	Explode *bool            `json:"explode,omitempty" description:"数组参数是否以重复的参数名传递"`
+	Style   string           `json:"style,omitempty" description:"数组参数的序列化方式"`
 	ParameterBase
 }
 
@@ -340,7 +407,7 @@ func (p *Parameter) FromQModel(model *QModel) *Parameter {
 	p.Name = model.JsonName()
 	p.Description = model.SchemaDesc()
 	p.Required = model.IsRequired()
-	p.Default = GetDefaultV(model.Tag, model.SchemaType())
+	p.Default = model.DefaultValue()
 	p.Schema = &ParameterSchema{
 		Type:  model.SchemaType(),
 		Title: model.SchemaTitle(),
@@ -349,13 +416,15 @@ func (p *Parameter) FromQModel(model *QModel) *Parameter {
 		p.Schema.Type = StringType
 		p.Schema.Format = DateTimeParamSchemaFormat
 	}
-
-	if model.InPath {
-		p.In = InPath
-	} else {
-		p.In = InQuery
+	if model.IsArray { // 数组类型
+		p.Schema.Type = ArrayType
+		p.Schema.Items = &ParameterSchema{Type: model.ElemType()}
+		p.Style = model.Style
+		p.Explode = &model.Explode
 	}
 
+	p.In = model.In()
+
 	return p
 }
 
@@ -403,9 +472,25 @@ func (p *PathModelContent) MarshalJSON() ([]byte, error) {
 
 // Response 路由返回体，包含了返回状态码，状态码说明和返回值模型
 type Response struct {
-	Content     *PathModelContent `json:"content" description:"返回值模型"`
+	Content     *PathModelContent          `json:"content,omitempty" description:"返回值模型"`
+	Headers     map[string]*ResponseHeader `json:"headers,omitempty" description:"响应头, 名称:响应头"`
+	Description string                     `json:"description,omitempty" description:"说明"`
+	StatusCode  int                        `json:"-" description:"状态码"`
+}
+
+// ResponseHeader 响应头文档
+type ResponseHeader struct {
 	Description string            `json:"description,omitempty" description:"说明"`
-	StatusCode  int               `json:"-" description:"状态码"`
+	Schema      map[string]string `json:"schema" description:"数据类型"`
+}
+
+// AddHeader 添加一个响应头文档
+func (r *Response) AddHeader(name, description string, dataType DataType) *Response {
+	if r.Headers == nil {
+		r.Headers = make(map[string]*ResponseHeader)
+	}
+	r.Headers[name] = &ResponseHeader{Description: description, Schema: map[string]string{"type": string(dataType)}}
+	return r
 }
 
 // Operation 路由HTTP方法: Get/Post/Patch/Delete 等操作方法
@@ -419,8 +504,9 @@ type Operation struct {
 	// 请求体，通过 MakeOperationRequestBody 构建
 	RequestBody *RequestBody `json:"requestBody,omitempty" description:"请求体"`
 	// 响应文档，对于任一个路由，均包含2个固定的响应实例：200 + 422 和一个可选的 RouteErrorFormatter 响应实例， 通过函数 ResponseFrom 构建
-	Responses  []*Response `json:"responses,omitempty" description:"响应体"`
-	Deprecated bool        `json:"deprecated,omitempty" description:"是否禁用"`
+	Responses  []*Response           `json:"responses,omitempty" description:"响应体"`
+	Deprecated bool                  `json:"deprecated,omitempty" description:"是否禁用"`
+	Security   []SecurityRequirement `json:"security,omitempty" description:"认证要求, 满足其一即可"`
 }
 
 // MarshalJSON 重写序列化方法，修改 Responses 和 RequestBody 字段
@@ -438,6 +524,7 @@ func (o *Operation) MarshalJSON() ([]byte, error) {
 	orm.Parameters = o.Parameters
 	orm.RequestBody = o.RequestBody // TODO:
 	orm.Deprecated = o.Deprecated
+	orm.Security = o.Security
 
 	orm.Responses = make(map[int]*Response)
 	for _, r := range o.Responses {
@@ -479,6 +566,10 @@ func (o *Operation) RequestBodyFrom(swagger *RouteSwagger) *Operation {
 // ResponseFrom 从 *openapi.BaseModelMeta 转换成 openapi 的响应实例
 func (o *Operation) ResponseFrom(swagger *RouteSwagger) *Operation {
 	m := make([]*Response, 0) // 200 + 422
+	if swagger.IsWebSocket() {
+		return o.webSocketResponseFrom()
+	}
+
 	// 200 接口处注册的返回值
 	m200 := &Response{
 		StatusCode:  http.StatusOK,
@@ -521,6 +612,36 @@ func (o *Operation) ResponseFrom(swagger *RouteSwagger) *Operation {
 	return o
 }
 
+// websocket 路由没有响应体, 握手成功时返回101, 失败时则与其他路由一致
+func (o *Operation) webSocketResponseFrom() *Operation {
+	m101 := &Response{
+		StatusCode:  http.StatusSwitchingProtocols,
+		Description: http.StatusText(http.StatusSwitchingProtocols),
+	}
+	m422 := &Response{
+		StatusCode:  http.StatusUnprocessableEntity,
+		Description: http.StatusText(http.StatusUnprocessableEntity),
+		Content: &PathModelContent{
+			MIMEType: MIMEApplicationJSONCharsetUTF8,
+			Schema:   &ValidationError{},
+		},
+	}
+	o.Responses = []*Response{m101, m422}
+
+	if routeErrorOption.ResponseMode != nil {
+		o.Responses = append(o.Responses, &Response{
+			StatusCode:  routeErrorOption.StatusCode,
+			Description: routeErrorOption.Description,
+			Content: &PathModelContent{
+				MIMEType: MIMEApplicationJSONCharsetUTF8,
+				Schema:   routeErrorOption.ResponseMode,
+			},
+		})
+	}
+
+	return o
+}
+
 // PathItem 路由选项，由于同一个路由可以存在不同的操作方法，因此此选项可以存在多个 Operation
 type PathItem struct {
 	Get    *Operation `json:"get,omitempty" description:"GET方法"`
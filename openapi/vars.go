@@ -10,6 +10,7 @@ import (
 const (
 	ValidationErrorName     string = "ValidationError"
 	HttpValidationErrorName string = "HTTPValidationError"
+	HttpErrorName           string = "HTTPError"
 )
 
 // ValidationErrorDefinition 422 表单验证错误模型
@@ -130,6 +131,50 @@ func (v *HTTPValidationError) Error() string {
 
 func (v *HTTPValidationError) String() string { return v.Error() }
 
+// HTTPError 通用的HTTP错误, 例如404和405, 默认的错误处理函数会以 StatusCode 作为响应状态码
+type HTTPError struct {
+	Detail     string `json:"detail" description:"错误信息"`
+	StatusCode int    `json:"-" description:"状态码"`
+}
+
+// NewHTTPError 创建一个HTTP错误, detail 为空时使用状态码的描述
+func NewHTTPError(statusCode int, detail ...string) *HTTPError {
+	e := &HTTPError{StatusCode: statusCode, Detail: http.StatusText(statusCode)}
+	if len(detail) > 0 && detail[0] != "" {
+		e.Detail = detail[0]
+	}
+	return e
+}
+
+func (v *HTTPError) SchemaPkg() string { return InnerModelNamePrefix + HttpErrorName }
+
+func (v *HTTPError) SchemaTitle() string { return HttpErrorName }
+
+func (v *HTTPError) JsonName() string { return v.SchemaTitle() }
+
+func (v *HTTPError) SchemaType() DataType { return ObjectType }
+
+func (v *HTTPError) SchemaDesc() string { return "HTTP错误" }
+
+func (v *HTTPError) IsRequired() bool { return true }
+
+func (v *HTTPError) Schema() map[string]any {
+	return dict{
+		"title":      HttpErrorName,
+		"type":       ObjectType,
+		"required":   []string{"detail"},
+		"properties": dict{"detail": dict{"title": "Detail", "type": "string"}},
+	}
+}
+
+// InnerSchema 内部字段模型文档
+func (v *HTTPError) InnerSchema() []SchemaIface {
+	m := make([]SchemaIface, 0)
+	return m
+}
+
+func (v *HTTPError) Error() string { return v.Detail }
+
 // SetRouteErrorResponse 设置路由文档错误响应的响应体
 func SetRouteErrorResponse(model any) {
 	if model == nil {
@@ -12,6 +12,8 @@ const (
 	PathParamPrefix          = ":" // 路径参数起始字符
 	PathSeparator            = "/" // 路径分隔符
 	OptionalQueryParamPrefix = "?" // 查询参数起始字符,也是路径参数结束字符
+	PathParamTypeStart       = "<" // 路径参数类型声明起始字符, 例如: /api/user/:id<int>
+	PathParamTypeEnd         = ">" // 路径参数类型声明结束字符
 )
 
 var rule = regexp.MustCompile(`[A-Z][a-z]*`)
@@ -254,6 +256,21 @@ func Format(prefix string, relativePath string, schema RoutePathSchema) string {
 	return full + strings.Join(spans, schema.Connector())
 }
 
+// SplitPathParamType 提取路径参数段中的类型声明, 返回去除类型声明后的路径段和类型,
+// 例如: ":id<int>" => (":id", "int"), ":day<uint>?" => (":day?", "uint"); 无类型声明时类型为空字符串
+func SplitPathParamType(segment string) (string, string) {
+	if !strings.HasPrefix(segment, PathParamPrefix) {
+		return segment, ""
+	}
+	start := strings.Index(segment, PathParamTypeStart)
+	end := strings.Index(segment, PathParamTypeEnd)
+	if start < 0 || end < start {
+		return segment, ""
+	}
+
+	return segment[:start] + segment[end+1:], segment[start+1 : end]
+}
+
 // SplitWords 将字符串s按照单词进行切分, 判断单词的依据为：是否首字母大写
 // 如果输入s无法切分，则返回只有s构成的一个元素的数组
 // 如果输入s包含数字，下划线等，则其包含在前面的单词结尾
@@ -748,3 +748,25 @@ func TestFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitPathParamType(t *testing.T) {
+	tests := []struct {
+		segment  string
+		want     string
+		wantType string
+	}{
+		{segment: ":id<int>", want: ":id", wantType: "int"},
+		{segment: ":day<uint8>?", want: ":day?", wantType: "uint8"},
+		{segment: ":id", want: ":id", wantType: ""},
+		{segment: "user<int>", want: "user<int>", wantType: ""},
+		{segment: ":id>int<", want: ":id>int<", wantType: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.segment, func(t *testing.T) {
+			got, typ := SplitPathParamType(tt.segment)
+			if got != tt.want || typ != tt.wantType {
+				t.Errorf("SplitPathParamType() = (%v, %v), want (%v, %v)", got, typ, tt.want, tt.wantType)
+			}
+		})
+	}
+}
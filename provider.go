@@ -0,0 +1,203 @@
+package fastapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// 已注册的提供者, 提供的类型:提供者
+var providers sync.Map
+
+var (
+	contextType = reflect.TypeOf(&Context{})
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Provide 注册一个依赖注入的提供者, 等同于 python-FastApi 中以返回值作为路由入参的 Depends
+//
+// 提供者的签名为 func(c *Context, args ...) (T, error), 注册后路由方法可直接声明类型为 T 的入参, 由 Wrapper 在调用路由前注入:
+//
+//	fastapi.Provide(func(c *fastapi.Context, q *TokenQuery) (*User, error) { ... })
+//
+//	func (r *UserRouter) GetMe(c *fastapi.Context, user *User) (*User, error) { return user, nil }
+//
+// 提供者除 Context 外的入参可以是:
+//
+//  1. 其他提供者提供的类型, 此时提供者之间存在依赖关系, 会按照依赖顺序调用, 不允许循环依赖;
+//  2. 结构体(指针), 其字段按照结构体查询参数的规则解析为查询参数或请求头参数, 并显示在路由的文档中;
+//
+// 同一个请求中, 每一个提供者至多被调用一次, 其返回值会被缓存并注入到全部依赖它的入参中;
+// 提供者在路由组依赖函数之后, 路由函数之前执行, 返回错误时将按照 RouteErrorFormatter 返回;
+// 提供者必须在 Wrapper 启动之前注册, 每一种类型只能注册一个提供者, 签名不符合要求或重复注册时 panic
+func Provide(fc any) {
+	p, err := newProvider(fc)
+	if err != nil {
+		panic(fmt.Sprintf("provider: %s", err))
+	}
+	if v, loaded := providers.LoadOrStore(p.out, p); loaded {
+		panic(fmt.Sprintf("provider: '%s' already provided by '%s'", p.out.String(), v.(*provider).name))
+	}
+}
+
+// 查找类型的提供者
+func lookupProvider(rt reflect.Type) (*provider, bool) {
+	v, ok := providers.Load(rt)
+	if !ok {
+		return nil, false
+	}
+	return v.(*provider), true
+}
+
+// 依赖注入的提供者
+type provider struct {
+	fn   reflect.Value
+	name string         // 函数名称, 用于错误提示和文档
+	out  reflect.Type   // 提供的类型
+	args []reflect.Type // 除 Context 外的入参类型
+}
+
+func newProvider(fc any) (*provider, error) {
+	rv := reflect.ValueOf(fc)
+	if rv.Kind() != reflect.Func || rv.IsNil() {
+		return nil, fmt.Errorf("'%T' is not a function", fc)
+	}
+
+	rt := rv.Type()
+	p := &provider{fn: rv, name: funcName(rv)}
+	if rt.NumIn() < 1 || rt.In(0) != contextType || rt.NumOut() != OutParamNum || rt.Out(LastOutParamOffset) != errorType {
+		return nil, fmt.Errorf("'%s' should be func(c *fastapi.Context, args ...) (T, error)", p.name)
+	}
+	p.out = rt.Out(FirstOutParamOffset)
+	if p.out == contextType || p.out == errorType {
+		return nil, fmt.Errorf("'%s' cannot provide '%s'", p.name, p.out.String())
+	}
+
+	for i := 1; i < rt.NumIn(); i++ {
+		p.args = append(p.args, rt.In(i))
+	}
+
+	return p, nil
+}
+
+// 提供者的一次调用
+type provideStep struct {
+	p       *provider
+	queries []int // 与 provider.args 一一对应, 参数结构体在 Context.queryStructs 中的索引, -1 表示其他提供者提供的类型
+}
+
+// 调用提供者, 并将返回值缓存在 Context 内
+func (s *provideStep) call(c *Context) error {
+	in := make([]reflect.Value, len(s.p.args)+1)
+	in[0] = reflect.ValueOf(c)
+	for i, rt := range s.p.args {
+		if s.queries[i] < 0 {
+			in[i+1] = c.provided[rt]
+			continue
+		}
+		v := reflect.ValueOf(c.queryStructs[s.queries[i]])
+		if rt.Kind() != reflect.Pointer {
+			v = v.Elem()
+		}
+		in[i+1] = v
+	}
+
+	out := s.p.fn.Call(in)
+	if last := out[LastOutParamOffset]; !last.IsNil() {
+		return last.Interface().(error)
+	}
+	c.provided[s.p.out] = out[FirstOutParamOffset]
+
+	return nil
+}
+
+// 路由函数的依赖注入计划, 在路由创建时解析
+type providePlan struct {
+	steps   []*provideStep // 按照依赖顺序排列
+	queries []reflect.Type // 提供者声明的参数结构体, 已去除指针
+}
+
+// 解析提供者之间的依赖关系, offset 为参数结构体在 Context.queryStructs 中的起始索引
+func newProvidePlan(types []reflect.Type, offset int) (*providePlan, error) {
+	plan := &providePlan{}
+	index := map[reflect.Type]int{} // 参数结构体:在 Context.queryStructs 中的索引
+	states := map[*provider]int{}   // 1: 解析中, 2: 已解析
+
+	var visit func(p *provider, chain []string) error
+	visit = func(p *provider, chain []string) error {
+		chain = append(chain, p.out.String())
+		switch states[p] {
+		case 1:
+			return fmt.Errorf("provider: circular dependency: %s", strings.Join(chain, " -> "))
+		case 2:
+			return nil
+		}
+
+		states[p] = 1
+		step := &provideStep{p: p, queries: make([]int, len(p.args))}
+		for i, arg := range p.args {
+			if dep, ok := lookupProvider(arg); ok {
+				if err := visit(dep, chain); err != nil {
+					return err
+				}
+				step.queries[i] = -1
+				continue
+			}
+
+			rt := structElem(arg)
+			if rt.Kind() != reflect.Struct {
+				return fmt.Errorf("provider: '%s' the %d param '%s' is neither provided nor a struct", p.name, i+1, arg.String())
+			}
+			if _, err := PrepareStructQueryPlan(rt); err != nil {
+				return fmt.Errorf("provider: '%s' %w", p.name, err)
+			}
+			if _, ok := index[rt]; !ok {
+				index[rt] = offset + len(plan.queries)
+				plan.queries = append(plan.queries, rt)
+			}
+			step.queries[i] = index[rt]
+		}
+		states[p] = 2
+		plan.steps = append(plan.steps, step)
+
+		return nil
+	}
+
+	for _, rt := range types {
+		p, ok := lookupProvider(rt)
+		if !ok {
+			return nil, fmt.Errorf("provider: '%s' is not provided", rt.String())
+		}
+		if err := visit(p, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return plan, nil
+}
+
+// 依次调用提供者, 同一个请求中每一个提供者至多调用一次
+func (plan *providePlan) call(c *Context) error {
+	if c.provided == nil {
+		c.provided = make(map[reflect.Type]reflect.Value, len(plan.steps))
+	}
+	for _, step := range plan.steps {
+		if _, ok := c.provided[step.p.out]; ok {
+			continue
+		}
+		if err := step.call(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 去除指针
+func structElem(rt reflect.Type) reflect.Type {
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	return rt
+}
@@ -0,0 +1,365 @@
+package fastapi
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Chendemo12/fastapi/openapi"
+)
+
+// 结构体查询参数的绑定计划缓存, reflect.Type: *StructQueryPlan, 无法创建计划的类型为nil
+var structQueryPlans sync.Map
+
+// PrepareStructQueryPlan 创建并缓存结构体的绑定计划, 路由注册时调用, 用于在启动时发现不支持的字段类型和不合法的默认值
+func PrepareStructQueryPlan(rt reflect.Type) (*StructQueryPlan, error) {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if v, ok := structQueryPlans.Load(rt); ok && v.(*StructQueryPlan) != nil {
+		return v.(*StructQueryPlan), nil
+	}
+
+	plan, err := NewStructQueryPlan(rt)
+	if err != nil {
+		return nil, err
+	}
+	structQueryPlans.Store(rt, plan)
+
+	return plan, nil
+}
+
+// 获取 obj 对应的绑定计划, 不存在则创建, obj 必须是结构体指针, 无法创建时返回nil
+func loadStructQueryPlan(obj any) *StructQueryPlan {
+	rt := reflect.TypeOf(obj)
+	if rt == nil || rt.Kind() != reflect.Ptr {
+		return nil
+	}
+	if v, ok := structQueryPlans.Load(rt.Elem()); ok {
+		return v.(*StructQueryPlan)
+	}
+
+	plan, err := NewStructQueryPlan(rt.Elem())
+	if err != nil {
+		plan = nil
+	}
+	structQueryPlans.Store(rt.Elem(), plan)
+
+	return plan
+}
+
+// StructQueryPlan 结构体查询参数的绑定计划, 在启动时按照结构体类型预先计算每一个字段的索引、解析函数、参数名称和默认值,
+// 绑定时按照索引直接写入结构体字段, 无需经过JSON序列化和反序列化
+type StructQueryPlan struct {
+	rt     reflect.Type
+	fields []*queryFieldPlan
+}
+
+// 结构体查询参数中单个字段的绑定计划
+type queryFieldPlan struct {
+	key      string                  // 参数名称, 即 Context.queryFields 中的键
+	in       openapi.ParameterInType // 参数位置
+	dataType openapi.DataType        // 用于错误提示
+	index    []int                   // 字段的索引序列, 用于 reflect.Value.FieldByIndex
+	set      queryFieldSetter        // 解析并写入字段
+	value    any                     // 已校验的默认值, 保留请求参数的形式(string 或 []string)以避免切片在请求间共享, 不存在则为nil
+}
+
+// 将参数值转换为字段类型并写入 field, 参数值可以是原始字符串, 也可以是经过 ModelBinder 转换后的值
+type queryFieldSetter func(field reflect.Value, v any) error
+
+// NewStructQueryPlan 为结构体类型创建绑定计划, 仅支持 openapi.StructToQModels 所能识别的字段,
+// 对于不支持的字段类型或不合法的默认值返回错误
+func NewStructQueryPlan(rt reflect.Type) (*StructQueryPlan, error) {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("'%s' is not a struct", rt.String())
+	}
+
+	plan := &StructQueryPlan{rt: rt}
+	for _, qm := range openapi.StructToQModels(rt) {
+		if err := qm.Init(); err != nil {
+			return nil, err
+		}
+
+		field := rt.FieldByIndex(qm.Index)
+		set := newQueryFieldSetter(field.Type)
+		if set == nil {
+			return nil, fmt.Errorf("field '%s.%s' type '%s' is not supported as query param", rt.String(), field.Name, field.Type.String())
+		}
+
+		fp := &queryFieldPlan{key: qm.JsonName(), in: qm.In(), dataType: qm.SchemaType(), index: qm.Index, set: set}
+		if qm.HasDefault() { // 写入一个临时的字段实例以校验默认值
+			if err := set(reflect.New(field.Type).Elem(), qm.DefaultParam()); err != nil {
+				return nil, fmt.Errorf("field '%s.%s' default value '%s' is invalid: %s", rt.String(), field.Name, qm.Default, err.Error())
+			}
+			fp.value = qm.DefaultParam()
+		}
+		plan.fields = append(plan.fields, fp)
+	}
+
+	return plan, nil
+}
+
+// Type 绑定计划对应的结构体类型
+func (p *StructQueryPlan) Type() reflect.Type { return p.rt }
+
+// Bind 将参数值写入结构体, obj 必须是指向计划对应结构体的指针; 参数不存在时写入默认值(如果存在)
+func (p *StructQueryPlan) Bind(params map[string]any, obj any) *openapi.ValidationError {
+	rv := reflect.ValueOf(obj)
+	if rv.Kind() != reflect.Ptr || rv.Type().Elem() != p.rt || rv.IsNil() {
+		return &openapi.ValidationError{
+			Loc:  []string{string(openapi.RouteParamQuery)},
+			Msg:  fmt.Sprintf("'%T' is not a pointer to '%s'", obj, p.rt.String()),
+			Type: string(openapi.ObjectType),
+			Ctx:  whereServerError,
+		}
+	}
+
+	rv = rv.Elem()
+	for _, fp := range p.fields {
+		field := rv.FieldByIndex(fp.index)
+		v, ok := params[fp.key]
+		if !ok {
+			if fp.value != nil {
+				_ = fp.set(field, fp.value)
+			}
+			continue
+		}
+
+		if err := fp.set(field, v); err != nil {
+			return &openapi.ValidationError{
+				Loc:  []string{string(fp.in), fp.key},
+				Msg:  err.Error(),
+				Type: string(fp.dataType),
+				Ctx:  whereClientError,
+			}
+		}
+	}
+
+	return nil
+}
+
+// 按照字段类型创建写入函数, 对于自定义类型按照其底层类型写入
+func newQueryFieldSetter(rt reflect.Type) queryFieldSetter {
+	if rt.String() == openapi.TimePkg {
+		return setTime
+	}
+
+	switch rt.Kind() {
+	case reflect.Slice:
+		return newSliceSetter(rt.Elem())
+	default:
+		return newScalarSetter(rt.Kind())
+	}
+}
+
+// 切片字段按照元素类型逐个转换, 参数值可以是 []any 或 []string; []byte 等不支持
+func newSliceSetter(elem reflect.Type) queryFieldSetter {
+	if elem.Kind() == reflect.Uint8 {
+		return nil
+	}
+	set := newScalarSetter(elem.Kind())
+	if set == nil {
+		return nil
+	}
+
+	return func(field reflect.Value, v any) error {
+		var values []any
+		switch value := v.(type) {
+		case []any:
+			values = value
+		case []string:
+			values = make([]any, len(value))
+			for i := range value {
+				values[i] = value[i]
+			}
+		default: // 单个值视为只有一个元素的数组
+			values = []any{v}
+		}
+
+		result := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i := range values {
+			if err := set(result.Index(i), values[i]); err != nil {
+				return err
+			}
+		}
+		field.Set(result)
+		return nil
+	}
+}
+
+func newScalarSetter(kind reflect.Kind) queryFieldSetter {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return setInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return setUint
+	case reflect.Float32, reflect.Float64:
+		return setFloat
+	case reflect.Bool:
+		return setBool
+	case reflect.String:
+		return setString
+	default:
+		return nil
+	}
+}
+
+func setInt(field reflect.Value, v any) error {
+	var n int64
+	switch value := v.(type) {
+	case int64:
+		n = value
+	case int:
+		n = int64(value)
+	case int8:
+		n = int64(value)
+	case int16:
+		n = int64(value)
+	case int32:
+		n = int64(value)
+	case uint64:
+		if value > math.MaxInt64 {
+			return fmt.Errorf("value: %d out of range", value)
+		}
+		n = int64(value)
+	case float64:
+		if value != math.Trunc(value) {
+			return fmt.Errorf("value: %v is not an integer", value)
+		}
+		n = int64(value)
+	case string:
+		result, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("value: '%s' is not an integer", value)
+		}
+		n = result
+	default:
+		return fmt.Errorf("value: '%v' is not an integer", v)
+	}
+
+	if field.OverflowInt(n) {
+		return fmt.Errorf("value: %d out of range", n)
+	}
+	field.SetInt(n)
+	return nil
+}
+
+func setUint(field reflect.Value, v any) error {
+	var n uint64
+	switch value := v.(type) {
+	case uint64:
+		n = value
+	case uint:
+		n = uint64(value)
+	case uint8:
+		n = uint64(value)
+	case uint16:
+		n = uint64(value)
+	case uint32:
+		n = uint64(value)
+	case int64:
+		if value < 0 {
+			return fmt.Errorf("value: %d out of range", value)
+		}
+		n = uint64(value)
+	case int:
+		if value < 0 {
+			return fmt.Errorf("value: %d out of range", value)
+		}
+		n = uint64(value)
+	case float64:
+		if value < 0 || value != math.Trunc(value) {
+			return fmt.Errorf("value: %v is not an unsigned integer", value)
+		}
+		n = uint64(value)
+	case string:
+		result, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("value: '%s' is not an unsigned integer", value)
+		}
+		n = result
+	default:
+		return fmt.Errorf("value: '%v' is not an unsigned integer", v)
+	}
+
+	if field.OverflowUint(n) {
+		return fmt.Errorf("value: %d out of range", n)
+	}
+	field.SetUint(n)
+	return nil
+}
+
+func setFloat(field reflect.Value, v any) error {
+	var f float64
+	switch value := v.(type) {
+	case float64:
+		f = value
+	case float32:
+		f = float64(value)
+	case int64:
+		f = float64(value)
+	case int:
+		f = float64(value)
+	case uint64:
+		f = float64(value)
+	case string:
+		result, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("value: '%s' is not a number", value)
+		}
+		f = result
+	default:
+		return fmt.Errorf("value: '%v' is not a number", v)
+	}
+
+	field.SetFloat(f)
+	return nil
+}
+
+func setBool(field reflect.Value, v any) error {
+	switch value := v.(type) {
+	case bool:
+		field.SetBool(value)
+	case string:
+		result, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("value: '%s' is not a boolean", value)
+		}
+		field.SetBool(result)
+	default:
+		return fmt.Errorf("value: '%v' is not a boolean", v)
+	}
+	return nil
+}
+
+func setString(field reflect.Value, v any) error {
+	value, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("value: '%v' is not a string", v)
+	}
+	field.SetString(value)
+	return nil
+}
+
+func setTime(field reflect.Value, v any) error {
+	switch value := v.(type) {
+	case time.Time:
+		field.Set(reflect.ValueOf(value))
+	case string:
+		result, ves := (&DateTimeModelBinder{}).Validate(nil, value)
+		if len(ves) > 0 {
+			return fmt.Errorf("value: '%s' is not a datetime", value)
+		}
+		field.Set(reflect.ValueOf(result.(time.Time)))
+	default:
+		return fmt.Errorf("value: '%v' is not a datetime", v)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package fastapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type Level int8
+
+type BaseQuery struct {
+	Page int `query:"page" default:"1"`
+}
+
+type PlanQuery struct {
+	BaseQuery
+	Name    string    `query:"name"`
+	Level   Level     `query:"level"`
+	Size    uint16    `query:"size"`
+	Score   float32   `query:"score"`
+	Active  bool      `query:"active"`
+	Since   time.Time `query:"since"`
+	Ids     []int64   `query:"id"`
+	Tags    []string  `query:"tags" default:"a,b" explode:"false"`
+	Tenant  string    `header:"X-Tenant-Id"`
+	ignored string
+}
+
+func TestStructQueryPlan_Bind(t *testing.T) {
+	plan, err := NewStructQueryPlan(reflect.TypeOf(&PlanQuery{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		params  map[string]any
+		want    *PlanQuery
+		wantLoc []string
+	}{
+		{
+			name: "converted",
+			params: map[string]any{
+				"page": int64(2), "name": "lee", "level": int64(-3), "size": uint64(20), "score": 1.5,
+				"active": true, "since": since, "id": []any{int64(1), int64(2)}, "tags": []any{"x"}, "X-Tenant-Id": "t1",
+			},
+			want: &PlanQuery{
+				BaseQuery: BaseQuery{Page: 2}, Name: "lee", Level: -3, Size: 20, Score: 1.5,
+				Active: true, Since: since, Ids: []int64{1, 2}, Tags: []string{"x"}, Tenant: "t1",
+			},
+		},
+		{
+			name:   "raw-and-default",
+			params: map[string]any{"level": "7", "size": 3, "active": "true", "since": "2024-01-02 03:04:05", "id": []string{"5"}},
+			want: &PlanQuery{
+				BaseQuery: BaseQuery{Page: 1}, Level: 7, Size: 3, Active: true, Since: since,
+				Ids: []int64{5}, Tags: []string{"a", "b"},
+			},
+		},
+		{name: "overflow", params: map[string]any{"level": int64(200)}, wantLoc: []string{"query", "level"}},
+		{name: "negative-uint", params: map[string]any{"size": "-1"}, wantLoc: []string{"query", "size"}},
+		{name: "elem-type", params: map[string]any{"id": []string{"1", "x"}}, wantLoc: []string{"query", "id"}},
+		{name: "header-type", params: map[string]any{"X-Tenant-Id": 1}, wantLoc: []string{"header", "X-Tenant-Id"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &PlanQuery{}
+			ve := plan.Bind(tt.params, got)
+			if tt.wantLoc != nil {
+				if ve == nil || !reflect.DeepEqual(ve.Loc, tt.wantLoc) {
+					t.Errorf("Bind() error = %v, want loc %v", ve, tt.wantLoc)
+				}
+				return
+			}
+			if ve != nil {
+				t.Fatalf("Bind() error = %v", ve)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Bind() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("type-mismatch", func(t *testing.T) {
+		if ve := plan.Bind(map[string]any{}, &Person{}); ve == nil {
+			t.Error("Bind() should return error for mismatched type")
+		}
+	})
+}
+
+func TestNewStructQueryPlan_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  any
+	}{
+		{name: "not-struct", obj: new(int)},
+		{name: "complex", obj: &struct {
+			C complex64 `query:"c"`
+		}{}},
+		{name: "bad-default", obj: &struct {
+			Page uint8 `query:"page" default:"-1"`
+		}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewStructQueryPlan(reflect.TypeOf(tt.obj)); err == nil {
+				t.Error("NewStructQueryPlan() should return error")
+			}
+		})
+	}
+}
+
+type benchQuery struct {
+	Page    int      `query:"page" validate:"gte=1"`
+	Size    int      `query:"size" validate:"lte=100"`
+	Keyword string   `query:"keyword" validate:"required"`
+	Active  bool     `query:"active"`
+	Ids     []int64  `query:"id"`
+	Tenant  string   `header:"X-Tenant-Id"`
+	Tags    []string `query:"tags"`
+}
+
+type benchRouter struct {
+	BaseGroupRouter
+}
+
+func (r *benchRouter) GetList(c *Context, q *benchQuery) (int, error) { return 0, nil }
+
+// 对比按照绑定计划写入与JSON序列化再反序列化两种绑定方式
+func BenchmarkStructQueryValidate(b *testing.B) {
+	LazyInit()
+	meta := NewGroupRouteMeta(&benchRouter{}, nil)
+	if err := meta.Init(); err != nil {
+		b.Fatal(err)
+	}
+	route := meta.Routes()[0]
+	c := &Context{queryFields: map[string]any{
+		"page": int64(1), "size": int64(20), "keyword": "go", "active": true,
+		"id": []any{int64(1), int64(2)}, "X-Tenant-Id": "t1", "tags": []any{"a", "b"},
+	}}
+
+	b.Run("plan", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if ves := structQueryValidate(c, route, false); len(ves) > 0 {
+				b.Fatal(ves[0])
+			}
+		}
+	})
+
+	b.Run("json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c.queryStructs = route.NewStructQueries()
+			// JSON 方式下请求头参数需转换为字段名称
+			values := map[string]any{}
+			for k, v := range c.queryFields {
+				values[k] = v
+			}
+			values["Tenant"] = values["X-Tenant-Id"]
+			if ve := structQueryBind.unmarshalJson(values, c.queryStructs[0]); ve != nil {
+				b.Fatal(ve)
+			}
+			if ves := structQueryBind.Validate(c.queryStructs[0]); len(ves) > 0 {
+				b.Fatal(ves[0])
+			}
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package fastapi
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Chendemo12/fastapi/openapi"
+)
+
+// 限流响应头
+const (
+	HeaderRetryAfter         = "Retry-After"
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+)
+
+// RateLimitKeyFunc 限流的客户端标识, 同一个标识共享同一份额度
+type RateLimitKeyFunc func(c *Context) string
+
+// RateLimitByIP 以客户端IP作为标识
+func RateLimitByIP(c *Context) string { return c.muxCtx.ClientIP() }
+
+// RateLimitByHeader 以请求头作为标识, 请求头不存在时以客户端IP作为标识
+func RateLimitByHeader(name string) RateLimitKeyFunc {
+	return func(c *Context) string {
+		if v := c.muxCtx.GetHeader(name); v != "" {
+			return name + ":" + v
+		}
+		return RateLimitByIP(c)
+	}
+}
+
+// RateLimitByPrincipal 以认证通过后的凭证作为标识, 未认证时以客户端IP作为标识;
+// 需通过 NewPrincipalRateLimiter 创建限流器才能在认证之后执行, 由 NewRateLimiter 创建的限流器均在认证之前执行
+func RateLimitByPrincipal(c *Context) string {
+	if credentials := c.Credentials(); credentials != nil {
+		if credentials.Username != "" {
+			return credentials.Scheme + ":" + credentials.Username
+		}
+		return credentials.Scheme + ":" + credentials.Token
+	}
+	return RateLimitByIP(c)
+}
+
+// GroupRateLimit GroupRouter 的可选接口, 为路由组内的全部路由设置限流器
+type GroupRateLimit interface {
+	RateLimit() *RateLimiter
+}
+
+// RouteRateLimit GroupRouter 的可选接口, 为单个路由方法设置限流器, 方法名:限流器, 会覆盖 GroupRateLimit 的设置;
+// 限流器为 nil 时, 此路由不限流
+type RouteRateLimit interface {
+	RouteRateLimit() map[string]*RateLimiter
+}
+
+// RateLimiter 基于令牌桶的进程内限流器, 每一个客户端标识拥有独立的令牌桶;
+// 同一个限流器实例作用于多个路由时, 这些路由共享同一份额度, 需要按路由限流时应为每一个路由创建独立的实例
+//
+// 超出限制时返回 429, 并添加 Retry-After 响应头; 每一个经过限流器的响应均携带 RateLimit-Limit/Remaining/Reset 响应头
+type RateLimiter struct {
+	limit     int
+	window    time.Duration
+	rate      float64 // 每秒恢复的令牌数
+	key       RateLimitKeyFunc
+	afterAuth bool // 在认证和鉴权之后执行
+	now       func() time.Time
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	swept     time.Time // 上一次清理空闲令牌桶的时间
+}
+
+// NewRateLimiter 创建一个限流器, 在 window 时间内至多允许 limit 个请求, 且允许 limit 个请求的突发;
+// key 为客户端标识, 默认为 RateLimitByIP; 作为 GroupRateLimit/RouteRateLimit 时在认证之前执行
+func NewRateLimiter(limit int, window time.Duration, key ...RateLimitKeyFunc) *RateLimiter {
+	if limit < 1 || window <= 0 {
+		panic("rate limiter: limit and window should be positive")
+	}
+	r := &RateLimiter{
+		limit:   limit,
+		window:  window,
+		rate:    float64(limit) / window.Seconds(),
+		key:     RateLimitByIP,
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+	if len(key) > 0 && key[0] != nil {
+		r.key = key[0]
+	}
+	return r
+}
+
+// NewPrincipalRateLimiter 创建一个以 RateLimitByPrincipal 为标识的限流器, 作为 GroupRateLimit/RouteRateLimit 时在认证和鉴权之后执行,
+// 因此同一凭证的请求共享同一份额度, 而认证失败的请求不消耗额度
+func NewPrincipalRateLimiter(limit int, window time.Duration) *RateLimiter {
+	r := NewRateLimiter(limit, window, RateLimitByPrincipal)
+	r.afterAuth = true
+	return r
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// 取出一个令牌, 返回剩余令牌数, 额度完全恢复所需的时间, 以及被拒绝时需要等待的时间
+func (r *RateLimiter) take(key string) (remaining int, reset, retryAfter time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.sweep(now)
+
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(r.limit), last: now}
+		r.buckets[key] = b
+	} else {
+		b.tokens = math.Min(float64(r.limit), b.tokens+now.Sub(b.last).Seconds()*r.rate)
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		retryAfter = r.duration(1 - b.tokens)
+	} else {
+		b.tokens--
+	}
+	reset = r.duration(float64(r.limit) - b.tokens)
+
+	return int(b.tokens), reset, retryAfter
+}
+
+// 恢复指定数量的令牌所需的时间
+func (r *RateLimiter) duration(tokens float64) time.Duration {
+	return time.Duration(math.Round(tokens / r.rate * float64(time.Second)))
+}
+
+// 每个窗口清理一次已完全恢复的令牌桶, 避免客户端标识无限增长
+func (r *RateLimiter) sweep(now time.Time) {
+	if now.Sub(r.swept) < r.window {
+		return
+	}
+	r.swept = now
+	for key, b := range r.buckets {
+		if now.Sub(b.last) >= r.window {
+			delete(r.buckets, key)
+		}
+	}
+}
+
+// Dependence 限流依赖函数, 可用于 Wrapper.UseAfter 或路由组依赖函数, 此时文档中不会显示429响应
+func (r *RateLimiter) Dependence() DependenceHandle {
+	return func(c *Context) error {
+		remaining, reset, retryAfter := r.take(r.key(c))
+
+		c.muxCtx.Header(HeaderRateLimitLimit, strconv.Itoa(r.limit))
+		c.muxCtx.Header(HeaderRateLimitRemaining, strconv.Itoa(remaining))
+		c.muxCtx.Header(HeaderRateLimitReset, ceilSeconds(reset))
+		if retryAfter > 0 {
+			c.muxCtx.Header(HeaderRetryAfter, ceilSeconds(retryAfter))
+			return NewHTTPError(http.StatusTooManyRequests)
+		}
+		return nil
+	}
+}
+
+// 429 响应文档
+func (r *RateLimiter) response() *openapi.Response {
+	return openapi.NewErrorResponse(http.StatusTooManyRequests).
+		AddHeader(HeaderRetryAfter, "需要等待的秒数", openapi.IntegerType).
+		AddHeader(HeaderRateLimitLimit, "窗口内允许的请求数", openapi.IntegerType).
+		AddHeader(HeaderRateLimitRemaining, "剩余的请求数", openapi.IntegerType).
+		AddHeader(HeaderRateLimitReset, "额度完全恢复所需的秒数", openapi.IntegerType)
+}
+
+// 向上取整的秒数
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
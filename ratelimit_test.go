@@ -0,0 +1,67 @@
+package fastapi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Take(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	r := NewRateLimiter(2, 10*time.Second)
+	r.now = func() time.Time { return now }
+
+	steps := []struct {
+		elapsed        time.Duration
+		key            string
+		wantRemaining  int
+		wantReset      time.Duration
+		wantRetryAfter time.Duration
+	}{
+		{key: "a", wantRemaining: 1, wantReset: 5 * time.Second},
+		{key: "a", wantRemaining: 0, wantReset: 10 * time.Second},
+		{key: "a", wantRemaining: 0, wantReset: 10 * time.Second, wantRetryAfter: 5 * time.Second},
+		{key: "b", wantRemaining: 1, wantReset: 5 * time.Second}, // 不同客户端独立计数
+		{elapsed: 2 * time.Second, key: "a", wantRemaining: 0, wantReset: 8 * time.Second, wantRetryAfter: 3 * time.Second},
+		{elapsed: 3 * time.Second, key: "a", wantRemaining: 0, wantReset: 10 * time.Second},
+		{elapsed: 20 * time.Second, key: "a", wantRemaining: 1, wantReset: 5 * time.Second}, // 恢复后不超过上限
+	}
+	for i, step := range steps {
+		now = now.Add(step.elapsed)
+		remaining, reset, retryAfter := r.take(step.key)
+		if remaining != step.wantRemaining || reset != step.wantReset || retryAfter != step.wantRetryAfter {
+			t.Errorf("step %d: take() = (%d, %s, %s), want (%d, %s, %s)", i, remaining, reset, retryAfter,
+				step.wantRemaining, step.wantReset, step.wantRetryAfter)
+		}
+	}
+}
+
+func TestRateLimiter_Sweep(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	r := NewRateLimiter(1, time.Second)
+	r.now = func() time.Time { return now }
+
+	r.take("a")
+	r.take("b")
+	now = now.Add(time.Second)
+	r.take("c")
+	if _, ok := r.buckets["a"]; ok || len(r.buckets) != 1 {
+		t.Errorf("buckets = %v, want only the active one", r.buckets)
+	}
+}
+
+func TestCeilSeconds(t *testing.T) {
+	for d, want := range map[time.Duration]string{0: "0", time.Millisecond: "1", time.Second: "1", 1500 * time.Millisecond: "2"} {
+		if got := ceilSeconds(d); got != want {
+			t.Errorf("ceilSeconds(%s) = %s, want %s", d, got, want)
+		}
+	}
+}
+
+func TestNewPrincipalRateLimiter(t *testing.T) {
+	if r := NewRateLimiter(1, time.Second, RateLimitByPrincipal); r.afterAuth {
+		t.Error("NewRateLimiter() should run before authentication")
+	}
+	if r := NewPrincipalRateLimiter(1, time.Second); !r.afterAuth || r.limit != 1 {
+		t.Errorf("NewPrincipalRateLimiter() = %+v", r)
+	}
+}
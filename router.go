@@ -6,6 +6,7 @@ import (
 	"reflect"
 
 	"github.com/Chendemo12/fastapi/openapi"
+	"github.com/Chendemo12/fastapi/utils"
 )
 
 type RouteType string
@@ -48,15 +49,19 @@ type RouteIface interface {
 	Id() string
 	RouteType() RouteType
 	Swagger() *openapi.RouteSwagger           // 路由文档
+	PathBinders() []ModelBinder               // 路径参数的处理接口, 与 Swagger().PathFields 一一对应, 用于转换声明了类型的路径参数
 	QueryBinders() []ModelBinder              // 查询参数的处理接口(查询参数名:处理接口)，每一个查询参数都必须绑定一个 ParamBinder
+	QueryDefaults() []any                     // 查询参数的默认值, 与 Swagger().QueryFields 一一对应, 已在启动时完成类型转换, 未声明默认值则为nil
 	RequestBinders() ModelBinder              // 请求体的处理接口,请求体也只有一个,内部已处理文件+表单
 	ResponseBinder() ModelBinder              // 响应体的处理接口,响应体只有一个
 	NewInParams(ctx *Context) []reflect.Value // 创建一个完整的函数入参实例列表, 此方法会在完成请求参数校验之后执行
-	NewStructQuery() any                      // 创建一个结构体查询参数实例,对于POST/PATCH/PUT, 即为 NewInParams 的最后一个元素; 对于GET/DELETE则为nil
+	NewStructQueries() []any                  // 创建结构体查询参数实例, 按照函数入参的顺序排列, 结构体查询参数可以存在多个, 但不包含请求体
 	NewRequestModel() any                     // 创建一个请求体实例,对于POST/PATCH/PUT, 即为 NewInParams 的最后一个元素; 对于GET/DELETE则为nil
-	HasStructQuery() bool                     // 是否存在结构体查询参数，如果存在则会调用 NewStructQuery 获得结构体实例
+	HasStructQuery() bool                     // 是否存在结构体查询参数，如果存在则会调用 NewStructQueries 获得结构体实例
 	HasFileRequest() bool                     // 是否存在上传文件
 	Call(in []reflect.Value) []reflect.Value  // 调用API
+	Dependencies() []DependenceHandle         // 路由组及路由方法的依赖函数, 在 UseAfter 依赖函数之后执行
+	Provide(ctx *Context) error               // 调用依赖注入的提供者, 在 Dependencies 之后, NewInParams 之前执行
 }
 
 // BaseModel 基本数据模型, 对于上层的路由定义其请求体和响应体都应为继承此结构体的结构体
@@ -194,9 +199,10 @@ func (s ScanHelper) InferResponseBinder(model *openapi.BaseModelMeta, routeType
 		return binder
 	}
 
-	// 对于非struct类型,函数签名就已经保证了类型的正确性,无需手动校验
+	// 对于非struct类型,函数签名就已经保证了类型的正确性,无需手动校验;
+	// 对于不包含任何校验规则的struct, 同样无需校验
 	if !model.SchemaType().IsBaseType() {
-		if !model.Param.IsFile {
+		if !model.Param.IsFile && requireValidate(model.Param.Prototype, map[reflect.Type]bool{}) {
 			binder = s.InferParamBinder(model.Param, model.Param.ElemKind(), openapi.RouteParamResponse)
 		}
 	}
@@ -204,18 +210,105 @@ func (s ScanHelper) InferResponseBinder(model *openapi.BaseModelMeta, routeType
 	return binder
 }
 
+// 判断类型中是否存在需要校验的字段(含有 validate 标签), 会递归检查嵌套的结构体、指针和数组元素
+func requireValidate(rt reflect.Type, visited map[reflect.Type]bool) bool {
+	for rt.Kind() == reflect.Ptr || rt.Kind() == reflect.Slice || rt.Kind() == reflect.Array || rt.Kind() == reflect.Map {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct || visited[rt] {
+		return false
+	}
+	visited[rt] = true
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if tag := field.Tag.Get(openapi.ValidateTagName); tag != "" && tag != "-" {
+			return true
+		}
+		if requireValidate(field.Type, visited) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (s ScanHelper) InferQueryBinder(qmodel *openapi.QModel, routeType RouteType) ModelBinder {
 	var binder ModelBinder
 
-	if qmodel.IsTime {
-		binder = &DateTimeModelBinder{modelName: qmodel.SchemaTitle(), paramType: openapi.RouteParamQuery}
+	paramType := openapi.RouteParamType(qmodel.In()) // 查询参数, 请求头参数或cookie参数
+	if qmodel.IsArray {
+		binder = &ArrayModelBinder{
+			modelName: qmodel.SchemaTitle(),
+			paramType: paramType,
+			elem:      scanHelper.InferParamBinder(qmodel, qmodel.ElemKind, paramType),
+		}
+	} else if qmodel.IsTime {
+		binder = &DateTimeModelBinder{modelName: qmodel.SchemaTitle(), paramType: paramType}
 	} else {
-		binder = scanHelper.InferParamBinder(qmodel, qmodel.Kind, openapi.RouteParamQuery)
+		binder = scanHelper.InferParamBinder(qmodel, qmodel.Kind, paramType)
 	}
 
 	return binder
 }
 
+// InferQueryDefault 通过参数的校验器转换声明的默认值, 默认值不合法时返回错误, 未声明默认值则为nil
+func (s ScanHelper) InferQueryDefault(qmodel *openapi.QModel, binder ModelBinder) (any, error) {
+	if !qmodel.HasDefault() {
+		return nil, nil
+	}
+
+	value, ves := binder.Validate(nil, qmodel.DefaultParam()) // 基本类型的校验器不依赖 Context
+	if len(ves) > 0 {
+		return nil, fmt.Errorf("param '%s' default value '%s' is invalid: %s", qmodel.Name, qmodel.Default, ves[0].Msg)
+	}
+
+	return value, nil
+}
+
+// InferFieldDefaults 推断结构体中声明了默认值的字段, 默认值在启动时完成类型转换; 仅支持基本类型的字段, 其他字段的默认值被忽略
+func (s ScanHelper) InferFieldDefaults(rt reflect.Type, paramType openapi.RouteParamType) ([]FieldDefault, error) {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	var defaults []FieldDefault
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		defaultV := utils.QueryFieldTag(field.Tag, openapi.DefaultValueTagNam, "")
+		if !field.IsExported() || defaultV == "" {
+			continue
+		}
+		qmodel := &openapi.QModel{Name: field.Name, Tag: field.Tag, Kind: field.Type.Kind(), Default: defaultV}
+		if !openapi.ReflectKindToType(qmodel.Kind).IsBaseType() { // 结构体、数组等字段的默认值被忽略, 不影响启动
+			structured.Warn("default value is only supported on base type fields, ignored",
+				"field", rt.String()+"."+field.Name, "default", defaultV,
+			)
+			continue
+		}
+
+		value, err := s.InferQueryDefault(qmodel, s.InferParamBinder(qmodel, qmodel.Kind, paramType))
+		if err != nil {
+			return nil, err
+		}
+		defaults = append(defaults, FieldDefault{Index: i, Value: reflect.ValueOf(value).Convert(field.Type)})
+	}
+
+	return defaults, nil
+}
+
+// InferPathBinders 推断路径参数的校验器, 未声明类型的路径参数为 NothingModelBinder
+func (s ScanHelper) InferPathBinders(fields []*openapi.QModel, routeType RouteType) []ModelBinder {
+	binders := make([]ModelBinder, len(fields))
+	for i, qmodel := range fields {
+		binders[i] = s.InferQueryBinder(qmodel, routeType)
+	}
+	return binders
+}
+
 // InferBaseQueryParam 推断基本类型的查询参数
 func (s ScanHelper) InferBaseQueryParam(param *openapi.RouteParam, routeType RouteType) *openapi.QModel {
 	name := param.QueryName // 手动指定一个查询参数名称
@@ -0,0 +1,246 @@
+package fastapi
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Chendemo12/fastapi/openapi"
+)
+
+// CredentialsKey 认证通过后, 凭证在 Context 中的键
+const CredentialsKey = "fastapi.credentials"
+
+// HeaderAuthorization 认证请求头
+const HeaderAuthorization = "Authorization"
+
+// HeaderWWWAuthenticate 认证失败时的质询响应头
+const HeaderWWWAuthenticate = "WWW-Authenticate"
+
+const (
+	notAuthenticated    = "Not authenticated"
+	notEnoughPermission = "Not enough permissions"
+)
+
+// Credentials 认证方案从请求中提取的凭证
+type Credentials struct {
+	Scheme   string   `json:"scheme" description:"认证方案名称"`
+	Token    string   `json:"token" description:"Bearer/OAuth2 令牌或 API Key, Basic 认证时为空"`
+	Username string   `json:"username" description:"用户名, 仅 Basic 认证"`
+	Password string   `json:"-" description:"密码, 仅 Basic 认证"`
+	Scopes   []string `json:"scopes" description:"已授予的权限范围或角色, 由认证方案填充, 用于 RequireScopes 鉴权"`
+}
+
+// SecurityScheme 认证方案, 用于生成文档中的 securitySchemes, 并从请求中提取凭证
+//
+// 认证方案可通过 Wrapper.UseSecurity 作用于全部路由, 或由路由组实现 GroupSecurity 和 RouteSecurity 接口作用于部分路由
+type SecurityScheme interface {
+	// SecurityName 认证方案名称, 在文档中唯一
+	SecurityName() string
+	// SecurityDoc 认证方案文档
+	SecurityDoc() *openapi.SecurityScheme
+	// Credentials 从请求中提取凭证, 凭证不存在或格式错误时返回 401 HTTPError
+	Credentials(c *Context) (*Credentials, error)
+}
+
+// GroupSecurity GroupRouter 的可选接口, 为路由组内的全部路由设置认证方案, 满足其一即可, 会覆盖 Wrapper.UseSecurity 的设置
+type GroupSecurity interface {
+	Security() []SecurityScheme
+}
+
+// RouteSecurity GroupRouter 的可选接口, 为单个路由方法设置认证方案, 方法名:认证方案, 会覆盖 GroupSecurity 的设置;
+// 认证方案为空时, 此路由无需认证
+type RouteSecurity interface {
+	RouteSecurity() map[string][]SecurityScheme
+}
+
+// GroupScopes GroupRouter 的可选接口, 为路由组内需要认证的全部路由设置所需的权限范围或角色
+type GroupScopes interface {
+	Scopes() []string
+}
+
+// RouteScopes GroupRouter 的可选接口, 为单个路由方法设置所需的权限范围或角色, 方法名:权限范围, 会覆盖 GroupScopes 的设置;
+// 权限范围需全部满足, 在认证通过之后校验, 未满足时返回 403, 并显示在文档的 security 中
+type RouteScopes interface {
+	RouteScopes() map[string][]string
+}
+
+// Security 创建一个认证依赖函数, 满足任意一个认证方案即可, 凭证可通过 Context.Credentials 获取;
+// 全部认证方案均未通过时, 添加 WWW-Authenticate 质询响应头(多个质询以逗号合并为一个值), 并以第一个认证方案的错误返回
+func Security(schemes ...SecurityScheme) DependenceHandle {
+	return func(c *Context) error {
+		var first error
+		for _, scheme := range schemes {
+			credentials, err := scheme.Credentials(c)
+			if err == nil {
+				c.Set(CredentialsKey, credentials)
+				return nil
+			}
+			if first == nil {
+				first = err
+			}
+		}
+
+		// MuxContext.Header 会覆盖同名响应头, 因此合并全部质询
+		challenges := make([]string, 0, len(schemes))
+		for _, scheme := range schemes {
+			if ch := securityChallenge(scheme.SecurityDoc()); ch != "" && !slices.Contains(challenges, ch) {
+				challenges = append(challenges, ch)
+			}
+		}
+		if len(challenges) > 0 {
+			c.muxCtx.Header(HeaderWWWAuthenticate, strings.Join(challenges, ", "))
+		}
+		return first
+	}
+}
+
+// RequireScopes 创建一个鉴权依赖函数, 需在认证依赖函数之后执行;
+// 凭证中需包含全部权限范围, 未认证时返回 401, 权限不足时返回 403
+func RequireScopes(scopes ...string) DependenceHandle {
+	return func(c *Context) error {
+		credentials := c.Credentials()
+		if credentials == nil {
+			return NewHTTPError(http.StatusUnauthorized, notAuthenticated)
+		}
+		for _, scope := range scopes {
+			if !slices.Contains(credentials.Scopes, scope) {
+				return NewHTTPError(http.StatusForbidden, notEnoughPermission)
+			}
+		}
+		return nil
+	}
+}
+
+// HTTPBearer Bearer 令牌认证, 从请求头 Authorization: Bearer <token> 中提取令牌
+func HTTPBearer(name string, bearerFormat ...string) SecurityScheme {
+	doc := &openapi.SecurityScheme{Type: openapi.SecurityTypeHTTP, Scheme: "bearer"}
+	if len(bearerFormat) > 0 {
+		doc.BearerFormat = bearerFormat[0]
+	}
+	return &securityScheme{name: name, doc: doc}
+}
+
+// HTTPBasic Basic 认证, 从请求头 Authorization: Basic <base64(username:password)> 中提取用户名和密码
+func HTTPBasic(name string) SecurityScheme {
+	return &securityScheme{name: name, doc: &openapi.SecurityScheme{Type: openapi.SecurityTypeHTTP, Scheme: "basic"}}
+}
+
+// APIKeyHeader 从请求头中提取 API Key
+func APIKeyHeader(name, key string) SecurityScheme {
+	return &securityScheme{name: name, doc: &openapi.SecurityScheme{Type: openapi.SecurityTypeApiKey, Name: key, In: openapi.InHeader}}
+}
+
+// APIKeyQuery 从查询参数中提取 API Key
+func APIKeyQuery(name, key string) SecurityScheme {
+	return &securityScheme{name: name, doc: &openapi.SecurityScheme{Type: openapi.SecurityTypeApiKey, Name: key, In: openapi.InQuery}}
+}
+
+// APIKeyCookie 从 cookie 中提取 API Key
+func APIKeyCookie(name, key string) SecurityScheme {
+	return &securityScheme{name: name, doc: &openapi.SecurityScheme{Type: openapi.SecurityTypeApiKey, Name: key, In: openapi.InCookie}}
+}
+
+// OAuth2Password OAuth2 密码模式, 客户端通过 tokenUrl 获取令牌, 之后与 HTTPBearer 相同, 从请求头中提取令牌;
+// 在线文档中可通过 Authorize 按钮输入用户名和密码获取令牌
+func OAuth2Password(name, tokenUrl string, scopes map[string]string) SecurityScheme {
+	if scopes == nil {
+		scopes = map[string]string{}
+	}
+	return &securityScheme{name: name, doc: &openapi.SecurityScheme{
+		Type:  openapi.SecurityTypeOAuth2,
+		Flows: &openapi.OAuthFlows{Password: &openapi.OAuthFlow{TokenUrl: tokenUrl, Scopes: scopes}},
+	}}
+}
+
+// 内置的认证方案
+type securityScheme struct {
+	name string
+	doc  *openapi.SecurityScheme
+}
+
+func (s *securityScheme) SecurityName() string { return s.name }
+
+func (s *securityScheme) SecurityDoc() *openapi.SecurityScheme { return s.doc }
+
+func (s *securityScheme) Credentials(c *Context) (*Credentials, error) {
+	switch {
+	case s.doc.Type == openapi.SecurityTypeApiKey:
+		var key string
+		switch s.doc.In {
+		case openapi.InQuery:
+			key = c.muxCtx.Query(s.doc.Name)
+		case openapi.InCookie:
+			key, _ = c.muxCtx.Cookie(s.doc.Name)
+		default:
+			key = c.muxCtx.GetHeader(s.doc.Name)
+		}
+		if key == "" {
+			return nil, NewHTTPError(http.StatusUnauthorized, notAuthenticated)
+		}
+		return &Credentials{Scheme: s.name, Token: key}, nil
+
+	case s.doc.Scheme == "basic":
+		value, ok := authorization(c, "Basic")
+		if !ok {
+			return nil, NewHTTPError(http.StatusUnauthorized, notAuthenticated)
+		}
+		bs, err := base64.StdEncoding.DecodeString(value)
+		username, password, found := strings.Cut(string(bs), ":")
+		if err != nil || !found {
+			return nil, NewHTTPError(http.StatusUnauthorized, "Invalid authentication credentials")
+		}
+		return &Credentials{Scheme: s.name, Username: username, Password: password}, nil
+
+	default: // bearer, oauth2
+		token, ok := authorization(c, "Bearer")
+		if !ok {
+			return nil, NewHTTPError(http.StatusUnauthorized, notAuthenticated)
+		}
+		return &Credentials{Scheme: s.name, Token: token}, nil
+	}
+}
+
+// 读取 Authorization 请求头中指定认证方式的凭证, 认证方式不区分大小写
+func authorization(c *Context, scheme string) (string, bool) {
+	value := c.muxCtx.GetHeader(HeaderAuthorization)
+	if len(value) <= len(scheme) || !strings.EqualFold(value[:len(scheme)], scheme) || value[len(scheme)] != ' ' {
+		return "", false
+	}
+	value = strings.TrimSpace(value[len(scheme):])
+	return value, value != ""
+}
+
+// 认证方案的质询方式, apiKey 不存在质询方式
+func securityChallenge(doc *openapi.SecurityScheme) string {
+	switch {
+	case doc.Type == openapi.SecurityTypeOAuth2, doc.Type == openapi.SecurityTypeHTTP && strings.EqualFold(doc.Scheme, "bearer"):
+		return "Bearer"
+	case doc.Type == openapi.SecurityTypeHTTP && strings.EqualFold(doc.Scheme, "basic"):
+		return "Basic"
+	}
+	return ""
+}
+
+// 按照名称对认证方案去重, 名称不能为空, 且同名的认证方案必须是同一个实例
+func uniqueSecuritySchemes(schemes []SecurityScheme) ([]SecurityScheme, error) {
+	registered := make(map[string]SecurityScheme)
+	unique := make([]SecurityScheme, 0, len(schemes))
+	for _, scheme := range schemes {
+		if scheme == nil || scheme.SecurityName() == "" {
+			return nil, errors.New("security scheme name cannot be empty")
+		}
+		if v, ok := registered[scheme.SecurityName()]; ok {
+			if v != scheme {
+				return nil, fmt.Errorf("security scheme '%s' is already registered", scheme.SecurityName())
+			}
+			continue
+		}
+		registered[scheme.SecurityName()] = scheme
+		unique = append(unique, scheme)
+	}
+	return unique, nil
+}
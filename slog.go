@@ -0,0 +1,165 @@
+package fastapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StructuredLogger LoggerIface 的可选能力, 通过 slog.Logger 记录结构化日志, SlogLogger 已实现此接口;
+// 未实现此接口时, 结构化日志的键值对以 key=value 的形式拼接在消息之后写入 LoggerIface
+type StructuredLogger interface {
+	Slog() *slog.Logger
+}
+
+// SlogLogger 基于 slog.Handler 的 LoggerIface 实现
+//
+//	fastapi.ReplaceLogger(fastapi.NewSlogLogger(slog.NewJSONHandler(os.Stdout, nil)))
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger 以 slog.Handler 创建日志, handler 为 nil 时使用 slog.Default 的 Handler
+func NewSlogLogger(handler slog.Handler) *SlogLogger {
+	if handler == nil {
+		handler = slog.Default().Handler()
+	}
+	return &SlogLogger{logger: slog.New(handler)}
+}
+
+// Slog 实现 StructuredLogger
+func (l *SlogLogger) Slog() *slog.Logger { return l.logger }
+
+func (l *SlogLogger) Debug(args ...any) { l.log(slog.LevelDebug, sprint(args...)) }
+
+func (l *SlogLogger) Info(args ...any) { l.log(slog.LevelInfo, sprint(args...)) }
+
+func (l *SlogLogger) Warn(args ...any) { l.log(slog.LevelWarn, sprint(args...)) }
+
+func (l *SlogLogger) Error(args ...any) { l.log(slog.LevelError, sprint(args...)) }
+
+func (l *SlogLogger) Errorf(format string, v ...any) {
+	l.log(slog.LevelError, fmt.Sprintf(format, v...))
+}
+
+func (l *SlogLogger) Warnf(format string, v ...any) {
+	l.log(slog.LevelWarn, fmt.Sprintf(format, v...))
+}
+
+func (l *SlogLogger) Debugf(format string, v ...any) {
+	l.log(slog.LevelDebug, fmt.Sprintf(format, v...))
+}
+
+// 记录调用者的位置, 而非 SlogLogger 的方法
+func (l *SlogLogger) log(level slog.Level, msg string) {
+	ctx := context.Background()
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // runtime.Callers, log, Debug/Info...
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	_ = l.logger.Handler().Handle(ctx, r)
+}
+
+// 与 DefaultLogger 一致, 参数之间以空格分隔
+func sprint(args ...any) string {
+	return strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+}
+
+// Logger 携带此次请求信息 (request_id, route, client_ip) 的结构化日志, 以便路由函数输出一致的日志;
+// route 为匹配到的路由标识 RouteIface.Id, 与访问日志一致, 未匹配到路由时为空; 日志写入 ReplaceLogger 设置的日志中
+func (c *Context) Logger() *slog.Logger {
+	if c.logger == nil {
+		c.logger = structured.With(
+			"request_id", c.RequestID(),
+			"route", c.RouteId(),
+			"client_ip", c.muxCtx.ClientIP(),
+		)
+	}
+	return c.logger
+}
+
+// 由 LoggerIface 创建结构化日志
+func slogFrom(logger LoggerIface) *slog.Logger {
+	if s, ok := logger.(StructuredLogger); ok {
+		return s.Slog()
+	}
+	return slog.New(&ifaceHandler{logger: logger})
+}
+
+// 将结构化日志写入 LoggerIface 的 slog.Handler, 级别由 LoggerIface 自行过滤
+type ifaceHandler struct {
+	logger LoggerIface
+	attrs  string // 已格式化的公共键值对
+	group  string // 键的前缀
+}
+
+func (h *ifaceHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *ifaceHandler) Handle(_ context.Context, r slog.Record) error {
+	buf := []byte(r.Message)
+	buf = append(buf, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		buf = appendAttr(buf, h.group, a)
+		return true
+	})
+
+	switch msg := string(buf); {
+	case r.Level >= slog.LevelError:
+		h.logger.Error(msg)
+	case r.Level >= slog.LevelWarn:
+		h.logger.Warn(msg)
+	case r.Level >= slog.LevelInfo:
+		h.logger.Info(msg)
+	default:
+		h.logger.Debug(msg)
+	}
+	return nil
+}
+
+func (h *ifaceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	buf := []byte(h.attrs)
+	for _, a := range attrs {
+		buf = appendAttr(buf, h.group, a)
+	}
+	return &ifaceHandler{logger: h.logger, attrs: string(buf), group: h.group}
+}
+
+func (h *ifaceHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &ifaceHandler{logger: h.logger, attrs: h.attrs, group: h.group + name + "."}
+}
+
+// 以 key=value 的形式追加键值对, 包含空白或引号的值会被转义
+func appendAttr(buf []byte, prefix string, a slog.Attr) []byte {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return buf
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			buf = appendAttr(buf, prefix, ga)
+		}
+		return buf
+	}
+
+	buf = append(buf, ' ')
+	buf = append(buf, prefix...)
+	buf = append(buf, a.Key...)
+	buf = append(buf, '=')
+	value := a.Value.String()
+	if value == "" || strings.ContainsAny(value, " \t\r\n\"=") {
+		return strconv.AppendQuote(buf, value)
+	}
+	return append(buf, value...)
+}
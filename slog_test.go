@@ -0,0 +1,98 @@
+package fastapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewSlogLogger(slog.NewJSONHandler(buf, &slog.HandlerOptions{AddSource: true}))
+
+	logger.Warnf("bind '%s' failed", "/api")
+	entry := struct {
+		Level  string `json:"level"`
+		Msg    string `json:"msg"`
+		Source struct {
+			File string `json:"file"`
+		} `json:"source"`
+	}{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log = %s, error = %v", buf.String(), err)
+	}
+	if entry.Level != "WARN" || entry.Msg != "bind '/api' failed" || !strings.HasSuffix(entry.Source.File, "slog_test.go") {
+		t.Errorf("log = %s", buf.String())
+	}
+
+	buf.Reset()
+	logger.Debug("ignored")
+	if buf.Len() != 0 {
+		t.Errorf("debug should be disabled by default, log = %s", buf.String())
+	}
+
+	logger.Info("a", 1)
+	if !strings.Contains(buf.String(), `"msg":"a 1"`) {
+		t.Errorf("log = %s", buf.String())
+	}
+}
+
+// 记录最后一条日志的 LoggerIface
+type lastLogger struct {
+	LoggerIface
+	level string
+	msg   string
+}
+
+func (l *lastLogger) Debug(args ...any) { l.level, l.msg = "DEBUG", fmt.Sprint(args...) }
+func (l *lastLogger) Info(args ...any)  { l.level, l.msg = "INFO", fmt.Sprint(args...) }
+func (l *lastLogger) Warn(args ...any)  { l.level, l.msg = "WARN", fmt.Sprint(args...) }
+func (l *lastLogger) Error(args ...any) { l.level, l.msg = "ERROR", fmt.Sprint(args...) }
+
+func TestSlogFrom(t *testing.T) {
+	s := NewSlogLogger(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	if slogFrom(s) != s.Slog() {
+		t.Error("StructuredLogger should be used directly")
+	}
+
+	l := &lastLogger{}
+	logger := slogFrom(l).With("request_id", "req-1").WithGroup("db")
+
+	tests := []struct {
+		name  string
+		log   func()
+		level string
+		msg   string
+	}{
+		{
+			name:  "error",
+			log:   func() { logger.Error("route bind failed", "path", "/api", "error", errors.New("duplicate route")) },
+			level: "ERROR",
+			msg:   `route bind failed request_id=req-1 db.path=/api db.error="duplicate route"`,
+		},
+		{
+			name:  "group",
+			log:   func() { logger.Warn("slow", slog.Group("query", "sql", "a=1", "rows", 2)) },
+			level: "WARN",
+			msg:   `slow request_id=req-1 db.query.sql="a=1" db.query.rows=2`,
+		},
+		{
+			name:  "debug",
+			log:   func() { logger.Debug("empty", "value", "") },
+			level: "DEBUG",
+			msg:   `empty request_id=req-1 db.value=""`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.log()
+			if l.level != tt.level || l.msg != tt.msg {
+				t.Errorf("got %s %q, want %s %q", l.level, l.msg, tt.level, tt.msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package fastapi
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Chendemo12/fastapi/utils"
+)
+
+// DefaultSSEHeartbeat SSE 响应默认的心跳间隔, 心跳以注释行的形式发送, 用于保持连接和及时发现客户端断开
+var DefaultSSEHeartbeat = 15 * time.Second
+
+// HeaderLastEventID 客户端重连时携带的最后一个事件ID
+const HeaderLastEventID = "Last-Event-ID"
+
+// SSEEvent 服务端推送事件(Server-Sent Events), T 为事件数据的类型
+type SSEEvent[T any] struct {
+	ID    string        `description:"事件ID, 客户端重连时通过 Last-Event-ID 请求头回传"`
+	Event string        `description:"事件类型, 为空时客户端按 message 事件处理"`
+	Data  T             `description:"事件数据, string 和 []byte 原样发送, 其他类型序列化为JSON"`
+	Retry time.Duration `description:"客户端重连间隔, 为0时不发送"`
+}
+
+// SSEResponse 服务端推送事件响应, 以 text/event-stream 的形式逐条写入 channel 中的事件
+//
+// 当 channel 被关闭, 或 Context.Done 完成, 或写入失败(客户端断开)时响应结束;
+// 事件生产者应同时监听 Context.Done, 以便在响应结束后退出, 由于 Context 在响应结束后会被回收,
+// 需在启动生产者协程之前获取 Context.Done.
+// 文档中响应体的模型为事件数据 T 的模型
+type SSEResponse[T any] struct {
+	events    <-chan SSEEvent[T]
+	heartbeat time.Duration
+}
+
+// SSE 创建一个服务端推送事件响应, 心跳间隔默认为 DefaultSSEHeartbeat
+//
+//	func (r *Router) GetProgress(c *fastapi.Context) (*fastapi.SSEResponse[Progress], error) {
+//		ch := make(chan fastapi.SSEEvent[Progress])
+//		go produce(c.Done(), ch)
+//		return fastapi.SSE(ch), nil
+//	}
+func SSE[T any](ch <-chan SSEEvent[T]) *SSEResponse[T] {
+	return &SSEResponse[T]{events: ch, heartbeat: DefaultSSEHeartbeat}
+}
+
+// Heartbeat 设置心跳间隔, 为0时不发送心跳
+func (s *SSEResponse[T]) Heartbeat(d time.Duration) *SSEResponse[T] {
+	s.heartbeat = d
+	return s
+}
+
+func (s *SSEResponse[T]) SchemaDesc() string {
+	return "服务端推送事件"
+}
+
+func (s *SSEResponse[T]) eventDataType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// 逐条写入事件, 每条事件写入后立刻刷新; done(路由 context 结束) 或 closed(客户端断开连接) 关闭时返回
+func (s *SSEResponse[T]) serveEvents(done, closed <-chan struct{}, w io.Writer, flush func() error) error {
+	if s == nil || s.events == nil {
+		return nil
+	}
+
+	var tick <-chan time.Time
+	if s.heartbeat > 0 {
+		ticker := time.NewTicker(s.heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	buf := &bytes.Buffer{}
+	for {
+		buf.Reset()
+		select {
+		case <-done:
+			return nil
+		case <-closed: // 客户端已断开连接
+			return nil
+		case <-tick:
+			buf.WriteString(": heartbeat\n\n")
+		case event, ok := <-s.events:
+			if !ok {
+				return nil
+			}
+			if err := encodeEvent(buf, event); err != nil {
+				return err
+			}
+		}
+
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+}
+
+// 按照 event-stream 格式编码一个事件, 多行数据会被拆分为多个 data 字段
+func encodeEvent[T any](buf *bytes.Buffer, event SSEEvent[T]) error {
+	var data string
+	switch v := any(event.Data).(type) {
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		bs, err := utils.JsonMarshal(v)
+		if err != nil {
+			return err
+		}
+		data = string(bs)
+	}
+
+	if event.ID != "" {
+		buf.WriteString("id: " + sseFieldValue(event.ID) + "\n")
+	}
+	if event.Event != "" {
+		buf.WriteString("event: " + sseFieldValue(event.Event) + "\n")
+	}
+	if event.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteByte('\n')
+
+	return nil
+}
+
+// 单行字段不允许包含换行符
+func sseFieldValue(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// 服务端推送事件响应的内部接口, 由 SSEResponse 实现
+type eventStreamer interface {
+	eventDataType() reflect.Type
+	serveEvents(done, closed <-chan struct{}, w io.Writer, flush func() error) error
+}
+
+var eventStreamerType = reflect.TypeOf((*eventStreamer)(nil)).Elem()
@@ -0,0 +1,95 @@
+package fastapi
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestEncodeEvent(t *testing.T) {
+	type Progress struct {
+		Percent int `json:"percent"`
+	}
+
+	tests := []struct {
+		name  string
+		event func(buf *bytes.Buffer) error
+		want  string
+	}{
+		{
+			name: "json",
+			event: func(buf *bytes.Buffer) error {
+				return encodeEvent(buf, SSEEvent[Progress]{ID: "1", Event: "progress", Data: Progress{Percent: 50}})
+			},
+			want: "id: 1\nevent: progress\ndata: {\"percent\":50}\n\n",
+		},
+		{
+			name: "multiline-string",
+			event: func(buf *bytes.Buffer) error {
+				return encodeEvent(buf, SSEEvent[string]{Data: "a\r\nb\nc", Retry: 3 * time.Second})
+			},
+			want: "retry: 3000\ndata: a\ndata: b\ndata: c\n\n",
+		},
+		{
+			name: "bytes-and-newline-in-id",
+			event: func(buf *bytes.Buffer) error {
+				return encodeEvent(buf, SSEEvent[[]byte]{ID: "1\n2", Data: []byte("raw")})
+			},
+			want: "id: 12\ndata: raw\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := tt.event(buf); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("encodeEvent() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSSEResponse_serveEvents(t *testing.T) {
+	t.Run("done", func(t *testing.T) {
+		ch := make(chan SSEEvent[string]) // 永不关闭
+		done := make(chan struct{})
+		buf := &bytes.Buffer{}
+		flushes := 0
+
+		result := make(chan error, 1)
+		go func() {
+			result <- SSE(ch).Heartbeat(5*time.Millisecond).serveEvents(done, nil, buf, func() error {
+				flushes++
+				return nil
+			})
+		}()
+
+		ch <- SSEEvent[string]{Data: "hello"}
+		time.Sleep(20 * time.Millisecond)
+		close(done)
+
+		select {
+		case err := <-result:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("serveEvents() should return when done")
+		}
+		if !bytes.HasPrefix(buf.Bytes(), []byte("data: hello\n\n")) || !bytes.Contains(buf.Bytes(), []byte(": heartbeat\n\n")) {
+			t.Errorf("body = %q", buf.String())
+		}
+		if flushes < 2 {
+			t.Errorf("flush called %d times", flushes)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var s *SSEResponse[string]
+		if err := s.serveEvents(nil, nil, &bytes.Buffer{}, func() error { return nil }); err != nil {
+			t.Error(err)
+		}
+	})
+}
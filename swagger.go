@@ -22,6 +22,19 @@ func (f *Wrapper) registerRouteDoc() *Wrapper {
 		for _, route := range group.Routes() {
 			f.openApi.RegisterFrom(route.Swagger())
 		}
+		for _, route := range group.WebSocketRoutes() {
+			f.openApi.RegisterFrom(route.Swagger())
+		}
+	}
+
+	for _, scheme := range f.securitySchemes {
+		f.openApi.Components.AddSecurityScheme(scheme.SecurityName(), scheme.SecurityDoc())
+	}
+
+	// 由 Wrapper 接管的404和405响应
+	if _, ok := f.mux.(FallbackMuxWrapper); ok {
+		f.openApi.AddErrorResponse("NotFound", http.StatusNotFound)
+		f.openApi.AddErrorResponse("MethodNotAllowed", http.StatusMethodNotAllowed)
 	}
 
 	return f
@@ -46,6 +59,17 @@ func (f *Wrapper) registerRouteHandle() *Wrapper {
 		panic(fmt.Sprintf("bind openapi failed, method: 'GET', path: '%s', error: %v", openapi.DocumentUrl, err))
 	}
 
+	// =========== docs OAuth2 认证的回调页面
+	err = f.Mux().BindRoute(http.MethodGet, openapi.Oauth2RedirectUrl,
+		func(ctx MuxContext) error {
+			ctx.Header(openapi.HeaderContentType, string(openapi.MIMETextHTMLCharsetUTF8))
+			return ctx.SendString(openapi.MakeOauth2RedirectHtml())
+		},
+	)
+	if err != nil {
+		panic(fmt.Sprintf("bind openapi failed, method: 'GET', path: '%s', error: %v", openapi.Oauth2RedirectUrl, err))
+	}
+
 	// =========== openapi 获取路由定义
 	err = f.Mux().BindRoute(http.MethodGet, openapi.JsonUrl,
 		func(ctx MuxContext) error {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -25,6 +26,8 @@ const ( // error message
 	ModelCannotArray   = "The return value cannot be a array"
 	PathPsIsEmpty      = "Path must not be empty"
 	QueryPsIsEmpty     = "Query must not be empty"
+	HeaderPsIsEmpty    = "Header must not be empty"
+	CookiePsIsEmpty    = "Cookie must not be empty"
 )
 
 const ( // json序列化错误, 关键信息的序号
@@ -292,7 +295,7 @@ func (m *BoolModelBinder) Validate(c *Context, requestParam any) (any, []*openap
 	if err != nil {
 		var ves []*openapi.ValidationError
 		ves = append(ves, &openapi.ValidationError{
-			Loc:  []string{"query", m.modelName},
+			Loc:  []string{string(m.paramType), m.modelName},
 			Msg:  fmt.Sprintf("value: '%s' is not a bool", sv),
 			Type: string(openapi.BoolType),
 			Ctx:  whereClientError,
@@ -386,7 +389,7 @@ func (m *TimeModelBinder) Validate(c *Context, requestParam any) (any, []*openap
 
 	var ves []*openapi.ValidationError
 	ves = append(ves, &openapi.ValidationError{
-		Loc:  []string{"query", m.modelName},
+		Loc:  []string{string(m.paramType), m.modelName},
 		Msg:  fmt.Sprintf("value: '%s' is not a time, err:%v", sv, err),
 		Type: string(openapi.StringType),
 		Ctx:  whereClientError,
@@ -425,7 +428,7 @@ func (m *DateModelBinder) Validate(c *Context, requestParam any) (any, []*openap
 
 	var ves []*openapi.ValidationError
 	ves = append(ves, &openapi.ValidationError{
-		Loc:  []string{"query", m.modelName},
+		Loc:  []string{string(m.paramType), m.modelName},
 		Msg:  fmt.Sprintf("value: '%s' is not a date, err:%v", sv, err),
 		Type: string(openapi.StringType),
 		Ctx:  whereClientError,
@@ -471,7 +474,7 @@ func (m *DateTimeModelBinder) Validate(c *Context, requestParam any) (any, []*op
 
 	if errors.As(err, &timeErr) {
 		ves = append(ves, &openapi.ValidationError{
-			Loc:  []string{"query", m.modelName},
+			Loc:  []string{string(m.paramType), m.modelName},
 			Msg:  fmt.Sprintf("value: '%s' is not a datetime, err:%s", sv, err.Error()),
 			Type: string(openapi.StringType),
 			Ctx: map[string]any{
@@ -481,7 +484,7 @@ func (m *DateTimeModelBinder) Validate(c *Context, requestParam any) (any, []*op
 		})
 	} else {
 		ves = append(ves, &openapi.ValidationError{
-			Loc:  []string{"query", m.modelName},
+			Loc:  []string{string(m.paramType), m.modelName},
 			Msg:  fmt.Sprintf("value: '%s' is not a datetime, err:%s", sv, err.Error()),
 			Type: string(openapi.StringType),
 			Ctx:  whereClientError,
@@ -491,6 +494,52 @@ func (m *DateTimeModelBinder) Validate(c *Context, requestParam any) (any, []*op
 	return nil, ves
 }
 
+// ArrayModelBinder 数组查询参数校验, 逐个元素进行类型转换和校验
+type ArrayModelBinder struct {
+	modelName string
+	paramType openapi.RouteParamType
+	elem      ModelBinder // 数组元素的校验器
+}
+
+func (m *ArrayModelBinder) Name() string { return "ArrayModelBinder" }
+
+func (m *ArrayModelBinder) ModelName() string {
+	return m.modelName
+}
+
+func (m *ArrayModelBinder) RouteParamType() openapi.RouteParamType {
+	return m.paramType
+}
+
+// Validate requestParam 为 []string 类型, 转换成功后返回 []any, 错误信息的 loc 中包含元素索引
+func (m *ArrayModelBinder) Validate(c *Context, requestParam any) (any, []*openapi.ValidationError) {
+	values, ok := requestParam.([]string)
+	if !ok {
+		return requestParam, []*openapi.ValidationError{{
+			Loc:  []string{string(m.paramType), m.modelName},
+			Msg:  fmt.Sprintf("value: '%v' is not an array", requestParam),
+			Type: string(openapi.ArrayType),
+			Ctx:  whereClientError,
+		}}
+	}
+
+	var ves []*openapi.ValidationError
+	result := make([]any, len(values))
+	for i, v := range values {
+		value, err := m.elem.Validate(c, v)
+		if err != nil {
+			for _, ve := range err {
+				ve.Loc = []string{string(m.paramType), m.modelName, strconv.Itoa(i)}
+			}
+			ves = append(ves, err...)
+			continue
+		}
+		result[i] = value
+	}
+
+	return result, ves
+}
+
 type RequestModelBinder struct {
 	modelName string
 	paramType openapi.RouteParamType
@@ -585,10 +634,17 @@ func (m *FileModelBinder) Validate(c *Context, requestParam any) (any, []*openap
 	return requestParam, nil
 }
 
+// FieldDefault 结构体字段的默认值
+type FieldDefault struct {
+	Index int           `description:"字段在结构体中的索引"`
+	Value reflect.Value `description:"已转换为字段类型的默认值"`
+}
+
 // FileWithParamModelBinder 文件+json 混合请求体验证
 type FileWithParamModelBinder struct {
 	FileModelBinder
 	paramType openapi.RouteParamType
+	defaults  []FieldDefault // 表单参数中声明了默认值的字段
 }
 
 func (m *FileWithParamModelBinder) Name() string {
@@ -614,6 +670,14 @@ func (m *FileWithParamModelBinder) Validate(c *Context, requestParam any) (any,
 		}}
 	}
 
+	// 首先填充默认值, 表单参数中缺失的字段将保留默认值
+	if len(m.defaults) > 0 {
+		rv := reflect.ValueOf(requestParam).Elem()
+		for _, d := range m.defaults {
+			rv.Field(d.Index).Set(d.Value)
+		}
+	}
+
 	// json 参数绑定
 	err := c.Unmarshal([]byte(params[0]), requestParam)
 	if err != nil {
@@ -636,12 +700,23 @@ func (m *FileWithParamModelBinder) Validate(c *Context, requestParam any) (any,
 }
 
 // StructQueryBind 结构体查询参数验证器
+//
+// 对于每一个结构体类型, 首次绑定时(或启动时通过 PrepareStructQueryPlan)创建一个 StructQueryPlan 并缓存,
+// 之后直接按照计划写入结构体字段; 对于无法创建绑定计划的类型, 则回退到JSON序列化和反序列化的方式
 type StructQueryBind struct {
 	json jsoniter.API
 }
 
-// Unmarshal todo 性能损耗过大了
+// Unmarshal 将参数值绑定到结构体 obj 上, 参数名称为字段的 query 标签名
 func (m *StructQueryBind) Unmarshal(params map[string]any, obj any) *openapi.ValidationError {
+	if plan := loadStructQueryPlan(obj); plan != nil {
+		return plan.Bind(params, obj)
+	}
+	return m.unmarshalJson(params, obj)
+}
+
+// 通过JSON序列化和反序列化实现绑定, 性能损耗较大, 仅用于无法创建绑定计划的类型
+func (m *StructQueryBind) unmarshalJson(params map[string]any, obj any) *openapi.ValidationError {
 	s, err := m.json.Marshal(params)
 	if err != nil {
 		return ParseJsoniterError(err, openapi.RouteParamQuery, "")
@@ -1,6 +1,7 @@
 package fastapi
 
 import (
+	"reflect"
 	"testing"
 
 	jsoniter "github.com/json-iterator/go"
@@ -63,3 +64,39 @@ func TestQBinder_Bind(t *testing.T) {
 		})
 	}
 }
+
+type ValidatedItem struct {
+	Price float64 `json:"price" validate:"gt=0"`
+}
+
+type ItemList struct {
+	Items []ValidatedItem `json:"items"`
+}
+
+type TreeNode struct {
+	Name     string      `json:"name" validate:"-"`
+	Children []*TreeNode `json:"children"`
+}
+
+func TestRequireValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  any
+		want bool
+	}{
+		{name: "no-tags", obj: &Person{}, want: false},
+		{name: "direct", obj: &ValidatedItem{}, want: true},
+		{name: "nested-slice", obj: &ItemList{}, want: true},
+		{name: "embedded", obj: &struct{ ValidatedItem }{}, want: true},
+		{name: "recursive", obj: &TreeNode{}, want: false},
+		{name: "map-value", obj: map[string]*ValidatedItem{}, want: true},
+		{name: "base-type", obj: new(int), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := requireValidate(reflect.TypeOf(tt.obj), map[reflect.Type]bool{}); got != tt.want {
+				t.Errorf("requireValidate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,423 @@
+// Package websocket 服务端的 websocket(RFC 6455) 协议实现, 供路由器实现 fastapi.WebSocketUpgrader
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Chendemo12/fastapi/utils"
+)
+
+// websocket 消息类型, 取值与 RFC 6455 中的操作码一致
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+)
+
+// websocket 关闭码
+const (
+	CloseNormalClosure      = 1000
+	CloseGoingAway          = 1001
+	CloseProtocolError      = 1002
+	CloseUnsupportedData    = 1003
+	CloseNoStatusReceived   = 1005
+	CloseInvalidPayloadData = 1007
+	CloseMessageTooBig      = 1009
+	CloseInternalServerErr  = 1011
+)
+
+const (
+	websocketGUID        = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	websocketVersion     = "13"
+	continuationFrame    = 0
+	maxControlPayloadLen = 125
+)
+
+// DefaultReadLimit 单条消息的默认最大字节数, 超出时将以 CloseMessageTooBig 关闭连接
+var DefaultReadLimit int64 = 4 << 20
+
+var (
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+	ErrCloseSent    = errors.New("websocket: close sent")
+	ErrReadLimit    = errors.New("websocket: read limit exceeded")
+)
+
+// CloseError 对端发送了关闭帧
+type CloseError struct {
+	Code int    `json:"code" description:"关闭码"`
+	Text string `json:"text" description:"关闭原因"`
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Text)
+}
+
+// 协议错误, 读取时会以 CloseProtocolError 关闭连接
+type protocolError string
+
+func (e protocolError) Error() string { return "websocket: " + string(e) }
+
+// Conn 服务端的 websocket 连接, 仅实现了 RFC 6455 的基本帧协议, 不支持扩展(如 permessage-deflate)
+//
+//	ReadMessage 会自动回复 Ping 帧和 Close 帧, 因此需要持续调用 ReadMessage 才能及时响应对端的控制帧;
+//	写方法是并发安全的, 但同一时刻只能有一个协程调用读方法
+type Conn struct {
+	conn      net.Conn
+	br        *bufio.Reader
+	wmu       sync.Mutex
+	readLimit int64
+	closeSent bool
+}
+
+// NewConn 在已完成握手的连接上创建 websocket 连接, 用于 MuxContext 实现 fastapi.WebSocketUpgrader
+//
+//	@param	conn	net.Conn		被劫持的连接
+//	@param	br		*bufio.Reader	连接的读缓冲区, 其中可能已经包含了客户端发送的帧, 为nil时直接读取 conn
+func NewConn(conn net.Conn, br *bufio.Reader) *Conn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+	// 清除 http server 设置的读写超时
+	_ = conn.SetDeadline(time.Time{})
+
+	return &Conn{conn: conn, br: br, readLimit: DefaultReadLimit}
+}
+
+// NetConn 底层连接
+func (w *Conn) NetConn() net.Conn { return w.conn }
+
+func (w *Conn) RemoteAddr() net.Addr { return w.conn.RemoteAddr() }
+
+// SetReadLimit 设置单条消息的最大字节数
+func (w *Conn) SetReadLimit(limit int64) { w.readLimit = limit }
+
+func (w *Conn) SetReadDeadline(t time.Time) error { return w.conn.SetReadDeadline(t) }
+
+func (w *Conn) SetWriteDeadline(t time.Time) error { return w.conn.SetWriteDeadline(t) }
+
+// ReadMessage 读取一条完整的消息, messageType 为 TextMessage 或 BinaryMessage
+//
+//	当对端关闭连接时返回 *CloseError
+func (w *Conn) ReadMessage() (messageType int, p []byte, err error) {
+	for {
+		fin, opcode, payload, err := w.readFrame()
+		if err != nil {
+			var pe protocolError
+			switch {
+			case errors.As(err, &pe):
+				_ = w.WriteClose(CloseProtocolError, pe.Error())
+			case errors.Is(err, ErrReadLimit):
+				_ = w.WriteClose(CloseMessageTooBig, "")
+			}
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case PingMessage:
+			if err = w.writeFrame(PongMessage, payload); err != nil && !errors.Is(err, ErrCloseSent) {
+				return 0, nil, err
+			}
+			continue
+
+		case PongMessage:
+			continue
+
+		case CloseMessage:
+			ce := &CloseError{Code: CloseNoStatusReceived}
+			switch {
+			case len(payload) == 1:
+				_ = w.WriteClose(CloseProtocolError, "")
+				return 0, nil, protocolError("invalid close payload")
+			case len(payload) >= 2:
+				ce.Code = int(binary.BigEndian.Uint16(payload))
+				ce.Text = string(payload[2:])
+				if !validCloseCode(ce.Code) || !utf8.ValidString(ce.Text) {
+					_ = w.WriteClose(CloseProtocolError, "")
+					return 0, nil, protocolError("invalid close code or reason")
+				}
+			}
+			// 回复关闭帧, 连接随后由上层关闭
+			if ce.Code == CloseNoStatusReceived {
+				_ = w.writeFrame(CloseMessage, nil)
+			} else {
+				_ = w.WriteClose(ce.Code, "")
+			}
+			return 0, nil, ce
+
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				_ = w.WriteClose(CloseProtocolError, "")
+				return 0, nil, protocolError("expect continuation frame")
+			}
+			messageType = opcode
+			p = payload
+
+		case continuationFrame:
+			if messageType == 0 {
+				_ = w.WriteClose(CloseProtocolError, "")
+				return 0, nil, protocolError("unexpected continuation frame")
+			}
+			if int64(len(p)+len(payload)) > w.readLimit {
+				_ = w.WriteClose(CloseMessageTooBig, "")
+				return 0, nil, ErrReadLimit
+			}
+			p = append(p, payload...)
+
+		default:
+			_ = w.WriteClose(CloseProtocolError, "")
+			return 0, nil, protocolError(fmt.Sprintf("unknown opcode %d", opcode))
+		}
+
+		if fin {
+			if messageType == TextMessage && !utf8.Valid(p) {
+				_ = w.WriteClose(CloseInvalidPayloadData, "")
+				return 0, nil, protocolError("invalid utf8 payload")
+			}
+			return messageType, p, nil
+		}
+	}
+}
+
+// ReadJSON 读取一条消息并反序列化到 v
+func (w *Conn) ReadJSON(v any) error {
+	_, p, err := w.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return utils.JsonUnmarshal(p, v)
+}
+
+// WriteMessage 发送一条消息, messageType 为 TextMessage, BinaryMessage, PingMessage 或 PongMessage
+func (w *Conn) WriteMessage(messageType int, data []byte) error {
+	switch messageType {
+	case TextMessage, BinaryMessage:
+	case PingMessage, PongMessage:
+		if len(data) > maxControlPayloadLen {
+			return errors.New("websocket: control frame payload too large")
+		}
+	default:
+		return fmt.Errorf("websocket: unsupported message type %d", messageType)
+	}
+
+	return w.writeFrame(messageType, data)
+}
+
+// WriteJSON 序列化 v 并以文本消息发送
+func (w *Conn) WriteJSON(v any) error {
+	p, err := utils.JsonMarshal(v)
+	if err != nil {
+		return err
+	}
+	return w.writeFrame(TextMessage, p)
+}
+
+// WriteClose 发送关闭帧, 之后将不能再发送任何消息, 但仍可继续读取直至收到对端的关闭帧
+func (w *Conn) WriteClose(code int, reason string) error {
+	if len(reason) > maxControlPayloadLen-2 {
+		reason = reason[:maxControlPayloadLen-2]
+	}
+	payload := make([]byte, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	copy(payload[2:], reason)
+
+	return w.writeFrame(CloseMessage, payload)
+}
+
+// Close 发送正常关闭帧(如果尚未发送)并关闭底层连接
+func (w *Conn) Close() error {
+	_ = w.WriteClose(CloseNormalClosure, "")
+	return w.conn.Close()
+}
+
+func (w *Conn) writeFrame(opcode int, payload []byte) error {
+	w.wmu.Lock()
+	defer w.wmu.Unlock()
+
+	if w.closeSent {
+		return ErrCloseSent
+	}
+
+	// 服务端发送的帧不需要掩码
+	header := make([]byte, 2, 10+len(payload))
+	header[0] = 0x80 | byte(opcode) // FIN
+	length := len(payload)
+	switch {
+	case length <= maxControlPayloadLen:
+		header[1] = byte(length)
+	case length <= 0xffff:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+
+	if opcode == CloseMessage {
+		w.closeSent = true
+	}
+	_, err := w.conn.Write(append(header, payload...))
+	return err
+}
+
+func (w *Conn) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	var head [8]byte
+	if _, err = io.ReadFull(w.br, head[:2]); err != nil {
+		return
+	}
+
+	fin = head[0]&0x80 != 0
+	opcode = int(head[0] & 0x0f)
+	masked := head[1]&0x80 != 0
+	length := int64(head[1] & 0x7f)
+
+	if head[0]&0x70 != 0 {
+		return fin, opcode, nil, protocolError("unexpected reserved bits")
+	}
+	if !masked {
+		return fin, opcode, nil, protocolError("client frame must be masked")
+	}
+
+	switch length {
+	case 126:
+		if _, err = io.ReadFull(w.br, head[:2]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint16(head[:2]))
+	case 127:
+		if _, err = io.ReadFull(w.br, head[:8]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint64(head[:8]))
+		if length < 0 {
+			return fin, opcode, nil, protocolError("invalid payload length")
+		}
+	}
+
+	if opcode >= CloseMessage && (length > maxControlPayloadLen || !fin) {
+		return fin, opcode, nil, protocolError("invalid control frame")
+	}
+	if length > w.readLimit {
+		return fin, opcode, nil, ErrReadLimit
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(w.br, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(w.br, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return
+}
+
+// 对端可以发送的关闭码, 1005, 1006, 1015 等保留的关闭码不能出现在关闭帧中
+func validCloseCode(code int) bool {
+	switch {
+	case code >= CloseNormalClosure && code <= CloseUnsupportedData:
+		return true
+	case code >= CloseInvalidPayloadData && code <= 1014:
+		return true
+	case code >= 3000 && code <= 4999: // 由框架和应用定义
+		return true
+	}
+	return false
+}
+
+// ================================ 握手 ================================
+
+// AcceptKey 依据请求头 Sec-WebSocket-Key 计算响应头 Sec-WebSocket-Accept
+func AcceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// Request 握手请求, fastapi.MuxContext 实现了此接口
+type Request interface {
+	Method() string
+	GetHeader(key string) string
+}
+
+// CheckHandshake 校验握手请求, 校验通过时返回响应头 Sec-WebSocket-Accept 的值
+func CheckHandshake(c Request) (acceptKey string, err error) {
+	if c.Method() != http.MethodGet {
+		return "", fmt.Errorf("%w: method '%s' not allowed", ErrBadHandshake, c.Method())
+	}
+	if !headerContainsToken(c.GetHeader("Connection"), "upgrade") {
+		return "", fmt.Errorf("%w: 'Connection' header missing 'upgrade' token", ErrBadHandshake)
+	}
+	if !headerContainsToken(c.GetHeader("Upgrade"), "websocket") {
+		return "", fmt.Errorf("%w: 'Upgrade' header missing 'websocket' token", ErrBadHandshake)
+	}
+	if c.GetHeader("Sec-WebSocket-Version") != websocketVersion {
+		return "", fmt.Errorf("%w: unsupported version", ErrBadHandshake)
+	}
+
+	key := c.GetHeader("Sec-WebSocket-Key")
+	if decoded, e := base64.StdEncoding.DecodeString(key); e != nil || len(decoded) != 16 {
+		return "", fmt.Errorf("%w: invalid 'Sec-WebSocket-Key'", ErrBadHandshake)
+	}
+
+	return AcceptKey(key), nil
+}
+
+// UpgradeHTTP 为基于 net/http 的路由器完成 websocket 握手, w 必须实现 http.Hijacker
+//
+//	握手响应会携带 w 中已设置的响应头(比如在依赖函数中设置的cookie)
+func UpgradeHTTP(c Request, w http.ResponseWriter) (*Conn, error) {
+	accept, err := CheckHandshake(c)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	header := w.Header().Clone()
+	header.Set("Upgrade", "websocket")
+	header.Set("Connection", "Upgrade")
+	header.Set("Sec-WebSocket-Accept", accept)
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+
+	_, _ = brw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	_ = header.Write(brw)
+	_, _ = brw.WriteString("\r\n")
+	if err = brw.Flush(); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return NewConn(conn, brw.Reader), nil
+}
+
+// 判断以逗号分隔的请求头中是否包含 token, 不区分大小写
+func headerContainsToken(value, token string) bool {
+	for _, v := range strings.Split(value, ",") {
+		if strings.EqualFold(strings.TrimSpace(v), token) {
+			return true
+		}
+	}
+	return false
+}
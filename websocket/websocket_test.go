@@ -0,0 +1,227 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+// 构造一个客户端(带掩码)帧
+func clientFrame(fin bool, opcode int, payload []byte) []byte {
+	b0 := byte(opcode)
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0}
+	switch {
+	case len(payload) <= 125:
+		frame = append(frame, 0x80|byte(len(payload)))
+	case len(payload) <= 0xffff:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+	mask := []byte{0x12, 0x34, 0x56, 0x78}
+	frame = append(frame, mask...)
+	for i, v := range payload {
+		frame = append(frame, v^mask[i%4])
+	}
+	return frame
+}
+
+// 读取一个服务端(无掩码)帧
+func readServerFrame(t *testing.T, r *bufio.Reader) (opcode int, payload []byte) {
+	t.Helper()
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(r, head); err != nil {
+		t.Fatal(err)
+	}
+	if head[1]&0x80 != 0 {
+		t.Fatal("server frame must not be masked")
+	}
+	length := int(head[1] & 0x7f)
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		_, _ = io.ReadFull(r, ext)
+		length = int(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		_, _ = io.ReadFull(r, ext)
+		length = int(binary.BigEndian.Uint64(ext))
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatal(err)
+	}
+	return int(head[0] & 0x0f), payload
+}
+
+func newWebSocketPipe() (*Conn, net.Conn, *bufio.Reader) {
+	server, client := net.Pipe()
+	return NewConn(server, nil), client, bufio.NewReader(client)
+}
+
+func TestAcceptKey(t *testing.T) {
+	// RFC 6455 1.3
+	if got := AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("AcceptKey() = %s", got)
+	}
+}
+
+func TestConn_ReadMessage(t *testing.T) {
+	conn, client, reader := newWebSocketPipe()
+	defer client.Close()
+
+	long := make([]byte, 70000)
+	go func() {
+		_, _ = client.Write(clientFrame(true, TextMessage, []byte("hello")))
+		// 分片消息中间插入 ping
+		_, _ = client.Write(clientFrame(false, BinaryMessage, []byte("ab")))
+		_, _ = client.Write(clientFrame(true, PingMessage, []byte("p")))
+		_, _ = client.Write(clientFrame(true, continuationFrame, []byte("cd")))
+		_, _ = client.Write(clientFrame(true, BinaryMessage, long))
+		_, _ = client.Write(clientFrame(true, CloseMessage, []byte{0x03, 0xe8}))
+	}()
+
+	mt, p, err := conn.ReadMessage()
+	if err != nil || mt != TextMessage || string(p) != "hello" {
+		t.Fatalf("ReadMessage() = %d, %s, %v", mt, p, err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if op, payload := readServerFrame(t, reader); op != PongMessage || string(payload) != "p" {
+			t.Errorf("pong = %d, %s", op, payload)
+		}
+	}()
+	mt, p, err = conn.ReadMessage()
+	if err != nil || mt != BinaryMessage || string(p) != "abcd" {
+		t.Fatalf("ReadMessage() = %d, %s, %v", mt, p, err)
+	}
+	<-done
+
+	mt, p, err = conn.ReadMessage()
+	if err != nil || len(p) != len(long) {
+		t.Fatalf("ReadMessage() = %d, %d, %v", mt, len(p), err)
+	}
+
+	go func() {
+		if op, payload := readServerFrame(t, reader); op != CloseMessage || binary.BigEndian.Uint16(payload) != CloseNormalClosure {
+			t.Errorf("close = %d, %v", op, payload)
+		}
+	}()
+	_, _, err = conn.ReadMessage()
+	var ce *CloseError
+	if !errors.As(err, &ce) || ce.Code != CloseNormalClosure {
+		t.Fatalf("ReadMessage() err = %v", err)
+	}
+	if err = conn.WriteMessage(TextMessage, []byte("x")); !errors.Is(err, ErrCloseSent) {
+		t.Errorf("WriteMessage() after close err = %v", err)
+	}
+}
+
+func TestConn_ProtocolError(t *testing.T) {
+	conn, client, reader := newWebSocketPipe()
+	defer client.Close()
+
+	go func() {
+		// 客户端帧未掩码
+		_, _ = client.Write([]byte{0x81, 0x01, 'a'})
+		if op, payload := readServerFrame(t, reader); op != CloseMessage || binary.BigEndian.Uint16(payload) != CloseProtocolError {
+			t.Errorf("close = %d, %v", op, payload)
+		}
+	}()
+
+	if _, _, err := conn.ReadMessage(); err == nil {
+		t.Error("ReadMessage() should return protocol error")
+	}
+}
+
+func TestConn_WriteJSON(t *testing.T) {
+	conn, client, reader := newWebSocketPipe()
+	defer client.Close()
+
+	go func() {
+		_ = conn.WriteJSON(map[string]int{"a": 1})
+	}()
+	op, payload := readServerFrame(t, reader)
+	if op != TextMessage || string(payload) != `{"a":1}` {
+		t.Errorf("WriteJSON() = %d, %s", op, payload)
+	}
+}
+
+func TestConn_ReadLimit(t *testing.T) {
+	conn, client, reader := newWebSocketPipe()
+	defer client.Close()
+	conn.SetReadLimit(4)
+
+	go func() {
+		// 超出限制的分片消息
+		_, _ = client.Write(clientFrame(false, TextMessage, []byte("abc")))
+		_, _ = client.Write(clientFrame(true, continuationFrame, []byte("de")))
+		if op, payload := readServerFrame(t, reader); op != CloseMessage || binary.BigEndian.Uint16(payload) != CloseMessageTooBig {
+			t.Errorf("close = %d, %v", op, payload)
+		}
+	}()
+
+	if _, _, err := conn.ReadMessage(); !errors.Is(err, ErrReadLimit) {
+		t.Errorf("ReadMessage() err = %v", err)
+	}
+}
+
+func TestConn_InvalidFrames(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames [][]byte
+		code   int
+	}{
+		{"fragmented-control", [][]byte{clientFrame(false, PingMessage, nil)}, CloseProtocolError},
+		{"long-control", [][]byte{clientFrame(true, PingMessage, make([]byte, 126))}, CloseProtocolError},
+		{"unexpected-continuation", [][]byte{clientFrame(true, continuationFrame, []byte("a"))}, CloseProtocolError},
+		{"interleaved-data", [][]byte{
+			clientFrame(false, TextMessage, []byte("a")),
+			clientFrame(true, TextMessage, []byte("b")),
+		}, CloseProtocolError},
+		{"invalid-utf8", [][]byte{clientFrame(true, TextMessage, []byte{0xff})}, CloseInvalidPayloadData},
+		{"close-one-byte", [][]byte{clientFrame(true, CloseMessage, []byte{0x03})}, CloseProtocolError},
+		{"close-reserved-code", [][]byte{clientFrame(true, CloseMessage, []byte{0x03, 0xed})}, CloseProtocolError},
+		{"close-invalid-reason", [][]byte{clientFrame(true, CloseMessage, []byte{0x03, 0xe8, 0xff})}, CloseProtocolError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, client, reader := newWebSocketPipe()
+			defer client.Close()
+
+			go func() {
+				for _, frame := range tt.frames {
+					_, _ = client.Write(frame)
+				}
+				if op, payload := readServerFrame(t, reader); op != CloseMessage || int(binary.BigEndian.Uint16(payload)) != tt.code {
+					t.Errorf("close = %d, %v", op, payload)
+				}
+			}()
+
+			if _, _, err := conn.ReadMessage(); err == nil {
+				t.Error("ReadMessage() should return error")
+			}
+		})
+	}
+}
+
+func TestValidCloseCode(t *testing.T) {
+	for code, want := range map[int]bool{
+		999: false, 1000: true, 1003: true, 1004: false, 1005: false, 1006: false,
+		1007: true, 1011: true, 1014: true, 1015: false, 2999: false, 3000: true, 4999: true, 5000: false,
+	} {
+		if got := validCloseCode(code); got != want {
+			t.Errorf("validCloseCode(%d) = %v", code, got)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package fastapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+	"unicode"
+
+	"github.com/Chendemo12/fastapi/openapi"
+	"github.com/Chendemo12/fastapi/websocket"
+)
+
+// websocket 路由第二个入参的类型
+var webSocketConnType = reflect.TypeOf(&WebSocketConn{})
+
+// WebSocketRoute 路由组中的 websocket 路由
+//
+// 方法名以 WS 开头或结尾, 且签名为 func(c *Context, conn *WebSocketConn) error 的方法会被作为 websocket 路由,
+// 例如: ChatWS(c *Context, conn *WebSocketConn) error
+//
+// websocket 路由没有查询参数和请求体, 但支持路径参数, 并且会执行 UsePrevious 和 Use 注册的依赖函数以及路由组的依赖函数,
+// 当依赖函数返回错误时将不会升级协议, 而是按照 RouteErrorFormatter 返回错误信息.
+// 方法返回后连接将被关闭, 若返回了错误或发生了panic则以 websocket.CloseInternalServerErr 关闭;
+// 协议升级之后 Context 中的请求信息来自握手请求的副本, 不可再写入响应
+type WebSocketRoute struct {
+	swagger     *openapi.RouteSwagger
+	group       *GroupRouterMeta
+	method      reflect.Method
+	nothing     ModelBinder
+	pathBinders []ModelBinder
+	deps        []DependenceHandle
+}
+
+func NewWebSocketRoute(swagger *openapi.RouteSwagger, method reflect.Method, group *GroupRouterMeta) *WebSocketRoute {
+	r := &WebSocketRoute{}
+	r.swagger = swagger
+	r.method = method
+	r.group = group
+	r.nothing = &NothingModelBinder{modelName: "", paramType: openapi.RouteParamRequest}
+
+	return r
+}
+
+func (r *WebSocketRoute) Id() string { return r.swagger.Id() }
+
+func (r *WebSocketRoute) Init() (err error) { return r.Scan() }
+
+func (r *WebSocketRoute) Scan() (err error) { return r.ScanInner() }
+
+// ScanInner 解析内部 openapi.RouteSwagger 数据, 仅存在路径参数
+func (r *WebSocketRoute) ScanInner() (err error) {
+	err = r.swagger.Init()
+	if err != nil {
+		return err
+	}
+	r.pathBinders = scanHelper.InferPathBinders(r.swagger.PathFields, r.RouteType())
+	return nil
+}
+
+func (r *WebSocketRoute) RouteType() RouteType { return RouteTypeGroup }
+
+func (r *WebSocketRoute) Swagger() *openapi.RouteSwagger { return r.swagger }
+
+func (r *WebSocketRoute) Dependencies() []DependenceHandle { return r.deps }
+
+// Provide websocket 路由的入参固定, 不存在依赖注入
+func (r *WebSocketRoute) Provide(ctx *Context) error { return nil }
+
+func (r *WebSocketRoute) PathBinders() []ModelBinder { return r.pathBinders }
+
+func (r *WebSocketRoute) QueryBinders() []ModelBinder { return []ModelBinder{} }
+
+func (r *WebSocketRoute) QueryDefaults() []any { return []any{} }
+
+func (r *WebSocketRoute) RequestBinders() ModelBinder { return r.nothing }
+
+func (r *WebSocketRoute) ResponseBinder() ModelBinder { return r.nothing }
+
+// NewInParams 仅包含接收器和 Context, 连接参数需在协议升级之后追加
+func (r *WebSocketRoute) NewInParams(ctx *Context) []reflect.Value {
+	return []reflect.Value{r.group.routerValue, reflect.ValueOf(ctx)}
+}
+
+func (r *WebSocketRoute) NewStructQueries() []any { return nil }
+
+func (r *WebSocketRoute) NewRequestModel() any { return nil }
+
+func (r *WebSocketRoute) HasStructQuery() bool { return false }
+
+func (r *WebSocketRoute) HasFileRequest() bool { return false }
+
+func (r *WebSocketRoute) Call(in []reflect.Value) []reflect.Value {
+	return r.method.Func.Call(in)
+}
+
+// 判断一个方法是不是 websocket 路由
+func (r *GroupRouterMeta) isWebSocketMethod(method reflect.Method) (*openapi.RouteSwagger, bool) {
+	name := method.Name
+	offset := len(WebsocketMethod)
+	if len(name) <= offset || unicode.IsLower([]rune(name)[0]) {
+		return nil, false
+	}
+
+	swagger := &openapi.RouteSwagger{Method: WebsocketMethod}
+	switch {
+	case name[:offset] == WebsocketMethod: // 区分大小写, 以免 News, Views 等方法被误认为 websocket 路由
+		swagger.RelativePath = name[offset:]
+	case name[len(name)-offset:] == WebsocketMethod:
+		swagger.RelativePath = name[:len(name)-offset]
+	default:
+		return nil, false
+	}
+
+	// receiver + Context + WebSocketConn, 返回值仅有 error
+	if method.Type.NumIn() != FirstCustomInParamOffset+1 || method.Type.NumOut() != 1 {
+		return nil, false
+	}
+
+	ctxParam := method.Type.In(FirstInParamOffset)
+	if ctxParam.Kind() != reflect.Pointer || ctxParam.Elem().Name() != FirstInParamName ||
+		method.Type.In(FirstCustomInParamOffset) != webSocketConnType ||
+		method.Type.Out(0).Name() != LastOutParamName {
+		return nil, false
+	}
+
+	return swagger, true
+}
+
+// WebSocketHandler websocket 路由的 MuxHandler, 通过 WebSocketMuxWrapper.BindWebSocket 注册
+//
+//  1. 申请一个 Context, 执行 UsePrevious 依赖函数, 校验路径参数, 执行 Use 依赖函数和路由组的依赖函数, 任一环节失败则返回错误响应
+//  2. 通过 WebSocketUpgrader 升级协议, 握手失败则返回400
+//  3. 升级成功后调用路由方法, 方法返回后释放 Context 并关闭连接
+//
+// 升级之前发生panic时与 Handler 一致返回500响应; 升级之后发生panic时执行 Wrapper.OnPanic 钩子并以 CloseInternalServerErr 关闭连接
+func (f *Wrapper) WebSocketHandler(ctx MuxContext) (err error) {
+	route, exist := f.finder.Get(openapi.CreateRouteIdentify(WebsocketMethod, ctx.Path()))
+	if !exist {
+		return nil
+	}
+
+	upgrader, ok := ctx.(WebSocketUpgrader)
+	if !ok {
+		return errors.New("mux context does not implement fastapi.WebSocketUpgrader")
+	}
+
+	wrapperCtx := f.acquireCtx(ctx, route)
+	upgraded := false
+	defer func() {
+		if !upgraded { // 升级成功后由连接处理函数释放
+			f.releaseCtx(wrapperCtx)
+		}
+	}()
+	defer func() {
+		if v := recover(); v != nil {
+			err = f.recoverPanic(wrapperCtx, route, v)
+		}
+	}()
+
+	for _, dep := range f.previousDeps {
+		err = dep(wrapperCtx)
+		if err != nil {
+			wrapperCtx.response.StatusCode, wrapperCtx.response.Content = f.routeErrorFormatter(wrapperCtx, err)
+			return f.write(wrapperCtx, route, openapi.MIMEApplicationJSONCharsetUTF8)
+		}
+	}
+
+	if wrapperCtx.beforeWorkflow(route, f.conf.StopImmediatelyWhenErrorOccurs) {
+		return f.write(wrapperCtx, route, openapi.MIMEApplicationJSONCharsetUTF8)
+	}
+
+	for _, dep := range f.afterDeps {
+		err = dep(wrapperCtx)
+		if err != nil {
+			wrapperCtx.response.StatusCode, wrapperCtx.response.Content = f.routeErrorFormatter(wrapperCtx, err)
+			return f.write(wrapperCtx, route, openapi.MIMEApplicationJSONCharsetUTF8)
+		}
+	}
+
+	for _, dep := range route.Dependencies() {
+		err = dep(wrapperCtx)
+		if err != nil {
+			wrapperCtx.response.StatusCode, wrapperCtx.response.Content = f.routeErrorFormatter(wrapperCtx, err)
+			return f.write(wrapperCtx, route, openapi.MIMEApplicationJSONCharsetUTF8)
+		}
+	}
+
+	// fiber 等路由器在升级之后才执行 handler, 此时 MuxContext 已被回收, 因此需提前复制请求信息
+	detached := newDetachedMuxContext(ctx, upgrader.HandshakeRequest(), wrapperCtx.pathFields)
+	err = upgrader.UpgradeWebSocket(func(conn *WebSocketConn) {
+		wrapperCtx.muxCtx = detached
+		wrapperCtx.written = true // 协议已升级, 不可再写入响应
+		defer func() {
+			if v := recover(); v != nil {
+				f.recoverWebSocketPanic(wrapperCtx, conn, v)
+			}
+			_ = conn.Close()
+			if wrapperCtx.routeCancel != nil {
+				wrapperCtx.routeCancel()
+			}
+			f.releaseCtx(wrapperCtx)
+		}()
+
+		params := append(route.NewInParams(wrapperCtx), reflect.ValueOf(conn))
+		result := route.Call(params)
+		if last := result[0]; last.IsValid() && !last.IsNil() {
+			e := last.Interface().(error)
+			structured.Warn("websocket closed with error",
+				"method", WebsocketMethod, "path", route.Swagger().Url, "route", route.Id(), "error", e.Error())
+			_ = conn.WriteClose(websocket.CloseInternalServerErr, e.Error())
+		}
+	})
+	if err != nil {
+		// 握手失败, 尚未写入任何响应
+		wrapperCtx.response.StatusCode = http.StatusBadRequest
+		wrapperCtx.response.StatusCode, wrapperCtx.response.Content = f.routeErrorFormatter(wrapperCtx, err)
+		return f.write(wrapperCtx, route, openapi.MIMEApplicationJSONCharsetUTF8)
+	}
+	upgraded = true
+
+	return nil
+}
+
+// 处理 websocket 连接中发生的panic, 执行钩子并以 CloseInternalServerErr 关闭连接;
+// fiber 等路由器在独立的协程中处理连接且不会恢复panic, 因此 http.ErrAbortHandler 同样仅关闭连接而不再抛出
+func (f *Wrapper) recoverWebSocketPanic(c *Context, conn *WebSocketConn, v any) {
+	if v != http.ErrAbortHandler {
+		f.onPanic(c, v, debug.Stack())
+	}
+	_ = conn.WriteClose(websocket.CloseInternalServerErr, "")
+}
+
+// 绑定 websocket 路由到路由器上, 路由器需实现 WebSocketMuxWrapper
+func (f *Wrapper) bindWebSocketRoute(route *WebSocketRoute) error {
+	mux, ok := f.mux.(WebSocketMuxWrapper)
+	if !ok {
+		return fmt.Errorf("mux '%T' does not implement fastapi.WebSocketMuxWrapper", f.mux)
+	}
+
+	return mux.BindWebSocket(route.Swagger().Url, f.WebSocketHandler)
+}